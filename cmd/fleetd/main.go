@@ -1,17 +1,26 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"fleetd.sh/internal/agent"
+	"fleetd.sh/internal/version"
 )
 
 func main() {
 	// Parse flags first
+	showVersion := flag.Bool("version", false, "Print the version and exit")
 	cfg := agent.ParseFlags()
+	if *showVersion {
+		// Also run against a new binary before a self-update swaps it in
+		fmt.Println(version.GetVersion())
+		return
+	}
 
 	a := agent.New(cfg)
 	if err := a.Start(); err != nil {
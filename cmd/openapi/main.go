@@ -0,0 +1,24 @@
+// Command openapi writes the OpenAPI document of the fleetd platform API,
+// generated from the Connect service definitions.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"fleetd.sh/internal/openapi"
+)
+
+func main() {
+	output := flag.String("o", openapi.DefaultPath, "file to write the document to")
+	flag.Parse()
+
+	data, err := openapi.MarshalFleetd()
+	if err != nil {
+		log.Fatalf("Failed to generate OpenAPI document: %v", err)
+	}
+	if err := os.WriteFile(*output, data, 0644); err != nil {
+		log.Fatalf("Failed to write %s: %v", *output, err)
+	}
+}
@@ -7,20 +7,56 @@ import (
 	"strings"
 	"time"
 
+	"fleetd.sh/internal/config"
+
 	_ "modernc.org/sqlite"
 )
 
-// New creates a new database connection with optimized settings
+// PoolConfig configures the database connection pool
+type PoolConfig struct {
+	MaxOpenConns    int           // Maximum open connections, 0 for unlimited
+	MaxIdleConns    int           // Maximum idle connections kept for reuse
+	ConnMaxLifetime time.Duration // Maximum time a connection is reused, 0 for forever
+}
+
+// DefaultPoolConfig returns a PoolConfig with default values
+func DefaultPoolConfig() PoolConfig {
+	return PoolConfig{
+		MaxOpenConns:    25,
+		MaxIdleConns:    25,
+		ConnMaxLifetime: 5 * time.Minute,
+	}
+}
+
+// PoolConfigFromEnv builds a PoolConfig from DB_MAX_OPEN_CONNS,
+// DB_MAX_IDLE_CONNS and DB_CONN_MAX_LIFETIME, using defaults for unset values
+func PoolConfigFromEnv() PoolConfig {
+	cfg := DefaultPoolConfig()
+	return PoolConfig{
+		MaxOpenConns:    config.GetIntFromEnv("DB_MAX_OPEN_CONNS", cfg.MaxOpenConns),
+		MaxIdleConns:    config.GetIntFromEnv("DB_MAX_IDLE_CONNS", cfg.MaxIdleConns),
+		ConnMaxLifetime: config.GetDurationFromEnv("DB_CONN_MAX_LIFETIME", cfg.ConnMaxLifetime),
+	}
+}
+
+// New creates a new database connection with optimized settings and a
+// connection pool configured from the environment
 func New(path string) (*sql.DB, error) {
+	return NewWithPool(path, PoolConfigFromEnv())
+}
+
+// NewWithPool creates a new database connection with optimized settings and
+// the given connection pool configuration
+func NewWithPool(path string, pool PoolConfig) (*sql.DB, error) {
 	db, err := sql.Open("sqlite", path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
 	// Configure connection pool
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(25)
-	db.SetConnMaxLifetime(5 * time.Minute)
+	db.SetMaxOpenConns(pool.MaxOpenConns)
+	db.SetMaxIdleConns(pool.MaxIdleConns)
+	db.SetConnMaxLifetime(pool.ConnMaxLifetime)
 
 	// Set pragmas for performance
 	pragmas := []string{
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"fleetd.sh/internal/telemetry"
+)
+
+func TestPoolSaturation(t *testing.T) {
+	db, err := NewWithPool(filepath.Join(t.TempDir(), "test.db"), PoolConfig{
+		MaxOpenConns:    2,
+		MaxIdleConns:    2,
+		ConnMaxLifetime: time.Minute,
+	})
+	require.NoError(t, err)
+	defer db.Close()
+
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer provider.Shutdown(context.Background())
+	registration, err := telemetry.RegisterDBPoolMetrics(provider, db, "test")
+	require.NoError(t, err)
+	defer registration.Unregister()
+
+	_, err = db.Exec("CREATE TABLE item (id INTEGER PRIMARY KEY)")
+	require.NoError(t, err)
+
+	// More concurrent transactions than connections have to wait their turn
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tx, err := db.BeginTx(ctx, nil)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer tx.Rollback()
+			var n int
+			assert.NoError(t, tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM item").Scan(&n))
+			time.Sleep(10 * time.Millisecond)
+		}()
+	}
+	wg.Wait()
+
+	stats := db.Stats()
+	assert.Greater(t, stats.WaitCount, int64(0))
+	assert.Greater(t, stats.WaitDuration, time.Duration(0))
+	assert.LessOrEqual(t, stats.OpenConnections, 2)
+
+	// Every connection is returned once the load subsides
+	assert.Equal(t, 0, stats.InUse)
+	assert.Equal(t, stats.OpenConnections, stats.Idle)
+
+	// The pool instruments report the same
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(ctx, &rm))
+	assert.Greater(t, collectedInt64(t, rm, "db_pool_wait_total"), int64(0))
+	assert.Equal(t, int64(0), collectedInt64(t, rm, "db_pool_in_use_connections"))
+	assert.Equal(t, int64(stats.Idle), collectedInt64(t, rm, "db_pool_idle_connections"))
+}
+
+// collectedInt64 returns the value of the single data point of an int64
+// gauge or counter
+func collectedInt64(t *testing.T, rm metricdata.ResourceMetrics, name string) int64 {
+	t.Helper()
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+			var points []metricdata.DataPoint[int64]
+			switch data := m.Data.(type) {
+			case metricdata.Gauge[int64]:
+				points = data.DataPoints
+			case metricdata.Sum[int64]:
+				points = data.DataPoints
+			}
+			require.Len(t, points, 1, "data points of %s", name)
+			return points[0].Value
+		}
+	}
+	t.Fatalf("Metric %s was not collected", name)
+	return 0
+}
+
+func TestPoolConfigFromEnv(t *testing.T) {
+	t.Setenv("DB_MAX_OPEN_CONNS", "100")
+	t.Setenv("DB_CONN_MAX_LIFETIME", "1m")
+
+	cfg := PoolConfigFromEnv()
+	assert.Equal(t, 100, cfg.MaxOpenConns)
+	assert.Equal(t, DefaultPoolConfig().MaxIdleConns, cfg.MaxIdleConns)
+	assert.Equal(t, time.Minute, cfg.ConnMaxLifetime)
+}
@@ -45,6 +45,18 @@ const (
 	// DaemonServiceListBinariesProcedure is the fully-qualified name of the DaemonService's
 	// ListBinaries RPC.
 	DaemonServiceListBinariesProcedure = "/agent.v1.DaemonService/ListBinaries"
+	// DaemonServiceStreamLogsProcedure is the fully-qualified name of the DaemonService's StreamLogs
+	// RPC.
+	DaemonServiceStreamLogsProcedure = "/agent.v1.DaemonService/StreamLogs"
+	// DaemonServiceInstallImageProcedure is the fully-qualified name of the DaemonService's
+	// InstallImage RPC.
+	DaemonServiceInstallImageProcedure = "/agent.v1.DaemonService/InstallImage"
+	// DaemonServiceCommitUpdateProcedure is the fully-qualified name of the DaemonService's
+	// CommitUpdate RPC.
+	DaemonServiceCommitUpdateProcedure = "/agent.v1.DaemonService/CommitUpdate"
+	// DaemonServiceUpdateAgentProcedure is the fully-qualified name of the DaemonService's UpdateAgent
+	// RPC.
+	DaemonServiceUpdateAgentProcedure = "/agent.v1.DaemonService/UpdateAgent"
 )
 
 // These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
@@ -54,6 +66,10 @@ var (
 	daemonServiceStartBinaryMethodDescriptor  = daemonServiceServiceDescriptor.Methods().ByName("StartBinary")
 	daemonServiceStopBinaryMethodDescriptor   = daemonServiceServiceDescriptor.Methods().ByName("StopBinary")
 	daemonServiceListBinariesMethodDescriptor = daemonServiceServiceDescriptor.Methods().ByName("ListBinaries")
+	daemonServiceStreamLogsMethodDescriptor   = daemonServiceServiceDescriptor.Methods().ByName("StreamLogs")
+	daemonServiceInstallImageMethodDescriptor = daemonServiceServiceDescriptor.Methods().ByName("InstallImage")
+	daemonServiceCommitUpdateMethodDescriptor = daemonServiceServiceDescriptor.Methods().ByName("CommitUpdate")
+	daemonServiceUpdateAgentMethodDescriptor  = daemonServiceServiceDescriptor.Methods().ByName("UpdateAgent")
 )
 
 // DaemonServiceClient is a client for the agent.v1.DaemonService service.
@@ -63,6 +79,17 @@ type DaemonServiceClient interface {
 	StartBinary(context.Context, *connect.Request[v1.StartBinaryRequest]) (*connect.Response[v1.StartBinaryResponse], error)
 	StopBinary(context.Context, *connect.Request[v1.StopBinaryRequest]) (*connect.Response[v1.StopBinaryResponse], error)
 	ListBinaries(context.Context, *connect.Request[v1.ListBinariesRequest]) (*connect.Response[v1.ListBinariesResponse], error)
+	// Log tailing
+	StreamLogs(context.Context, *connect.Request[v1.StreamLogsRequest]) (*connect.ServerStreamForClient[v1.StreamLogsResponse], error)
+	// Downloads a system image to the inactive slot of an A/B device and sets
+	// it to be tried on the next boot
+	InstallImage(context.Context, *connect.Request[v1.InstallImageRequest]) (*connect.Response[v1.InstallImageResponse], error)
+	// Keeps the slot an A/B device booted after an update. Until then the
+	// bootloader reverts to the previous slot on the next boot.
+	CommitUpdate(context.Context, *connect.Request[v1.CommitUpdateRequest]) (*connect.Response[v1.CommitUpdateResponse], error)
+	// Downloads a new agent binary and restarts into it. Requires the agent
+	// to be started with -self-update.
+	UpdateAgent(context.Context, *connect.Request[v1.UpdateAgentRequest]) (*connect.Response[v1.UpdateAgentResponse], error)
 }
 
 // NewDaemonServiceClient constructs a client for the agent.v1.DaemonService service. By default, it
@@ -99,6 +126,30 @@ func NewDaemonServiceClient(httpClient connect.HTTPClient, baseURL string, opts
 			connect.WithSchema(daemonServiceListBinariesMethodDescriptor),
 			connect.WithClientOptions(opts...),
 		),
+		streamLogs: connect.NewClient[v1.StreamLogsRequest, v1.StreamLogsResponse](
+			httpClient,
+			baseURL+DaemonServiceStreamLogsProcedure,
+			connect.WithSchema(daemonServiceStreamLogsMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		installImage: connect.NewClient[v1.InstallImageRequest, v1.InstallImageResponse](
+			httpClient,
+			baseURL+DaemonServiceInstallImageProcedure,
+			connect.WithSchema(daemonServiceInstallImageMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		commitUpdate: connect.NewClient[v1.CommitUpdateRequest, v1.CommitUpdateResponse](
+			httpClient,
+			baseURL+DaemonServiceCommitUpdateProcedure,
+			connect.WithSchema(daemonServiceCommitUpdateMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		updateAgent: connect.NewClient[v1.UpdateAgentRequest, v1.UpdateAgentResponse](
+			httpClient,
+			baseURL+DaemonServiceUpdateAgentProcedure,
+			connect.WithSchema(daemonServiceUpdateAgentMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
 	}
 }
 
@@ -108,6 +159,10 @@ type daemonServiceClient struct {
 	startBinary  *connect.Client[v1.StartBinaryRequest, v1.StartBinaryResponse]
 	stopBinary   *connect.Client[v1.StopBinaryRequest, v1.StopBinaryResponse]
 	listBinaries *connect.Client[v1.ListBinariesRequest, v1.ListBinariesResponse]
+	streamLogs   *connect.Client[v1.StreamLogsRequest, v1.StreamLogsResponse]
+	installImage *connect.Client[v1.InstallImageRequest, v1.InstallImageResponse]
+	commitUpdate *connect.Client[v1.CommitUpdateRequest, v1.CommitUpdateResponse]
+	updateAgent  *connect.Client[v1.UpdateAgentRequest, v1.UpdateAgentResponse]
 }
 
 // DeployBinary calls agent.v1.DaemonService.DeployBinary.
@@ -130,6 +185,26 @@ func (c *daemonServiceClient) ListBinaries(ctx context.Context, req *connect.Req
 	return c.listBinaries.CallUnary(ctx, req)
 }
 
+// StreamLogs calls agent.v1.DaemonService.StreamLogs.
+func (c *daemonServiceClient) StreamLogs(ctx context.Context, req *connect.Request[v1.StreamLogsRequest]) (*connect.ServerStreamForClient[v1.StreamLogsResponse], error) {
+	return c.streamLogs.CallServerStream(ctx, req)
+}
+
+// InstallImage calls agent.v1.DaemonService.InstallImage.
+func (c *daemonServiceClient) InstallImage(ctx context.Context, req *connect.Request[v1.InstallImageRequest]) (*connect.Response[v1.InstallImageResponse], error) {
+	return c.installImage.CallUnary(ctx, req)
+}
+
+// CommitUpdate calls agent.v1.DaemonService.CommitUpdate.
+func (c *daemonServiceClient) CommitUpdate(ctx context.Context, req *connect.Request[v1.CommitUpdateRequest]) (*connect.Response[v1.CommitUpdateResponse], error) {
+	return c.commitUpdate.CallUnary(ctx, req)
+}
+
+// UpdateAgent calls agent.v1.DaemonService.UpdateAgent.
+func (c *daemonServiceClient) UpdateAgent(ctx context.Context, req *connect.Request[v1.UpdateAgentRequest]) (*connect.Response[v1.UpdateAgentResponse], error) {
+	return c.updateAgent.CallUnary(ctx, req)
+}
+
 // DaemonServiceHandler is an implementation of the agent.v1.DaemonService service.
 type DaemonServiceHandler interface {
 	// Binary management
@@ -137,6 +212,17 @@ type DaemonServiceHandler interface {
 	StartBinary(context.Context, *connect.Request[v1.StartBinaryRequest]) (*connect.Response[v1.StartBinaryResponse], error)
 	StopBinary(context.Context, *connect.Request[v1.StopBinaryRequest]) (*connect.Response[v1.StopBinaryResponse], error)
 	ListBinaries(context.Context, *connect.Request[v1.ListBinariesRequest]) (*connect.Response[v1.ListBinariesResponse], error)
+	// Log tailing
+	StreamLogs(context.Context, *connect.Request[v1.StreamLogsRequest], *connect.ServerStream[v1.StreamLogsResponse]) error
+	// Downloads a system image to the inactive slot of an A/B device and sets
+	// it to be tried on the next boot
+	InstallImage(context.Context, *connect.Request[v1.InstallImageRequest]) (*connect.Response[v1.InstallImageResponse], error)
+	// Keeps the slot an A/B device booted after an update. Until then the
+	// bootloader reverts to the previous slot on the next boot.
+	CommitUpdate(context.Context, *connect.Request[v1.CommitUpdateRequest]) (*connect.Response[v1.CommitUpdateResponse], error)
+	// Downloads a new agent binary and restarts into it. Requires the agent
+	// to be started with -self-update.
+	UpdateAgent(context.Context, *connect.Request[v1.UpdateAgentRequest]) (*connect.Response[v1.UpdateAgentResponse], error)
 }
 
 // NewDaemonServiceHandler builds an HTTP handler from the service implementation. It returns the
@@ -169,6 +255,30 @@ func NewDaemonServiceHandler(svc DaemonServiceHandler, opts ...connect.HandlerOp
 		connect.WithSchema(daemonServiceListBinariesMethodDescriptor),
 		connect.WithHandlerOptions(opts...),
 	)
+	daemonServiceStreamLogsHandler := connect.NewServerStreamHandler(
+		DaemonServiceStreamLogsProcedure,
+		svc.StreamLogs,
+		connect.WithSchema(daemonServiceStreamLogsMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	daemonServiceInstallImageHandler := connect.NewUnaryHandler(
+		DaemonServiceInstallImageProcedure,
+		svc.InstallImage,
+		connect.WithSchema(daemonServiceInstallImageMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	daemonServiceCommitUpdateHandler := connect.NewUnaryHandler(
+		DaemonServiceCommitUpdateProcedure,
+		svc.CommitUpdate,
+		connect.WithSchema(daemonServiceCommitUpdateMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	daemonServiceUpdateAgentHandler := connect.NewUnaryHandler(
+		DaemonServiceUpdateAgentProcedure,
+		svc.UpdateAgent,
+		connect.WithSchema(daemonServiceUpdateAgentMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
 	return "/agent.v1.DaemonService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case DaemonServiceDeployBinaryProcedure:
@@ -179,6 +289,14 @@ func NewDaemonServiceHandler(svc DaemonServiceHandler, opts ...connect.HandlerOp
 			daemonServiceStopBinaryHandler.ServeHTTP(w, r)
 		case DaemonServiceListBinariesProcedure:
 			daemonServiceListBinariesHandler.ServeHTTP(w, r)
+		case DaemonServiceStreamLogsProcedure:
+			daemonServiceStreamLogsHandler.ServeHTTP(w, r)
+		case DaemonServiceInstallImageProcedure:
+			daemonServiceInstallImageHandler.ServeHTTP(w, r)
+		case DaemonServiceCommitUpdateProcedure:
+			daemonServiceCommitUpdateHandler.ServeHTTP(w, r)
+		case DaemonServiceUpdateAgentProcedure:
+			daemonServiceUpdateAgentHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -203,3 +321,19 @@ func (UnimplementedDaemonServiceHandler) StopBinary(context.Context, *connect.Re
 func (UnimplementedDaemonServiceHandler) ListBinaries(context.Context, *connect.Request[v1.ListBinariesRequest]) (*connect.Response[v1.ListBinariesResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("agent.v1.DaemonService.ListBinaries is not implemented"))
 }
+
+func (UnimplementedDaemonServiceHandler) StreamLogs(context.Context, *connect.Request[v1.StreamLogsRequest], *connect.ServerStream[v1.StreamLogsResponse]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("agent.v1.DaemonService.StreamLogs is not implemented"))
+}
+
+func (UnimplementedDaemonServiceHandler) InstallImage(context.Context, *connect.Request[v1.InstallImageRequest]) (*connect.Response[v1.InstallImageResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("agent.v1.DaemonService.InstallImage is not implemented"))
+}
+
+func (UnimplementedDaemonServiceHandler) CommitUpdate(context.Context, *connect.Request[v1.CommitUpdateRequest]) (*connect.Response[v1.CommitUpdateResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("agent.v1.DaemonService.CommitUpdate is not implemented"))
+}
+
+func (UnimplementedDaemonServiceHandler) UpdateAgent(context.Context, *connect.Request[v1.UpdateAgentRequest]) (*connect.Response[v1.UpdateAgentResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("agent.v1.DaemonService.UpdateAgent is not implemented"))
+}
@@ -657,6 +657,87 @@ func (x *ListBinariesResponse) GetNextPageToken() string {
 	return ""
 }
 
+type DeleteBinaryRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *DeleteBinaryRequest) Reset() {
+	*x = DeleteBinaryRequest{}
+	mi := &file_fleetd_v1_binary_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteBinaryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteBinaryRequest) ProtoMessage() {}
+
+func (x *DeleteBinaryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fleetd_v1_binary_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteBinaryRequest.ProtoReflect.Descriptor instead.
+func (*DeleteBinaryRequest) Descriptor() ([]byte, []int) {
+	return file_fleetd_v1_binary_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DeleteBinaryRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteBinaryResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *DeleteBinaryResponse) Reset() {
+	*x = DeleteBinaryResponse{}
+	mi := &file_fleetd_v1_binary_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteBinaryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteBinaryResponse) ProtoMessage() {}
+
+func (x *DeleteBinaryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_fleetd_v1_binary_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteBinaryResponse.ProtoReflect.Descriptor instead.
+func (*DeleteBinaryResponse) Descriptor() ([]byte, []int) {
+	return file_fleetd_v1_binary_proto_rawDescGZIP(), []int{11}
+}
+
 var File_fleetd_v1_binary_proto protoreflect.FileDescriptor
 
 var file_fleetd_v1_binary_proto_rawDesc = []byte{
@@ -744,28 +825,37 @@ var file_fleetd_v1_binary_proto_rawDesc = []byte{
 	0x65, 0x74, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x52, 0x08, 0x62,
 	0x69, 0x6e, 0x61, 0x72, 0x69, 0x65, 0x73, 0x12, 0x26, 0x0a, 0x0f, 0x6e, 0x65, 0x78, 0x74, 0x5f,
 	0x70, 0x61, 0x67, 0x65, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x0d, 0x6e, 0x65, 0x78, 0x74, 0x50, 0x61, 0x67, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x32,
-	0xd4, 0x02, 0x0a, 0x0d, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63,
-	0x65, 0x12, 0x51, 0x0a, 0x0c, 0x55, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x42, 0x69, 0x6e, 0x61, 0x72,
-	0x79, 0x12, 0x1e, 0x2e, 0x66, 0x6c, 0x65, 0x65, 0x74, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x70,
-	0x6c, 0x6f, 0x61, 0x64, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x1a, 0x1f, 0x2e, 0x66, 0x6c, 0x65, 0x65, 0x74, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x70,
-	0x6c, 0x6f, 0x61, 0x64, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
-	0x73, 0x65, 0x28, 0x01, 0x12, 0x46, 0x0a, 0x09, 0x47, 0x65, 0x74, 0x42, 0x69, 0x6e, 0x61, 0x72,
-	0x79, 0x12, 0x1b, 0x2e, 0x66, 0x6c, 0x65, 0x65, 0x74, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65,
-	0x74, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c,
-	0x2e, 0x66, 0x6c, 0x65, 0x65, 0x74, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x42, 0x69,
-	0x6e, 0x61, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x57, 0x0a, 0x0e,
-	0x44, 0x6f, 0x77, 0x6e, 0x6c, 0x6f, 0x61, 0x64, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x12, 0x20,
-	0x2e, 0x66, 0x6c, 0x65, 0x65, 0x74, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x6f, 0x77, 0x6e, 0x6c,
+	0x52, 0x0d, 0x6e, 0x65, 0x78, 0x74, 0x50, 0x61, 0x67, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x22,
+	0x25, 0x0a, 0x13, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x22, 0x16, 0x0a, 0x14, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65,
+	0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x32, 0xa5,
+	0x03, 0x0a, 0x0d, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65,
+	0x12, 0x51, 0x0a, 0x0c, 0x55, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79,
+	0x12, 0x1e, 0x2e, 0x66, 0x6c, 0x65, 0x65, 0x74, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x70, 0x6c,
 	0x6f, 0x61, 0x64, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x1a, 0x21, 0x2e, 0x66, 0x6c, 0x65, 0x65, 0x74, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x6f, 0x77,
-	0x6e, 0x6c, 0x6f, 0x61, 0x64, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f,
-	0x6e, 0x73, 0x65, 0x30, 0x01, 0x12, 0x4f, 0x0a, 0x0c, 0x4c, 0x69, 0x73, 0x74, 0x42, 0x69, 0x6e,
-	0x61, 0x72, 0x69, 0x65, 0x73, 0x12, 0x1e, 0x2e, 0x66, 0x6c, 0x65, 0x65, 0x74, 0x64, 0x2e, 0x76,
-	0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x69, 0x65, 0x73, 0x52, 0x65,
+	0x1a, 0x1f, 0x2e, 0x66, 0x6c, 0x65, 0x65, 0x74, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x70, 0x6c,
+	0x6f, 0x61, 0x64, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x28, 0x01, 0x12, 0x46, 0x0a, 0x09, 0x47, 0x65, 0x74, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79,
+	0x12, 0x1b, 0x2e, 0x66, 0x6c, 0x65, 0x65, 0x74, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74,
+	0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e,
+	0x66, 0x6c, 0x65, 0x65, 0x74, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x42, 0x69, 0x6e,
+	0x61, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x57, 0x0a, 0x0e, 0x44,
+	0x6f, 0x77, 0x6e, 0x6c, 0x6f, 0x61, 0x64, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x12, 0x20, 0x2e,
+	0x66, 0x6c, 0x65, 0x65, 0x74, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x6f, 0x77, 0x6e, 0x6c, 0x6f,
+	0x61, 0x64, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x21, 0x2e, 0x66, 0x6c, 0x65, 0x65, 0x74, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x6f, 0x77, 0x6e,
+	0x6c, 0x6f, 0x61, 0x64, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x30, 0x01, 0x12, 0x4f, 0x0a, 0x0c, 0x4c, 0x69, 0x73, 0x74, 0x42, 0x69, 0x6e, 0x61,
+	0x72, 0x69, 0x65, 0x73, 0x12, 0x1e, 0x2e, 0x66, 0x6c, 0x65, 0x65, 0x74, 0x64, 0x2e, 0x76, 0x31,
+	0x2e, 0x4c, 0x69, 0x73, 0x74, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x69, 0x65, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x66, 0x6c, 0x65, 0x65, 0x74, 0x64, 0x2e, 0x76, 0x31,
+	0x2e, 0x4c, 0x69, 0x73, 0x74, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x69, 0x65, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4f, 0x0a, 0x0c, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x42,
+	0x69, 0x6e, 0x61, 0x72, 0x79, 0x12, 0x1e, 0x2e, 0x66, 0x6c, 0x65, 0x65, 0x74, 0x64, 0x2e, 0x76,
+	0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x52, 0x65,
 	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x66, 0x6c, 0x65, 0x65, 0x74, 0x64, 0x2e, 0x76,
-	0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x69, 0x65, 0x73, 0x52, 0x65,
+	0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x52, 0x65,
 	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x82, 0x01, 0x0a, 0x0d, 0x63, 0x6f, 0x6d, 0x2e, 0x66,
 	0x6c, 0x65, 0x65, 0x74, 0x64, 0x2e, 0x76, 0x31, 0x42, 0x0b, 0x42, 0x69, 0x6e, 0x61, 0x72, 0x79,
 	0x50, 0x72, 0x6f, 0x74, 0x6f, 0x50, 0x01, 0x5a, 0x1f, 0x66, 0x6c, 0x65, 0x65, 0x74, 0x64, 0x2e,
@@ -790,7 +880,7 @@ func file_fleetd_v1_binary_proto_rawDescGZIP() []byte {
 	return file_fleetd_v1_binary_proto_rawDescData
 }
 
-var file_fleetd_v1_binary_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_fleetd_v1_binary_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
 var file_fleetd_v1_binary_proto_goTypes = []any{
 	(*Binary)(nil),                 // 0: fleetd.v1.Binary
 	(*UploadBinaryRequest)(nil),    // 1: fleetd.v1.UploadBinaryRequest
@@ -802,27 +892,31 @@ var file_fleetd_v1_binary_proto_goTypes = []any{
 	(*DownloadBinaryResponse)(nil), // 7: fleetd.v1.DownloadBinaryResponse
 	(*ListBinariesRequest)(nil),    // 8: fleetd.v1.ListBinariesRequest
 	(*ListBinariesResponse)(nil),   // 9: fleetd.v1.ListBinariesResponse
-	nil,                            // 10: fleetd.v1.Binary.MetadataEntry
-	nil,                            // 11: fleetd.v1.BinaryMetadata.MetadataEntry
-	(*timestamppb.Timestamp)(nil),  // 12: google.protobuf.Timestamp
+	(*DeleteBinaryRequest)(nil),    // 10: fleetd.v1.DeleteBinaryRequest
+	(*DeleteBinaryResponse)(nil),   // 11: fleetd.v1.DeleteBinaryResponse
+	nil,                            // 12: fleetd.v1.Binary.MetadataEntry
+	nil,                            // 13: fleetd.v1.BinaryMetadata.MetadataEntry
+	(*timestamppb.Timestamp)(nil),  // 14: google.protobuf.Timestamp
 }
 var file_fleetd_v1_binary_proto_depIdxs = []int32{
-	10, // 0: fleetd.v1.Binary.metadata:type_name -> fleetd.v1.Binary.MetadataEntry
-	12, // 1: fleetd.v1.Binary.created_at:type_name -> google.protobuf.Timestamp
+	12, // 0: fleetd.v1.Binary.metadata:type_name -> fleetd.v1.Binary.MetadataEntry
+	14, // 1: fleetd.v1.Binary.created_at:type_name -> google.protobuf.Timestamp
 	2,  // 2: fleetd.v1.UploadBinaryRequest.metadata:type_name -> fleetd.v1.BinaryMetadata
-	11, // 3: fleetd.v1.BinaryMetadata.metadata:type_name -> fleetd.v1.BinaryMetadata.MetadataEntry
+	13, // 3: fleetd.v1.BinaryMetadata.metadata:type_name -> fleetd.v1.BinaryMetadata.MetadataEntry
 	0,  // 4: fleetd.v1.GetBinaryResponse.binary:type_name -> fleetd.v1.Binary
 	0,  // 5: fleetd.v1.ListBinariesResponse.binaries:type_name -> fleetd.v1.Binary
 	1,  // 6: fleetd.v1.BinaryService.UploadBinary:input_type -> fleetd.v1.UploadBinaryRequest
 	4,  // 7: fleetd.v1.BinaryService.GetBinary:input_type -> fleetd.v1.GetBinaryRequest
 	6,  // 8: fleetd.v1.BinaryService.DownloadBinary:input_type -> fleetd.v1.DownloadBinaryRequest
 	8,  // 9: fleetd.v1.BinaryService.ListBinaries:input_type -> fleetd.v1.ListBinariesRequest
-	3,  // 10: fleetd.v1.BinaryService.UploadBinary:output_type -> fleetd.v1.UploadBinaryResponse
-	5,  // 11: fleetd.v1.BinaryService.GetBinary:output_type -> fleetd.v1.GetBinaryResponse
-	7,  // 12: fleetd.v1.BinaryService.DownloadBinary:output_type -> fleetd.v1.DownloadBinaryResponse
-	9,  // 13: fleetd.v1.BinaryService.ListBinaries:output_type -> fleetd.v1.ListBinariesResponse
-	10, // [10:14] is the sub-list for method output_type
-	6,  // [6:10] is the sub-list for method input_type
+	10, // 10: fleetd.v1.BinaryService.DeleteBinary:input_type -> fleetd.v1.DeleteBinaryRequest
+	3,  // 11: fleetd.v1.BinaryService.UploadBinary:output_type -> fleetd.v1.UploadBinaryResponse
+	5,  // 12: fleetd.v1.BinaryService.GetBinary:output_type -> fleetd.v1.GetBinaryResponse
+	7,  // 13: fleetd.v1.BinaryService.DownloadBinary:output_type -> fleetd.v1.DownloadBinaryResponse
+	9,  // 14: fleetd.v1.BinaryService.ListBinaries:output_type -> fleetd.v1.ListBinariesResponse
+	11, // 15: fleetd.v1.BinaryService.DeleteBinary:output_type -> fleetd.v1.DeleteBinaryResponse
+	11, // [11:16] is the sub-list for method output_type
+	6,  // [6:11] is the sub-list for method input_type
 	6,  // [6:6] is the sub-list for extension type_name
 	6,  // [6:6] is the sub-list for extension extendee
 	0,  // [0:6] is the sub-list for field type_name
@@ -843,7 +937,7 @@ func file_fleetd_v1_binary_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_fleetd_v1_binary_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   12,
+			NumMessages:   14,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
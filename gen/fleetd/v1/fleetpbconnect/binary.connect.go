@@ -44,6 +44,9 @@ const (
 	// BinaryServiceListBinariesProcedure is the fully-qualified name of the BinaryService's
 	// ListBinaries RPC.
 	BinaryServiceListBinariesProcedure = "/fleetd.v1.BinaryService/ListBinaries"
+	// BinaryServiceDeleteBinaryProcedure is the fully-qualified name of the BinaryService's
+	// DeleteBinary RPC.
+	BinaryServiceDeleteBinaryProcedure = "/fleetd.v1.BinaryService/DeleteBinary"
 )
 
 // These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
@@ -53,6 +56,7 @@ var (
 	binaryServiceGetBinaryMethodDescriptor      = binaryServiceServiceDescriptor.Methods().ByName("GetBinary")
 	binaryServiceDownloadBinaryMethodDescriptor = binaryServiceServiceDescriptor.Methods().ByName("DownloadBinary")
 	binaryServiceListBinariesMethodDescriptor   = binaryServiceServiceDescriptor.Methods().ByName("ListBinaries")
+	binaryServiceDeleteBinaryMethodDescriptor   = binaryServiceServiceDescriptor.Methods().ByName("DeleteBinary")
 )
 
 // BinaryServiceClient is a client for the fleetd.v1.BinaryService service.
@@ -65,6 +69,8 @@ type BinaryServiceClient interface {
 	DownloadBinary(context.Context, *connect.Request[v1.DownloadBinaryRequest]) (*connect.ServerStreamForClient[v1.DownloadBinaryResponse], error)
 	// List available binaries
 	ListBinaries(context.Context, *connect.Request[v1.ListBinariesRequest]) (*connect.Response[v1.ListBinariesResponse], error)
+	// Delete a binary
+	DeleteBinary(context.Context, *connect.Request[v1.DeleteBinaryRequest]) (*connect.Response[v1.DeleteBinaryResponse], error)
 }
 
 // NewBinaryServiceClient constructs a client for the fleetd.v1.BinaryService service. By default,
@@ -101,6 +107,12 @@ func NewBinaryServiceClient(httpClient connect.HTTPClient, baseURL string, opts
 			connect.WithSchema(binaryServiceListBinariesMethodDescriptor),
 			connect.WithClientOptions(opts...),
 		),
+		deleteBinary: connect.NewClient[v1.DeleteBinaryRequest, v1.DeleteBinaryResponse](
+			httpClient,
+			baseURL+BinaryServiceDeleteBinaryProcedure,
+			connect.WithSchema(binaryServiceDeleteBinaryMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
 	}
 }
 
@@ -110,6 +122,7 @@ type binaryServiceClient struct {
 	getBinary      *connect.Client[v1.GetBinaryRequest, v1.GetBinaryResponse]
 	downloadBinary *connect.Client[v1.DownloadBinaryRequest, v1.DownloadBinaryResponse]
 	listBinaries   *connect.Client[v1.ListBinariesRequest, v1.ListBinariesResponse]
+	deleteBinary   *connect.Client[v1.DeleteBinaryRequest, v1.DeleteBinaryResponse]
 }
 
 // UploadBinary calls fleetd.v1.BinaryService.UploadBinary.
@@ -132,6 +145,11 @@ func (c *binaryServiceClient) ListBinaries(ctx context.Context, req *connect.Req
 	return c.listBinaries.CallUnary(ctx, req)
 }
 
+// DeleteBinary calls fleetd.v1.BinaryService.DeleteBinary.
+func (c *binaryServiceClient) DeleteBinary(ctx context.Context, req *connect.Request[v1.DeleteBinaryRequest]) (*connect.Response[v1.DeleteBinaryResponse], error) {
+	return c.deleteBinary.CallUnary(ctx, req)
+}
+
 // BinaryServiceHandler is an implementation of the fleetd.v1.BinaryService service.
 type BinaryServiceHandler interface {
 	// Upload a new binary to the fleet
@@ -142,6 +160,8 @@ type BinaryServiceHandler interface {
 	DownloadBinary(context.Context, *connect.Request[v1.DownloadBinaryRequest], *connect.ServerStream[v1.DownloadBinaryResponse]) error
 	// List available binaries
 	ListBinaries(context.Context, *connect.Request[v1.ListBinariesRequest]) (*connect.Response[v1.ListBinariesResponse], error)
+	// Delete a binary
+	DeleteBinary(context.Context, *connect.Request[v1.DeleteBinaryRequest]) (*connect.Response[v1.DeleteBinaryResponse], error)
 }
 
 // NewBinaryServiceHandler builds an HTTP handler from the service implementation. It returns the
@@ -174,6 +194,12 @@ func NewBinaryServiceHandler(svc BinaryServiceHandler, opts ...connect.HandlerOp
 		connect.WithSchema(binaryServiceListBinariesMethodDescriptor),
 		connect.WithHandlerOptions(opts...),
 	)
+	binaryServiceDeleteBinaryHandler := connect.NewUnaryHandler(
+		BinaryServiceDeleteBinaryProcedure,
+		svc.DeleteBinary,
+		connect.WithSchema(binaryServiceDeleteBinaryMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
 	return "/fleetd.v1.BinaryService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case BinaryServiceUploadBinaryProcedure:
@@ -184,6 +210,8 @@ func NewBinaryServiceHandler(svc BinaryServiceHandler, opts ...connect.HandlerOp
 			binaryServiceDownloadBinaryHandler.ServeHTTP(w, r)
 		case BinaryServiceListBinariesProcedure:
 			binaryServiceListBinariesHandler.ServeHTTP(w, r)
+		case BinaryServiceDeleteBinaryProcedure:
+			binaryServiceDeleteBinaryHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -208,3 +236,7 @@ func (UnimplementedBinaryServiceHandler) DownloadBinary(context.Context, *connec
 func (UnimplementedBinaryServiceHandler) ListBinaries(context.Context, *connect.Request[v1.ListBinariesRequest]) (*connect.Response[v1.ListBinariesResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("fleetd.v1.BinaryService.ListBinaries is not implemented"))
 }
+
+func (UnimplementedBinaryServiceHandler) DeleteBinary(context.Context, *connect.Request[v1.DeleteBinaryRequest]) (*connect.Response[v1.DeleteBinaryResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("fleetd.v1.BinaryService.DeleteBinary is not implemented"))
+}
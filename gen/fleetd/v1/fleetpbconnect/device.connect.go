@@ -48,17 +48,45 @@ const (
 	// DeviceServiceDeleteDeviceProcedure is the fully-qualified name of the DeviceService's
 	// DeleteDevice RPC.
 	DeviceServiceDeleteDeviceProcedure = "/fleetd.v1.DeviceService/DeleteDevice"
+	// DeviceServiceBatchUpdateDeviceStatusProcedure is the fully-qualified name of the DeviceService's
+	// BatchUpdateDeviceStatus RPC.
+	DeviceServiceBatchUpdateDeviceStatusProcedure = "/fleetd.v1.DeviceService/BatchUpdateDeviceStatus"
+	// DeviceServiceBatchRegisterDevicesProcedure is the fully-qualified name of the DeviceService's
+	// BatchRegisterDevices RPC.
+	DeviceServiceBatchRegisterDevicesProcedure = "/fleetd.v1.DeviceService/BatchRegisterDevices"
+	// DeviceServiceCreateFleetProcedure is the fully-qualified name of the DeviceService's CreateFleet
+	// RPC.
+	DeviceServiceCreateFleetProcedure = "/fleetd.v1.DeviceService/CreateFleet"
+	// DeviceServiceListFleetsProcedure is the fully-qualified name of the DeviceService's ListFleets
+	// RPC.
+	DeviceServiceListFleetsProcedure = "/fleetd.v1.DeviceService/ListFleets"
+	// DeviceServiceAddDeviceToFleetProcedure is the fully-qualified name of the DeviceService's
+	// AddDeviceToFleet RPC.
+	DeviceServiceAddDeviceToFleetProcedure = "/fleetd.v1.DeviceService/AddDeviceToFleet"
+	// DeviceServiceRemoveDeviceFromFleetProcedure is the fully-qualified name of the DeviceService's
+	// RemoveDeviceFromFleet RPC.
+	DeviceServiceRemoveDeviceFromFleetProcedure = "/fleetd.v1.DeviceService/RemoveDeviceFromFleet"
+	// DeviceServiceSetDeviceTagsProcedure is the fully-qualified name of the DeviceService's
+	// SetDeviceTags RPC.
+	DeviceServiceSetDeviceTagsProcedure = "/fleetd.v1.DeviceService/SetDeviceTags"
 )
 
 // These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
 var (
-	deviceServiceServiceDescriptor            = v1.File_fleetd_v1_device_proto.Services().ByName("DeviceService")
-	deviceServiceRegisterMethodDescriptor     = deviceServiceServiceDescriptor.Methods().ByName("Register")
-	deviceServiceHeartbeatMethodDescriptor    = deviceServiceServiceDescriptor.Methods().ByName("Heartbeat")
-	deviceServiceReportStatusMethodDescriptor = deviceServiceServiceDescriptor.Methods().ByName("ReportStatus")
-	deviceServiceGetDeviceMethodDescriptor    = deviceServiceServiceDescriptor.Methods().ByName("GetDevice")
-	deviceServiceListDevicesMethodDescriptor  = deviceServiceServiceDescriptor.Methods().ByName("ListDevices")
-	deviceServiceDeleteDeviceMethodDescriptor = deviceServiceServiceDescriptor.Methods().ByName("DeleteDevice")
+	deviceServiceServiceDescriptor                       = v1.File_fleetd_v1_device_proto.Services().ByName("DeviceService")
+	deviceServiceRegisterMethodDescriptor                = deviceServiceServiceDescriptor.Methods().ByName("Register")
+	deviceServiceHeartbeatMethodDescriptor               = deviceServiceServiceDescriptor.Methods().ByName("Heartbeat")
+	deviceServiceReportStatusMethodDescriptor            = deviceServiceServiceDescriptor.Methods().ByName("ReportStatus")
+	deviceServiceGetDeviceMethodDescriptor               = deviceServiceServiceDescriptor.Methods().ByName("GetDevice")
+	deviceServiceListDevicesMethodDescriptor             = deviceServiceServiceDescriptor.Methods().ByName("ListDevices")
+	deviceServiceDeleteDeviceMethodDescriptor            = deviceServiceServiceDescriptor.Methods().ByName("DeleteDevice")
+	deviceServiceBatchUpdateDeviceStatusMethodDescriptor = deviceServiceServiceDescriptor.Methods().ByName("BatchUpdateDeviceStatus")
+	deviceServiceBatchRegisterDevicesMethodDescriptor    = deviceServiceServiceDescriptor.Methods().ByName("BatchRegisterDevices")
+	deviceServiceCreateFleetMethodDescriptor             = deviceServiceServiceDescriptor.Methods().ByName("CreateFleet")
+	deviceServiceListFleetsMethodDescriptor              = deviceServiceServiceDescriptor.Methods().ByName("ListFleets")
+	deviceServiceAddDeviceToFleetMethodDescriptor        = deviceServiceServiceDescriptor.Methods().ByName("AddDeviceToFleet")
+	deviceServiceRemoveDeviceFromFleetMethodDescriptor   = deviceServiceServiceDescriptor.Methods().ByName("RemoveDeviceFromFleet")
+	deviceServiceSetDeviceTagsMethodDescriptor           = deviceServiceServiceDescriptor.Methods().ByName("SetDeviceTags")
 )
 
 // DeviceServiceClient is a client for the fleetd.v1.DeviceService service.
@@ -75,6 +103,20 @@ type DeviceServiceClient interface {
 	ListDevices(context.Context, *connect.Request[v1.ListDevicesRequest]) (*connect.Response[v1.ListDevicesResponse], error)
 	// Delete a device
 	DeleteDevice(context.Context, *connect.Request[v1.DeleteDeviceRequest]) (*connect.Response[v1.DeleteDeviceResponse], error)
+	// Set the status of several devices in a single transaction
+	BatchUpdateDeviceStatus(context.Context, *connect.Request[v1.BatchUpdateDeviceStatusRequest]) (*connect.Response[v1.BatchUpdateDeviceStatusResponse], error)
+	// Pre-register many devices in a single transaction
+	BatchRegisterDevices(context.Context, *connect.Request[v1.BatchRegisterDevicesRequest]) (*connect.Response[v1.BatchRegisterDevicesResponse], error)
+	// Create a fleet of devices
+	CreateFleet(context.Context, *connect.Request[v1.CreateFleetRequest]) (*connect.Response[v1.CreateFleetResponse], error)
+	// List fleets
+	ListFleets(context.Context, *connect.Request[v1.ListFleetsRequest]) (*connect.Response[v1.ListFleetsResponse], error)
+	// Move a device into a fleet. A device belongs to at most one fleet.
+	AddDeviceToFleet(context.Context, *connect.Request[v1.AddDeviceToFleetRequest]) (*connect.Response[v1.AddDeviceToFleetResponse], error)
+	// Return a device from its fleet to the default fleet
+	RemoveDeviceFromFleet(context.Context, *connect.Request[v1.RemoveDeviceFromFleetRequest]) (*connect.Response[v1.RemoveDeviceFromFleetResponse], error)
+	// Set and remove tags on a device
+	SetDeviceTags(context.Context, *connect.Request[v1.SetDeviceTagsRequest]) (*connect.Response[v1.SetDeviceTagsResponse], error)
 }
 
 // NewDeviceServiceClient constructs a client for the fleetd.v1.DeviceService service. By default,
@@ -123,17 +165,66 @@ func NewDeviceServiceClient(httpClient connect.HTTPClient, baseURL string, opts
 			connect.WithSchema(deviceServiceDeleteDeviceMethodDescriptor),
 			connect.WithClientOptions(opts...),
 		),
+		batchUpdateDeviceStatus: connect.NewClient[v1.BatchUpdateDeviceStatusRequest, v1.BatchUpdateDeviceStatusResponse](
+			httpClient,
+			baseURL+DeviceServiceBatchUpdateDeviceStatusProcedure,
+			connect.WithSchema(deviceServiceBatchUpdateDeviceStatusMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		batchRegisterDevices: connect.NewClient[v1.BatchRegisterDevicesRequest, v1.BatchRegisterDevicesResponse](
+			httpClient,
+			baseURL+DeviceServiceBatchRegisterDevicesProcedure,
+			connect.WithSchema(deviceServiceBatchRegisterDevicesMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		createFleet: connect.NewClient[v1.CreateFleetRequest, v1.CreateFleetResponse](
+			httpClient,
+			baseURL+DeviceServiceCreateFleetProcedure,
+			connect.WithSchema(deviceServiceCreateFleetMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		listFleets: connect.NewClient[v1.ListFleetsRequest, v1.ListFleetsResponse](
+			httpClient,
+			baseURL+DeviceServiceListFleetsProcedure,
+			connect.WithSchema(deviceServiceListFleetsMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		addDeviceToFleet: connect.NewClient[v1.AddDeviceToFleetRequest, v1.AddDeviceToFleetResponse](
+			httpClient,
+			baseURL+DeviceServiceAddDeviceToFleetProcedure,
+			connect.WithSchema(deviceServiceAddDeviceToFleetMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		removeDeviceFromFleet: connect.NewClient[v1.RemoveDeviceFromFleetRequest, v1.RemoveDeviceFromFleetResponse](
+			httpClient,
+			baseURL+DeviceServiceRemoveDeviceFromFleetProcedure,
+			connect.WithSchema(deviceServiceRemoveDeviceFromFleetMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		setDeviceTags: connect.NewClient[v1.SetDeviceTagsRequest, v1.SetDeviceTagsResponse](
+			httpClient,
+			baseURL+DeviceServiceSetDeviceTagsProcedure,
+			connect.WithSchema(deviceServiceSetDeviceTagsMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
 	}
 }
 
 // deviceServiceClient implements DeviceServiceClient.
 type deviceServiceClient struct {
-	register     *connect.Client[v1.RegisterRequest, v1.RegisterResponse]
-	heartbeat    *connect.Client[v1.HeartbeatRequest, v1.HeartbeatResponse]
-	reportStatus *connect.Client[v1.ReportStatusRequest, v1.ReportStatusResponse]
-	getDevice    *connect.Client[v1.GetDeviceRequest, v1.GetDeviceResponse]
-	listDevices  *connect.Client[v1.ListDevicesRequest, v1.ListDevicesResponse]
-	deleteDevice *connect.Client[v1.DeleteDeviceRequest, v1.DeleteDeviceResponse]
+	register                *connect.Client[v1.RegisterRequest, v1.RegisterResponse]
+	heartbeat               *connect.Client[v1.HeartbeatRequest, v1.HeartbeatResponse]
+	reportStatus            *connect.Client[v1.ReportStatusRequest, v1.ReportStatusResponse]
+	getDevice               *connect.Client[v1.GetDeviceRequest, v1.GetDeviceResponse]
+	listDevices             *connect.Client[v1.ListDevicesRequest, v1.ListDevicesResponse]
+	deleteDevice            *connect.Client[v1.DeleteDeviceRequest, v1.DeleteDeviceResponse]
+	batchUpdateDeviceStatus *connect.Client[v1.BatchUpdateDeviceStatusRequest, v1.BatchUpdateDeviceStatusResponse]
+	batchRegisterDevices    *connect.Client[v1.BatchRegisterDevicesRequest, v1.BatchRegisterDevicesResponse]
+	createFleet             *connect.Client[v1.CreateFleetRequest, v1.CreateFleetResponse]
+	listFleets              *connect.Client[v1.ListFleetsRequest, v1.ListFleetsResponse]
+	addDeviceToFleet        *connect.Client[v1.AddDeviceToFleetRequest, v1.AddDeviceToFleetResponse]
+	removeDeviceFromFleet   *connect.Client[v1.RemoveDeviceFromFleetRequest, v1.RemoveDeviceFromFleetResponse]
+	setDeviceTags           *connect.Client[v1.SetDeviceTagsRequest, v1.SetDeviceTagsResponse]
 }
 
 // Register calls fleetd.v1.DeviceService.Register.
@@ -166,6 +257,41 @@ func (c *deviceServiceClient) DeleteDevice(ctx context.Context, req *connect.Req
 	return c.deleteDevice.CallUnary(ctx, req)
 }
 
+// BatchUpdateDeviceStatus calls fleetd.v1.DeviceService.BatchUpdateDeviceStatus.
+func (c *deviceServiceClient) BatchUpdateDeviceStatus(ctx context.Context, req *connect.Request[v1.BatchUpdateDeviceStatusRequest]) (*connect.Response[v1.BatchUpdateDeviceStatusResponse], error) {
+	return c.batchUpdateDeviceStatus.CallUnary(ctx, req)
+}
+
+// BatchRegisterDevices calls fleetd.v1.DeviceService.BatchRegisterDevices.
+func (c *deviceServiceClient) BatchRegisterDevices(ctx context.Context, req *connect.Request[v1.BatchRegisterDevicesRequest]) (*connect.Response[v1.BatchRegisterDevicesResponse], error) {
+	return c.batchRegisterDevices.CallUnary(ctx, req)
+}
+
+// CreateFleet calls fleetd.v1.DeviceService.CreateFleet.
+func (c *deviceServiceClient) CreateFleet(ctx context.Context, req *connect.Request[v1.CreateFleetRequest]) (*connect.Response[v1.CreateFleetResponse], error) {
+	return c.createFleet.CallUnary(ctx, req)
+}
+
+// ListFleets calls fleetd.v1.DeviceService.ListFleets.
+func (c *deviceServiceClient) ListFleets(ctx context.Context, req *connect.Request[v1.ListFleetsRequest]) (*connect.Response[v1.ListFleetsResponse], error) {
+	return c.listFleets.CallUnary(ctx, req)
+}
+
+// AddDeviceToFleet calls fleetd.v1.DeviceService.AddDeviceToFleet.
+func (c *deviceServiceClient) AddDeviceToFleet(ctx context.Context, req *connect.Request[v1.AddDeviceToFleetRequest]) (*connect.Response[v1.AddDeviceToFleetResponse], error) {
+	return c.addDeviceToFleet.CallUnary(ctx, req)
+}
+
+// RemoveDeviceFromFleet calls fleetd.v1.DeviceService.RemoveDeviceFromFleet.
+func (c *deviceServiceClient) RemoveDeviceFromFleet(ctx context.Context, req *connect.Request[v1.RemoveDeviceFromFleetRequest]) (*connect.Response[v1.RemoveDeviceFromFleetResponse], error) {
+	return c.removeDeviceFromFleet.CallUnary(ctx, req)
+}
+
+// SetDeviceTags calls fleetd.v1.DeviceService.SetDeviceTags.
+func (c *deviceServiceClient) SetDeviceTags(ctx context.Context, req *connect.Request[v1.SetDeviceTagsRequest]) (*connect.Response[v1.SetDeviceTagsResponse], error) {
+	return c.setDeviceTags.CallUnary(ctx, req)
+}
+
 // DeviceServiceHandler is an implementation of the fleetd.v1.DeviceService service.
 type DeviceServiceHandler interface {
 	// Register a new device with the fleet
@@ -180,6 +306,20 @@ type DeviceServiceHandler interface {
 	ListDevices(context.Context, *connect.Request[v1.ListDevicesRequest]) (*connect.Response[v1.ListDevicesResponse], error)
 	// Delete a device
 	DeleteDevice(context.Context, *connect.Request[v1.DeleteDeviceRequest]) (*connect.Response[v1.DeleteDeviceResponse], error)
+	// Set the status of several devices in a single transaction
+	BatchUpdateDeviceStatus(context.Context, *connect.Request[v1.BatchUpdateDeviceStatusRequest]) (*connect.Response[v1.BatchUpdateDeviceStatusResponse], error)
+	// Pre-register many devices in a single transaction
+	BatchRegisterDevices(context.Context, *connect.Request[v1.BatchRegisterDevicesRequest]) (*connect.Response[v1.BatchRegisterDevicesResponse], error)
+	// Create a fleet of devices
+	CreateFleet(context.Context, *connect.Request[v1.CreateFleetRequest]) (*connect.Response[v1.CreateFleetResponse], error)
+	// List fleets
+	ListFleets(context.Context, *connect.Request[v1.ListFleetsRequest]) (*connect.Response[v1.ListFleetsResponse], error)
+	// Move a device into a fleet. A device belongs to at most one fleet.
+	AddDeviceToFleet(context.Context, *connect.Request[v1.AddDeviceToFleetRequest]) (*connect.Response[v1.AddDeviceToFleetResponse], error)
+	// Return a device from its fleet to the default fleet
+	RemoveDeviceFromFleet(context.Context, *connect.Request[v1.RemoveDeviceFromFleetRequest]) (*connect.Response[v1.RemoveDeviceFromFleetResponse], error)
+	// Set and remove tags on a device
+	SetDeviceTags(context.Context, *connect.Request[v1.SetDeviceTagsRequest]) (*connect.Response[v1.SetDeviceTagsResponse], error)
 }
 
 // NewDeviceServiceHandler builds an HTTP handler from the service implementation. It returns the
@@ -224,6 +364,48 @@ func NewDeviceServiceHandler(svc DeviceServiceHandler, opts ...connect.HandlerOp
 		connect.WithSchema(deviceServiceDeleteDeviceMethodDescriptor),
 		connect.WithHandlerOptions(opts...),
 	)
+	deviceServiceBatchUpdateDeviceStatusHandler := connect.NewUnaryHandler(
+		DeviceServiceBatchUpdateDeviceStatusProcedure,
+		svc.BatchUpdateDeviceStatus,
+		connect.WithSchema(deviceServiceBatchUpdateDeviceStatusMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	deviceServiceBatchRegisterDevicesHandler := connect.NewUnaryHandler(
+		DeviceServiceBatchRegisterDevicesProcedure,
+		svc.BatchRegisterDevices,
+		connect.WithSchema(deviceServiceBatchRegisterDevicesMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	deviceServiceCreateFleetHandler := connect.NewUnaryHandler(
+		DeviceServiceCreateFleetProcedure,
+		svc.CreateFleet,
+		connect.WithSchema(deviceServiceCreateFleetMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	deviceServiceListFleetsHandler := connect.NewUnaryHandler(
+		DeviceServiceListFleetsProcedure,
+		svc.ListFleets,
+		connect.WithSchema(deviceServiceListFleetsMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	deviceServiceAddDeviceToFleetHandler := connect.NewUnaryHandler(
+		DeviceServiceAddDeviceToFleetProcedure,
+		svc.AddDeviceToFleet,
+		connect.WithSchema(deviceServiceAddDeviceToFleetMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	deviceServiceRemoveDeviceFromFleetHandler := connect.NewUnaryHandler(
+		DeviceServiceRemoveDeviceFromFleetProcedure,
+		svc.RemoveDeviceFromFleet,
+		connect.WithSchema(deviceServiceRemoveDeviceFromFleetMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	deviceServiceSetDeviceTagsHandler := connect.NewUnaryHandler(
+		DeviceServiceSetDeviceTagsProcedure,
+		svc.SetDeviceTags,
+		connect.WithSchema(deviceServiceSetDeviceTagsMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
 	return "/fleetd.v1.DeviceService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case DeviceServiceRegisterProcedure:
@@ -238,6 +420,20 @@ func NewDeviceServiceHandler(svc DeviceServiceHandler, opts ...connect.HandlerOp
 			deviceServiceListDevicesHandler.ServeHTTP(w, r)
 		case DeviceServiceDeleteDeviceProcedure:
 			deviceServiceDeleteDeviceHandler.ServeHTTP(w, r)
+		case DeviceServiceBatchUpdateDeviceStatusProcedure:
+			deviceServiceBatchUpdateDeviceStatusHandler.ServeHTTP(w, r)
+		case DeviceServiceBatchRegisterDevicesProcedure:
+			deviceServiceBatchRegisterDevicesHandler.ServeHTTP(w, r)
+		case DeviceServiceCreateFleetProcedure:
+			deviceServiceCreateFleetHandler.ServeHTTP(w, r)
+		case DeviceServiceListFleetsProcedure:
+			deviceServiceListFleetsHandler.ServeHTTP(w, r)
+		case DeviceServiceAddDeviceToFleetProcedure:
+			deviceServiceAddDeviceToFleetHandler.ServeHTTP(w, r)
+		case DeviceServiceRemoveDeviceFromFleetProcedure:
+			deviceServiceRemoveDeviceFromFleetHandler.ServeHTTP(w, r)
+		case DeviceServiceSetDeviceTagsProcedure:
+			deviceServiceSetDeviceTagsHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -270,3 +466,31 @@ func (UnimplementedDeviceServiceHandler) ListDevices(context.Context, *connect.R
 func (UnimplementedDeviceServiceHandler) DeleteDevice(context.Context, *connect.Request[v1.DeleteDeviceRequest]) (*connect.Response[v1.DeleteDeviceResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("fleetd.v1.DeviceService.DeleteDevice is not implemented"))
 }
+
+func (UnimplementedDeviceServiceHandler) BatchUpdateDeviceStatus(context.Context, *connect.Request[v1.BatchUpdateDeviceStatusRequest]) (*connect.Response[v1.BatchUpdateDeviceStatusResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("fleetd.v1.DeviceService.BatchUpdateDeviceStatus is not implemented"))
+}
+
+func (UnimplementedDeviceServiceHandler) BatchRegisterDevices(context.Context, *connect.Request[v1.BatchRegisterDevicesRequest]) (*connect.Response[v1.BatchRegisterDevicesResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("fleetd.v1.DeviceService.BatchRegisterDevices is not implemented"))
+}
+
+func (UnimplementedDeviceServiceHandler) CreateFleet(context.Context, *connect.Request[v1.CreateFleetRequest]) (*connect.Response[v1.CreateFleetResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("fleetd.v1.DeviceService.CreateFleet is not implemented"))
+}
+
+func (UnimplementedDeviceServiceHandler) ListFleets(context.Context, *connect.Request[v1.ListFleetsRequest]) (*connect.Response[v1.ListFleetsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("fleetd.v1.DeviceService.ListFleets is not implemented"))
+}
+
+func (UnimplementedDeviceServiceHandler) AddDeviceToFleet(context.Context, *connect.Request[v1.AddDeviceToFleetRequest]) (*connect.Response[v1.AddDeviceToFleetResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("fleetd.v1.DeviceService.AddDeviceToFleet is not implemented"))
+}
+
+func (UnimplementedDeviceServiceHandler) RemoveDeviceFromFleet(context.Context, *connect.Request[v1.RemoveDeviceFromFleetRequest]) (*connect.Response[v1.RemoveDeviceFromFleetResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("fleetd.v1.DeviceService.RemoveDeviceFromFleet is not implemented"))
+}
+
+func (UnimplementedDeviceServiceHandler) SetDeviceTags(context.Context, *connect.Request[v1.SetDeviceTagsRequest]) (*connect.Response[v1.SetDeviceTagsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("fleetd.v1.DeviceService.SetDeviceTags is not implemented"))
+}
@@ -48,16 +48,24 @@ const (
 	// UpdateServiceReportUpdateStatusProcedure is the fully-qualified name of the UpdateService's
 	// ReportUpdateStatus RPC.
 	UpdateServiceReportUpdateStatusProcedure = "/fleetd.v1.UpdateService/ReportUpdateStatus"
+	// UpdateServiceRollbackUpdateCampaignProcedure is the fully-qualified name of the UpdateService's
+	// RollbackUpdateCampaign RPC.
+	UpdateServiceRollbackUpdateCampaignProcedure = "/fleetd.v1.UpdateService/RollbackUpdateCampaign"
+	// UpdateServiceGetDeviceVersionHistoryProcedure is the fully-qualified name of the UpdateService's
+	// GetDeviceVersionHistory RPC.
+	UpdateServiceGetDeviceVersionHistoryProcedure = "/fleetd.v1.UpdateService/GetDeviceVersionHistory"
 )
 
 // These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
 var (
-	updateServiceServiceDescriptor                     = v1.File_fleetd_v1_update_proto.Services().ByName("UpdateService")
-	updateServiceCreateUpdateCampaignMethodDescriptor  = updateServiceServiceDescriptor.Methods().ByName("CreateUpdateCampaign")
-	updateServiceGetUpdateCampaignMethodDescriptor     = updateServiceServiceDescriptor.Methods().ByName("GetUpdateCampaign")
-	updateServiceListUpdateCampaignsMethodDescriptor   = updateServiceServiceDescriptor.Methods().ByName("ListUpdateCampaigns")
-	updateServiceGetDeviceUpdateStatusMethodDescriptor = updateServiceServiceDescriptor.Methods().ByName("GetDeviceUpdateStatus")
-	updateServiceReportUpdateStatusMethodDescriptor    = updateServiceServiceDescriptor.Methods().ByName("ReportUpdateStatus")
+	updateServiceServiceDescriptor                       = v1.File_fleetd_v1_update_proto.Services().ByName("UpdateService")
+	updateServiceCreateUpdateCampaignMethodDescriptor    = updateServiceServiceDescriptor.Methods().ByName("CreateUpdateCampaign")
+	updateServiceGetUpdateCampaignMethodDescriptor       = updateServiceServiceDescriptor.Methods().ByName("GetUpdateCampaign")
+	updateServiceListUpdateCampaignsMethodDescriptor     = updateServiceServiceDescriptor.Methods().ByName("ListUpdateCampaigns")
+	updateServiceGetDeviceUpdateStatusMethodDescriptor   = updateServiceServiceDescriptor.Methods().ByName("GetDeviceUpdateStatus")
+	updateServiceReportUpdateStatusMethodDescriptor      = updateServiceServiceDescriptor.Methods().ByName("ReportUpdateStatus")
+	updateServiceRollbackUpdateCampaignMethodDescriptor  = updateServiceServiceDescriptor.Methods().ByName("RollbackUpdateCampaign")
+	updateServiceGetDeviceVersionHistoryMethodDescriptor = updateServiceServiceDescriptor.Methods().ByName("GetDeviceVersionHistory")
 )
 
 // UpdateServiceClient is a client for the fleetd.v1.UpdateService service.
@@ -72,6 +80,10 @@ type UpdateServiceClient interface {
 	GetDeviceUpdateStatus(context.Context, *connect.Request[v1.GetDeviceUpdateStatusRequest]) (*connect.Response[v1.GetDeviceUpdateStatusResponse], error)
 	// Report update status from device
 	ReportUpdateStatus(context.Context, *connect.Request[v1.ReportUpdateStatusRequest]) (*connect.Response[v1.ReportUpdateStatusResponse], error)
+	// Roll devices updated by a campaign back to their previous version
+	RollbackUpdateCampaign(context.Context, *connect.Request[v1.RollbackUpdateCampaignRequest]) (*connect.Response[v1.RollbackUpdateCampaignResponse], error)
+	// Get the versions installed on a device
+	GetDeviceVersionHistory(context.Context, *connect.Request[v1.GetDeviceVersionHistoryRequest]) (*connect.Response[v1.GetDeviceVersionHistoryResponse], error)
 }
 
 // NewUpdateServiceClient constructs a client for the fleetd.v1.UpdateService service. By default,
@@ -114,16 +126,30 @@ func NewUpdateServiceClient(httpClient connect.HTTPClient, baseURL string, opts
 			connect.WithSchema(updateServiceReportUpdateStatusMethodDescriptor),
 			connect.WithClientOptions(opts...),
 		),
+		rollbackUpdateCampaign: connect.NewClient[v1.RollbackUpdateCampaignRequest, v1.RollbackUpdateCampaignResponse](
+			httpClient,
+			baseURL+UpdateServiceRollbackUpdateCampaignProcedure,
+			connect.WithSchema(updateServiceRollbackUpdateCampaignMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		getDeviceVersionHistory: connect.NewClient[v1.GetDeviceVersionHistoryRequest, v1.GetDeviceVersionHistoryResponse](
+			httpClient,
+			baseURL+UpdateServiceGetDeviceVersionHistoryProcedure,
+			connect.WithSchema(updateServiceGetDeviceVersionHistoryMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
 	}
 }
 
 // updateServiceClient implements UpdateServiceClient.
 type updateServiceClient struct {
-	createUpdateCampaign  *connect.Client[v1.CreateUpdateCampaignRequest, v1.CreateUpdateCampaignResponse]
-	getUpdateCampaign     *connect.Client[v1.GetUpdateCampaignRequest, v1.GetUpdateCampaignResponse]
-	listUpdateCampaigns   *connect.Client[v1.ListUpdateCampaignsRequest, v1.ListUpdateCampaignsResponse]
-	getDeviceUpdateStatus *connect.Client[v1.GetDeviceUpdateStatusRequest, v1.GetDeviceUpdateStatusResponse]
-	reportUpdateStatus    *connect.Client[v1.ReportUpdateStatusRequest, v1.ReportUpdateStatusResponse]
+	createUpdateCampaign    *connect.Client[v1.CreateUpdateCampaignRequest, v1.CreateUpdateCampaignResponse]
+	getUpdateCampaign       *connect.Client[v1.GetUpdateCampaignRequest, v1.GetUpdateCampaignResponse]
+	listUpdateCampaigns     *connect.Client[v1.ListUpdateCampaignsRequest, v1.ListUpdateCampaignsResponse]
+	getDeviceUpdateStatus   *connect.Client[v1.GetDeviceUpdateStatusRequest, v1.GetDeviceUpdateStatusResponse]
+	reportUpdateStatus      *connect.Client[v1.ReportUpdateStatusRequest, v1.ReportUpdateStatusResponse]
+	rollbackUpdateCampaign  *connect.Client[v1.RollbackUpdateCampaignRequest, v1.RollbackUpdateCampaignResponse]
+	getDeviceVersionHistory *connect.Client[v1.GetDeviceVersionHistoryRequest, v1.GetDeviceVersionHistoryResponse]
 }
 
 // CreateUpdateCampaign calls fleetd.v1.UpdateService.CreateUpdateCampaign.
@@ -151,6 +177,16 @@ func (c *updateServiceClient) ReportUpdateStatus(ctx context.Context, req *conne
 	return c.reportUpdateStatus.CallUnary(ctx, req)
 }
 
+// RollbackUpdateCampaign calls fleetd.v1.UpdateService.RollbackUpdateCampaign.
+func (c *updateServiceClient) RollbackUpdateCampaign(ctx context.Context, req *connect.Request[v1.RollbackUpdateCampaignRequest]) (*connect.Response[v1.RollbackUpdateCampaignResponse], error) {
+	return c.rollbackUpdateCampaign.CallUnary(ctx, req)
+}
+
+// GetDeviceVersionHistory calls fleetd.v1.UpdateService.GetDeviceVersionHistory.
+func (c *updateServiceClient) GetDeviceVersionHistory(ctx context.Context, req *connect.Request[v1.GetDeviceVersionHistoryRequest]) (*connect.Response[v1.GetDeviceVersionHistoryResponse], error) {
+	return c.getDeviceVersionHistory.CallUnary(ctx, req)
+}
+
 // UpdateServiceHandler is an implementation of the fleetd.v1.UpdateService service.
 type UpdateServiceHandler interface {
 	// Create a new update campaign
@@ -163,6 +199,10 @@ type UpdateServiceHandler interface {
 	GetDeviceUpdateStatus(context.Context, *connect.Request[v1.GetDeviceUpdateStatusRequest]) (*connect.Response[v1.GetDeviceUpdateStatusResponse], error)
 	// Report update status from device
 	ReportUpdateStatus(context.Context, *connect.Request[v1.ReportUpdateStatusRequest]) (*connect.Response[v1.ReportUpdateStatusResponse], error)
+	// Roll devices updated by a campaign back to their previous version
+	RollbackUpdateCampaign(context.Context, *connect.Request[v1.RollbackUpdateCampaignRequest]) (*connect.Response[v1.RollbackUpdateCampaignResponse], error)
+	// Get the versions installed on a device
+	GetDeviceVersionHistory(context.Context, *connect.Request[v1.GetDeviceVersionHistoryRequest]) (*connect.Response[v1.GetDeviceVersionHistoryResponse], error)
 }
 
 // NewUpdateServiceHandler builds an HTTP handler from the service implementation. It returns the
@@ -201,6 +241,18 @@ func NewUpdateServiceHandler(svc UpdateServiceHandler, opts ...connect.HandlerOp
 		connect.WithSchema(updateServiceReportUpdateStatusMethodDescriptor),
 		connect.WithHandlerOptions(opts...),
 	)
+	updateServiceRollbackUpdateCampaignHandler := connect.NewUnaryHandler(
+		UpdateServiceRollbackUpdateCampaignProcedure,
+		svc.RollbackUpdateCampaign,
+		connect.WithSchema(updateServiceRollbackUpdateCampaignMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	updateServiceGetDeviceVersionHistoryHandler := connect.NewUnaryHandler(
+		UpdateServiceGetDeviceVersionHistoryProcedure,
+		svc.GetDeviceVersionHistory,
+		connect.WithSchema(updateServiceGetDeviceVersionHistoryMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
 	return "/fleetd.v1.UpdateService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case UpdateServiceCreateUpdateCampaignProcedure:
@@ -213,6 +265,10 @@ func NewUpdateServiceHandler(svc UpdateServiceHandler, opts ...connect.HandlerOp
 			updateServiceGetDeviceUpdateStatusHandler.ServeHTTP(w, r)
 		case UpdateServiceReportUpdateStatusProcedure:
 			updateServiceReportUpdateStatusHandler.ServeHTTP(w, r)
+		case UpdateServiceRollbackUpdateCampaignProcedure:
+			updateServiceRollbackUpdateCampaignHandler.ServeHTTP(w, r)
+		case UpdateServiceGetDeviceVersionHistoryProcedure:
+			updateServiceGetDeviceVersionHistoryHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -241,3 +297,11 @@ func (UnimplementedUpdateServiceHandler) GetDeviceUpdateStatus(context.Context,
 func (UnimplementedUpdateServiceHandler) ReportUpdateStatus(context.Context, *connect.Request[v1.ReportUpdateStatusRequest]) (*connect.Response[v1.ReportUpdateStatusResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("fleetd.v1.UpdateService.ReportUpdateStatus is not implemented"))
 }
+
+func (UnimplementedUpdateServiceHandler) RollbackUpdateCampaign(context.Context, *connect.Request[v1.RollbackUpdateCampaignRequest]) (*connect.Response[v1.RollbackUpdateCampaignResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("fleetd.v1.UpdateService.RollbackUpdateCampaign is not implemented"))
+}
+
+func (UnimplementedUpdateServiceHandler) GetDeviceVersionHistory(context.Context, *connect.Request[v1.GetDeviceVersionHistoryRequest]) (*connect.Response[v1.GetDeviceVersionHistoryResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("fleetd.v1.UpdateService.GetDeviceVersionHistory is not implemented"))
+}
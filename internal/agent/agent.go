@@ -3,22 +3,29 @@ package agent
 import (
 	"bytes"
 	"context"
+	"crypto/ed25519"
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"log/slog"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 
 	agentrpc "fleetd.sh/gen/agent/v1/agentpbconnect"
 	"fleetd.sh/internal/discovery"
+	"fleetd.sh/internal/health"
+	"fleetd.sh/internal/middleware"
+	"fleetd.sh/internal/reflection"
 	rt "fleetd.sh/internal/runtime"
 	"fleetd.sh/internal/state"
 	"fleetd.sh/internal/update"
@@ -26,10 +33,14 @@ import (
 	"fleetd.sh/pkg/telemetry/handlers"
 	"fleetd.sh/pkg/telemetry/sources"
 
+	"connectrpc.com/connect"
+	"connectrpc.com/grpchealth"
 	"github.com/google/uuid"
 	"github.com/shirou/gopsutil/v3/cpu"
 	"github.com/shirou/gopsutil/v3/disk"
 	"github.com/shirou/gopsutil/v3/mem"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 // Agent represents the main fleetd device agent
@@ -43,6 +54,7 @@ type Agent struct {
 	discovery  *discovery.Discovery
 	runtime    *rt.Runtime
 	telemetry  *telemetry.Collector
+	backlog    *handlers.Buffered
 	updater    *update.Updater
 	state      *state.Manager
 	statePath  string
@@ -51,6 +63,9 @@ type Agent struct {
 	ready      chan struct{}
 	server     *http.Server
 	listener   net.Listener
+	drainer    *middleware.Drainer
+	restart    func() error // Replaces the process after an update
+	abUpdater  *update.ABUpdater
 }
 
 // New creates a new Agent instance
@@ -58,10 +73,11 @@ func New(cfg *Config) *Agent {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	return &Agent{
-		cfg:    cfg,
-		ctx:    ctx,
-		cancel: cancel,
-		ready:  make(chan struct{}),
+		cfg:     cfg,
+		ctx:     ctx,
+		cancel:  cancel,
+		ready:   make(chan struct{}),
+		drainer: middleware.NewDrainer(),
 		deviceInfo: &DeviceInfo{
 			DeviceID:   cfg.DeviceID,
 			DeviceType: runtime.GOARCH,
@@ -71,7 +87,7 @@ func New(cfg *Config) *Agent {
 }
 
 // Start initializes and starts all agent components
-func (a *Agent) Start() error {
+func (a *Agent) Start() (err error) {
 	// Configure logging
 	logHandler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
 		Level:     slog.LevelDebug,
@@ -93,8 +109,6 @@ func (a *Agent) Start() error {
 	}
 
 	// Initialize all components
-	var err error
-
 	// Initialize state manager
 	a.state, err = state.New(filepath.Join(a.cfg.StorageDir, "state", "state.json"))
 	if err != nil {
@@ -106,6 +120,9 @@ func (a *Agent) Start() error {
 	if err != nil {
 		return fmt.Errorf("failed to initialize runtime: %w", err)
 	}
+	if a.cfg.SecretsDir != "" {
+		a.runtime.SetSecretStore(rt.NewDirSecretStore(a.cfg.SecretsDir))
+	}
 
 	// Initialize device info
 	err = a.state.Update(func(s *state.State) error {
@@ -126,8 +143,82 @@ func (a *Agent) Start() error {
 		return fmt.Errorf("failed to initialize device info: %w", err)
 	}
 
+	// Restore the configuration applied before a restart
+	if saved := a.state.Get().Configuration; saved != nil {
+		a.config = &Configuration{
+			DeviceName:  saved.DeviceName,
+			APIEndpoint: saved.APIEndpoint,
+			FleetID:     saved.FleetID,
+		}
+		if saved.WiFiSSID != "" {
+			a.config.WiFi = &WiFiConfig{SSID: saved.WiFiSSID, Password: a.state.Secrets().WiFiPassword}
+		}
+		a.deviceInfo.Configured = true
+	}
+
+	// Remote agent binaries must be signed, not just checksummed
+	if a.cfg.SelfUpdate && a.cfg.UpdatePublicKey == "" {
+		return ErrSelfUpdateUnsigned
+	}
+
+	// Initialize updater
+	if a.updater == nil {
+		a.updater, err = update.New(filepath.Join(a.cfg.StorageDir, "update"))
+		if err != nil {
+			return fmt.Errorf("failed to initialize updater: %w", err)
+		}
+	}
+	var updateKey ed25519.PublicKey
+	if a.cfg.UpdatePublicKey != "" {
+		updateKey, err = update.ParsePublicKey(a.cfg.UpdatePublicKey)
+		if err != nil {
+			return fmt.Errorf("failed to parse update public key: %w", err)
+		}
+		a.updater.SetPublicKey(updateKey)
+		a.runtime.SetPublicKey(updateKey)
+	}
+	a.updater.SetDownloadLimit(a.cfg.MaxDownloadKBps)
+	if a.restart == nil {
+		a.restart = a.updater.Restart
+	}
+
+	// System images go to the inactive slot on A/B devices
+	if a.cfg.SlotDevices != "" && a.abUpdater == nil {
+		devices, err := update.ParseSlotDevices(a.cfg.SlotDevices)
+		if err != nil {
+			return fmt.Errorf("failed to parse slot devices: %w", err)
+		}
+		a.abUpdater, err = update.NewABUpdater(update.NewUBoot(), devices)
+		if err != nil {
+			return fmt.Errorf("failed to initialize A/B updater: %w", err)
+		}
+		if updateKey != nil {
+			a.abUpdater.SetPublicKey(updateKey)
+		}
+	}
+
+	// An updated agent binary is on trial until it has started and is ready
+	if a.cfg.SelfUpdate {
+		// Assigned to the named err so the deferred finishTrial sees failures
+		// later in Start
+		var trial *update.UpdateInfo
+		trial, err = a.updater.BeginTrial()
+		if errors.Is(err, update.ErrRolledBack) {
+			slog.Error("Updated agent failed to start, restarting previous version", "version", trial.Version)
+			a.RecordUpdate(trial.Version, false, "agent failed to start")
+			return a.restart()
+		}
+		if err != nil {
+			return fmt.Errorf("failed to check update trial: %w", err)
+		}
+		if trial != nil {
+			defer func() { a.finishTrial(trial, err) }()
+		}
+	}
+
 	// Initialize other components
-	a.discovery = discovery.New(a.cfg.DeviceID, a.cfg.MDNSPort, a.cfg.ServiceType)
+	a.discovery = discovery.New(a.cfg.DeviceID, a.cfg.MDNSPort, a.cfg.ServiceType).
+		WithAnnouncement(a.announcement())
 	a.telemetry = telemetry.New(time.Duration(a.cfg.TelemetryInterval) * time.Second)
 
 	// Add telemetry sources and handlers
@@ -139,7 +230,12 @@ func (a *Agent) Start() error {
 	if err != nil {
 		return fmt.Errorf("failed to initialize telemetry handler: %w", err)
 	}
-	a.telemetry.AddHandler(localHandler)
+	backlogPath := filepath.Join(a.cfg.StorageDir, "telemetry", "backlog.jsonl")
+	a.backlog, err = handlers.NewBuffered(localHandler, backlogPath, handlers.DefaultBufferConfig())
+	if err != nil {
+		return fmt.Errorf("failed to initialize telemetry backlog: %w", err)
+	}
+	a.telemetry.AddHandler(a.backlog)
 
 	// Only start discovery if not disabled
 	if !a.cfg.DisableMDNS {
@@ -152,13 +248,23 @@ func (a *Agent) Start() error {
 	service := NewDaemonService(a)
 
 	// Initialize and start RPC server
+	accessLog := connect.WithInterceptors(
+		middleware.NewAccessLogInterceptor(middleware.AccessLogConfigFromEnv("agent")),
+	)
 	mux := http.NewServeMux()
 	// Add the daemon service handler
-	mux.Handle(agentrpc.NewDaemonServiceHandler(service))
+	mux.Handle(agentrpc.NewDaemonServiceHandler(service, accessLog))
 	// Add the discovery service handler
 	discoveryService := NewDiscoveryService(a)
-	path, handler := agentrpc.NewDiscoveryServiceHandler(discoveryService)
+	path, handler := agentrpc.NewDiscoveryServiceHandler(discoveryService, accessLog)
 	mux.Handle(path, handler)
+	// Health checks and reflection for probes and tools like grpcurl
+	mux.Handle(grpchealth.NewHandler(grpchealth.NewStaticChecker(agentrpc.DaemonServiceName, agentrpc.DiscoveryServiceName)))
+	reflector := reflection.NewReflector(agentrpc.DaemonServiceName, agentrpc.DiscoveryServiceName, grpchealth.HealthV1ServiceName)
+	mux.Handle(reflection.NewHandlerV1(reflector))
+	mux.Handle(reflection.NewHandlerV1Alpha(reflector))
+	health.Register(mux, health.DefaultReadinessTimeout,
+		health.Dependency{Name: "server", Check: a.drainer.Ready})
 
 	// Create listener - bind to all interfaces
 	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", a.cfg.RPCPort))
@@ -168,8 +274,9 @@ func (a *Agent) Start() error {
 	a.listener = listener
 
 	// Create server
+	// Serve HTTP/2 without TLS too, which gRPC clients require
 	a.server = &http.Server{
-		Handler: mux,
+		Handler: h2c.NewHandler(middleware.DrainMiddleware(a.drainer)(mux), &http2.Server{}),
 	}
 
 	// Start server in goroutine
@@ -208,10 +315,14 @@ func (a *Agent) Stop() error {
 		return nil
 	}
 
-	// Shutdown RPC server first
+	// Shutdown RPC server first. Readiness fails from here on so load
+	// balancers stop sending traffic, and in-flight requests finish.
 	if a.server != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
+		if err := a.drainer.Drain(ctx); err != nil {
+			slog.Warn("RPC server did not drain", "in_flight", a.drainer.InFlight(), "error", err)
+		}
 		if err := a.server.Shutdown(ctx); err != nil {
 			slog.Error("Error shutting down RPC server", "error", err)
 		}
@@ -245,32 +356,119 @@ func (a *Agent) runMainLoop() {
 	}
 }
 
-// Update performs a self-update of the agent
-func (a *Agent) Update(binary io.Reader, info update.UpdateInfo) error {
+// ErrSelfUpdateDisabled is returned by Update when self-update isn't enabled
+var ErrSelfUpdateDisabled = errors.New("self-update is disabled")
+
+// ErrSelfUpdateUnsigned is returned by Start when self-update is enabled
+// without a public key to verify updates with
+var ErrSelfUpdateUnsigned = errors.New("self-update requires an update public key")
+
+// Update performs a self-update of the agent: binary replaces the agent
+// executable and the agent restarts into it. If the new binary then fails
+// to start and become ready, the previous one is restored.
+func (a *Agent) Update(ctx context.Context, binary io.Reader, info update.UpdateInfo) error {
+	if !a.cfg.SelfUpdate {
+		return ErrSelfUpdateDisabled
+	}
+	if a.updater == nil {
+		return fmt.Errorf("update support not available")
+	}
+
+	// Perform update
+	if err := a.updater.Update(ctx, binary, info); err != nil {
+		a.RecordUpdate(info.Version, false, err.Error())
+		return fmt.Errorf("update failed: %w", err)
+	}
+	return a.restartForUpdate()
+}
+
+// SelfUpdate downloads the agent binary at url and updates to it. The
+// agent restarts into the new binary in the background once SelfUpdate has
+// returned, so a request that triggered the update can still be answered.
+func (a *Agent) SelfUpdate(ctx context.Context, url string, info update.UpdateInfo) error {
+	if !a.cfg.SelfUpdate {
+		return ErrSelfUpdateDisabled
+	}
 	if a.updater == nil {
 		return fmt.Errorf("update support not available")
 	}
 
-	// Stop all services before update
+	if err := a.updater.Download(ctx, http.DefaultClient, url, info); err != nil {
+		a.RecordUpdate(info.Version, false, err.Error())
+		return fmt.Errorf("update failed: %w", err)
+	}
+	go func() {
+		if err := a.restartForUpdate(); err != nil {
+			slog.Error("Failed to restart into agent update", "version", info.Version, "error", err)
+		}
+	}()
+	return nil
+}
+
+func (a *Agent) restartForUpdate() error {
+	// Stop all services before restarting into the new binary
 	if err := a.Stop(); err != nil {
 		return fmt.Errorf("failed to stop services for update: %w", err)
 	}
+	if err := a.restart(); err != nil {
+		return fmt.Errorf("failed to restart agent after update: %w", err)
+	}
+	return nil
+}
 
-	// Perform update
-	if err := a.updater.Update(context.Background(), binary, info); err != nil {
-		return fmt.Errorf("update failed: %w", err)
+// finishTrial commits the agent update on trial once the agent has started
+// and reports ready, and otherwise rolls it back and restarts
+func (a *Agent) finishTrial(trial *update.UpdateInfo, startErr error) {
+	err := startErr
+	if err == nil {
+		err = a.checkReady()
+	}
+	if err == nil {
+		if err := a.updater.Commit(); err != nil {
+			slog.Error("Failed to commit agent update", "version", trial.Version, "error", err)
+			return
+		}
+		slog.Info("Agent update committed", "version", trial.Version)
+		a.RecordUpdate(trial.Version, true, "")
+		return
 	}
 
-	// Cleanup update files
-	if err := a.updater.Cleanup(); err != nil {
-		log.Printf("Failed to cleanup update files: %v", err)
+	slog.Error("Updated agent failed health check, rolling back", "version", trial.Version, "error", err)
+	if err := a.updater.Rollback(); err != nil {
+		slog.Error("Failed to roll back agent update", "version", trial.Version, "error", err)
+		return
+	}
+	a.RecordUpdate(trial.Version, false, err.Error())
+	if err := a.restart(); err != nil {
+		slog.Error("Failed to restart agent after rollback", "error", err)
 	}
+}
 
-	// Restart agent
-	if err := a.Start(); err != nil {
-		return fmt.Errorf("failed to restart agent after update: %w", err)
+// checkReady asks the agent's own RPC server whether it is ready
+func (a *Agent) checkReady() error {
+	if a.listener == nil {
+		return errors.New("RPC server is not listening")
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, port, err := net.SplitHostPort(a.listener.Addr().String())
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://127.0.0.1:"+port+health.ReadinessPath, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("readiness check failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("readiness check failed: %s", resp.Status)
+	}
 	return nil
 }
 
@@ -323,8 +521,10 @@ func (a *Agent) ListBinaries() ([]BinaryInfo, error) {
 	return binaries, nil
 }
 
-// DeployBinary deploys a new binary to the agent
-func (a *Agent) DeployBinary(name string, data []byte) error {
+// DeployBinary deploys a new binary to the agent. signature is the base64
+// Ed25519 signature of the binary's SHA256 digest, required when the agent
+// has an update public key.
+func (a *Agent) DeployBinary(name string, data []byte, signature string) error {
 	if a.runtime == nil {
 		return fmt.Errorf("runtime support not available")
 	}
@@ -335,10 +535,31 @@ func (a *Agent) DeployBinary(name string, data []byte) error {
 		"runtime_initialized", a.runtime != nil)
 
 	// Create a new reader from the byte slice - no need to pre-read
-	reader := bytes.NewReader(data)
+	return a.deployBinary(name, bytes.NewReader(data), signature)
+}
+
+// DeployBinaryFrom downloads the binary at url and deploys it like
+// DeployBinary. The download is throttled to MaxDownloadKBps.
+func (a *Agent) DeployBinaryFrom(ctx context.Context, name, url, signature string) error {
+	if a.runtime == nil {
+		return fmt.Errorf("runtime support not available")
+	}
+
+	slog.Info("Agent received deploy binary request",
+		"name", name,
+		"url", url)
+
+	binary, err := a.download(ctx, url)
+	if err != nil {
+		return fmt.Errorf("failed to deploy binary: %w", err)
+	}
+	defer binary.Close()
+	return a.deployBinary(name, binary, signature)
+}
 
+func (a *Agent) deployBinary(name string, binary io.Reader, signature string) error {
 	// Deploy binary through runtime
-	if err := a.runtime.Deploy(name, reader); err != nil {
+	if err := a.runtime.Deploy(name, binary, signature); err != nil {
 		slog.Error("Runtime deploy failed",
 			"error", err,
 			"name", name)
@@ -361,8 +582,8 @@ func (a *Agent) DeployBinary(name string, data []byte) error {
 	return nil
 }
 
-// StartBinary starts a deployed binary
-func (a *Agent) StartBinary(name string, args []string) error {
+// StartBinary starts a deployed binary with env added to its environment
+func (a *Agent) StartBinary(name string, args []string, env map[string]string) error {
 	if a.runtime == nil {
 		return fmt.Errorf("runtime support not available")
 	}
@@ -374,6 +595,7 @@ func (a *Agent) StartBinary(name string, args []string) error {
 			Timeout:     5 * time.Second,
 			MaxFailures: 3,
 		},
+		Environment: env,
 	}); err != nil {
 		return fmt.Errorf("failed to start binary: %w", err)
 	}
@@ -415,6 +637,89 @@ func (a *Agent) StopBinary(name string) error {
 	})
 }
 
+// ErrABUpdatesDisabled is returned for A/B updates on a device without slots
+var ErrABUpdatesDisabled = errors.New("A/B updates are not configured")
+
+// InstallImage writes a system image to the inactive slot of an A/B device
+// and sets it to be tried on the next boot. The device must then be
+// rebooted, and the update committed with CommitUpdate once the new system
+// is known to work.
+func (a *Agent) InstallImage(ctx context.Context, image io.Reader, info update.UpdateInfo) (update.Slot, error) {
+	if a.abUpdater == nil {
+		return "", ErrABUpdatesDisabled
+	}
+	slot, err := a.abUpdater.Install(ctx, image, info)
+	if err != nil {
+		a.RecordUpdate(info.Version, false, err.Error())
+		return "", err
+	}
+	slog.Info("System image installed, reboot to try it", "version", info.Version, "slot", slot)
+	return slot, nil
+}
+
+// InstallImageFrom downloads the system image at url and installs it like
+// InstallImage
+func (a *Agent) InstallImageFrom(ctx context.Context, url string, info update.UpdateInfo) (update.Slot, error) {
+	if a.abUpdater == nil {
+		return "", ErrABUpdatesDisabled
+	}
+	image, err := a.download(ctx, url)
+	if err != nil {
+		a.RecordUpdate(info.Version, false, err.Error())
+		return "", err
+	}
+	defer image.Close()
+	return a.InstallImage(ctx, image, info)
+}
+
+// download fetches url, at most as fast as MaxDownloadKBps allows
+func (a *Agent) download(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create download request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{update.ThrottleReader(ctx, resp.Body, a.cfg.MaxDownloadKBps), resp.Body}, nil
+}
+
+// CommitUpdate keeps the slot an A/B device booted after InstallImage.
+// committed is false if that slot was already committed, which is also the
+// case after the bootloader reverted a failed update.
+func (a *Agent) CommitUpdate() (slot update.Slot, committed bool, err error) {
+	if a.abUpdater == nil {
+		return "", false, ErrABUpdatesDisabled
+	}
+	slot, err = a.abUpdater.Commit()
+	if errors.Is(err, update.ErrNotOnTrial) {
+		return slot, false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	slog.Info("System update committed", "slot", slot)
+	return slot, true, nil
+}
+
+// StreamLogs sends the log output of a managed binary to send, optionally
+// skipping output written before since and following new output until ctx
+// is done
+func (a *Agent) StreamLogs(ctx context.Context, name string, tail int, since time.Time, follow, stderr bool, send func(line string) error) error {
+	if a.runtime == nil {
+		return fmt.Errorf("runtime support not available")
+	}
+	return a.runtime.StreamLogs(ctx, name, tail, since, follow, stderr, send)
+}
+
 // RecordUpdate records the result of an agent update
 func (a *Agent) RecordUpdate(version string, success bool, errorDetail string) error {
 	return a.state.Update(func(s *state.State) error {
@@ -434,6 +739,16 @@ func (a *Agent) State() *state.Manager {
 	return a.state
 }
 
+// GetState returns a snapshot of the agent's state, including the number of
+// metrics waiting to be delivered
+func (a *Agent) GetState() state.State {
+	s := a.state.Get()
+	if a.backlog != nil {
+		s.RuntimeState.TelemetryBacklog = a.backlog.Depth()
+	}
+	return s
+}
+
 // Runtime returns the agent's runtime manager
 func (a *Agent) Runtime() *rt.Runtime {
 	return a.runtime
@@ -456,9 +771,73 @@ type SystemStats struct {
 	DiskUsed    uint64
 }
 
+// Configuration is the setup a device receives through discovery
 type Configuration struct {
 	DeviceName  string
 	APIEndpoint string
+	FleetID     string
+	WiFi        *WiFiConfig
+
+	// Force replaces an existing configuration
+	Force bool
+}
+
+// WiFiConfig is the wireless network a device should join
+type WiFiConfig struct {
+	SSID     string
+	Password string // Empty for open networks
+}
+
+// LogValue implements slog.LogValuer, leaving out the password
+func (w WiFiConfig) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("ssid", w.SSID))
+}
+
+// ErrAlreadyConfigured is returned when configuring a device that already has
+// a configuration without Force
+var ErrAlreadyConfigured = errors.New("device is already configured")
+
+// Validate checks that the configuration can be applied
+func (c Configuration) Validate() error {
+	if c.APIEndpoint == "" {
+		return fmt.Errorf("api endpoint is required")
+	}
+	u, err := url.Parse(c.APIEndpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("api endpoint %q must be an http or https URL", c.APIEndpoint)
+	}
+	if len(c.DeviceName) > 64 {
+		return fmt.Errorf("device name must be at most 64 characters")
+	}
+	if c.WiFi != nil {
+		// Limits from 802.11 and WPA2
+		if c.WiFi.SSID == "" || len(c.WiFi.SSID) > 32 {
+			return fmt.Errorf("wifi ssid must be 1 to 32 bytes")
+		}
+		if n := len(c.WiFi.Password); n > 0 && (n < 8 || n > 63) {
+			return fmt.Errorf("wifi password must be 8 to 63 characters")
+		}
+	}
+	return nil
+}
+
+// agentCapabilities are the services the agent offers over its RPC server
+var agentCapabilities = []string{"daemon", "discovery", "telemetry", "update"}
+
+// announcement returns what the agent advertises about itself over mDNS, so
+// discovery clients can configure it without another round-trip. Callers
+// must hold a.mu.
+func (a *Agent) announcement() discovery.DeviceAnnouncement {
+	announcement := discovery.DeviceAnnouncement{
+		DeviceID:     a.deviceInfo.DeviceID,
+		Version:      a.deviceInfo.Version,
+		APIPort:      a.cfg.RPCPort,
+		Capabilities: agentCapabilities,
+	}
+	if a.config != nil {
+		announcement.APIEndpoint = a.config.APIEndpoint
+	}
+	return announcement
 }
 
 // GetDeviceInfo returns a copy of the current device info
@@ -498,17 +877,20 @@ func (a *Agent) Configure(cfg Configuration) error {
 
 // configure is the internal implementation of configuration updates
 func (a *Agent) configure(cfg Configuration) error {
-	// Validate configuration
-	if cfg.APIEndpoint == "" {
-		return fmt.Errorf("api endpoint is required")
+	if err := cfg.Validate(); err != nil {
+		return err
 	}
 
-	// Update internal state
-	a.config = &cfg
-
 	if a.deviceInfo == nil {
 		a.deviceInfo = &DeviceInfo{}
 	}
+	if a.deviceInfo.Configured && !cfg.Force {
+		return ErrAlreadyConfigured
+	}
+
+	// Update internal state
+	cfg.Force = false
+	a.config = &cfg
 
 	a.deviceInfo.Configured = true
 	if a.deviceInfo.DeviceID == "" {
@@ -516,11 +898,35 @@ func (a *Agent) configure(cfg Configuration) error {
 	}
 	a.deviceInfo.APIKey = generateAPIKey()
 
-	// Persist state
+	// Advertise the new API endpoint to discovery clients
+	if a.discovery != nil {
+		if err := a.discovery.Announce(a.announcement()); err != nil {
+			slog.Error("Failed to update mDNS announcement", "error", err)
+		}
+	}
+
+	// Persist state, keeping the WiFi password out of the state file
 	if a.state != nil {
+		if err := a.state.UpdateSecrets(func(s *state.Secrets) error {
+			s.WiFiPassword = ""
+			if cfg.WiFi != nil {
+				s.WiFiPassword = cfg.WiFi.Password
+			}
+			return nil
+		}); err != nil {
+			return fmt.Errorf("failed to persist configuration: %w", err)
+		}
 		if err := a.state.Update(func(s *state.State) error {
 			s.DeviceInfo.ID = a.deviceInfo.DeviceID
-			// Update other state fields as needed
+			s.Configuration = &state.Configuration{
+				DeviceName:   cfg.DeviceName,
+				APIEndpoint:  cfg.APIEndpoint,
+				FleetID:      cfg.FleetID,
+				ConfiguredAt: time.Now(),
+			}
+			if cfg.WiFi != nil {
+				s.Configuration.WiFiSSID = cfg.WiFi.SSID
+			}
 			return nil
 		}); err != nil {
 			return fmt.Errorf("failed to persist configuration: %w", err)
@@ -530,8 +936,44 @@ func (a *Agent) configure(cfg Configuration) error {
 	return nil
 }
 
+// hardwareIDFiles are read in order for a stable machine identifier. The
+// firmware UUID and the board serial (e.g. of a Raspberry Pi) come first
+// since they survive re-flashing. machine-id is only a fallback: it is
+// generated when an image is first booted.
+var hardwareIDFiles = []string{
+	"/sys/class/dmi/id/product_uuid",
+	"/proc/device-tree/serial-number",
+	"/etc/machine-id",
+	"/var/lib/dbus/machine-id",
+}
+
+// hardwareIDNamespace scopes hardware IDs to fleetd, so the raw machine ID is
+// never sent to the server
+var hardwareIDNamespace = uuid.MustParse("6f1c7a52-2f3e-4d5b-9a0e-8b6d1c4e2f70")
+
+// HardwareID returns an identifier that stays the same across reboots and
+// reinstalls of the agent, derived from the first readable hardwareIDFiles.
+// It returns an empty string if the machine has no readable identifier.
+func HardwareID() string {
+	for _, path := range hardwareIDFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		// Device tree strings end in a NUL
+		if id := strings.TrimSpace(strings.TrimRight(string(data), "\x00")); id != "" {
+			return uuid.NewSHA1(hardwareIDNamespace, []byte(id)).String()
+		}
+	}
+	return ""
+}
+
 // Helper functions
 func generateDeviceID() string {
+	// Prefer an ID derived from the hardware so it survives re-flashing
+	if id := HardwareID(); id != "" {
+		return id
+	}
 	// Generate a UUID v4
 	id := uuid.New()
 	return id.String()
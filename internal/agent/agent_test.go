@@ -1,8 +1,30 @@
 package agent
 
 import (
+	"bytes"
 	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	agentpb "fleetd.sh/gen/agent/v1"
+	"fleetd.sh/internal/discovery"
+	"fleetd.sh/internal/state"
+	"fleetd.sh/internal/update"
+
+	"connectrpc.com/connect"
 )
 
 func TestBinaryManagement(t *testing.T) {
@@ -28,7 +50,7 @@ while true; do
 done
 `)
 
-	if err := agent.DeployBinary("test-script", testScript); err != nil {
+	if err := agent.DeployBinary("test-script", testScript, ""); err != nil {
 		t.Fatalf("Failed to deploy binary: %v", err)
 	}
 
@@ -45,7 +67,7 @@ done
 	}
 
 	// Test starting binary
-	if err := agent.StartBinary("test-script", []string{}); err != nil {
+	if err := agent.StartBinary("test-script", []string{}, nil); err != nil {
 		t.Fatalf("Failed to start binary: %v", err)
 	}
 
@@ -118,3 +140,544 @@ func TestUpdateBinaryState(t *testing.T) {
 		t.Errorf("Unexpected binary state: %+v", binary)
 	}
 }
+
+func TestGetStateTelemetryBacklog(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Leave a backlog from a previous run that couldn't deliver
+	backlog := `{"name":"cpu","value":1,"timestamp":"` + time.Now().Format(time.RFC3339) + `"}` + "\n"
+	if err := os.MkdirAll(filepath.Join(tmpDir, "telemetry"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "telemetry", "backlog.jsonl"), []byte(backlog+backlog), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &Config{
+		DeviceID:          "test-device",
+		StorageDir:        tmpDir,
+		TelemetryInterval: 60,
+		DisableMDNS:       true,
+	}
+
+	agent := New(cfg)
+	if err := agent.Start(); err != nil {
+		t.Fatalf("Failed to start agent: %v", err)
+	}
+	defer agent.Stop()
+
+	if depth := agent.GetState().RuntimeState.TelemetryBacklog; depth != 2 {
+		t.Errorf("Expected telemetry backlog 2, got %d", depth)
+	}
+}
+
+func TestAnnouncement(t *testing.T) {
+	agent := New(&Config{DeviceID: "test-device", RPCPort: 9090, DisableMDNS: true})
+	agent.discovery = discovery.New("test-device", 0, "").WithAnnouncement(agent.announcement())
+
+	// The advertised TXT fields parse back into the typed announcement
+	got := discovery.ParseAnnouncement(agent.discovery.TXT())
+	if got.DeviceID != "test-device" || got.APIPort != 9090 || got.Version != agent.deviceInfo.Version {
+		t.Errorf("Unexpected announcement: %+v", got)
+	}
+	if !reflect.DeepEqual(got.Capabilities, agentCapabilities) {
+		t.Errorf("Capabilities = %v, want %v", got.Capabilities, agentCapabilities)
+	}
+	if got.APIEndpoint != "" {
+		t.Errorf("Unconfigured agent should not advertise an API endpoint, got %q", got.APIEndpoint)
+	}
+
+	// Configuring the agent advertises its new endpoint
+	if err := agent.Configure(Configuration{APIEndpoint: "https://fleet.example.com"}); err != nil {
+		t.Fatalf("Failed to configure agent: %v", err)
+	}
+	got = discovery.ParseAnnouncement(agent.discovery.TXT())
+	if got.APIEndpoint != "https://fleet.example.com" {
+		t.Errorf("APIEndpoint = %q, want https://fleet.example.com", got.APIEndpoint)
+	}
+}
+
+func TestConfigureDevice(t *testing.T) {
+	agent := New(&Config{DeviceID: "test-device", DisableMDNS: true})
+	stateDir := t.TempDir()
+	var err error
+	agent.state, err = state.New(filepath.Join(stateDir, "state.json"))
+	if err != nil {
+		t.Fatalf("Failed to create state manager: %v", err)
+	}
+	service := NewDiscoveryService(agent)
+	ctx := context.Background()
+
+	// First-time configuration succeeds and is persisted
+	resp, err := service.ConfigureDevice(ctx, connect.NewRequest(&agentpb.ConfigureDeviceRequest{
+		DeviceName:  "kiosk-1",
+		ApiEndpoint: "https://fleet.example.com",
+		FleetId:     "edge",
+		Wifi:        &agentpb.WifiConfig{Ssid: "office", Password: "correct horse"},
+	}))
+	if err != nil {
+		t.Fatalf("Failed to configure device: %v", err)
+	}
+	if !resp.Msg.Success || resp.Msg.DeviceId != "test-device" || resp.Msg.ApiKey == "" {
+		t.Errorf("Unexpected response: %+v", resp.Msg)
+	}
+	if !strings.Contains(resp.Msg.Message, "configured successfully") {
+		t.Errorf("Unexpected message: %q", resp.Msg.Message)
+	}
+
+	saved := agent.state.Get().Configuration
+	if saved == nil || saved.DeviceName != "kiosk-1" || saved.FleetID != "edge" || saved.WiFiSSID != "office" {
+		t.Errorf("Configuration was not persisted: %+v", saved)
+	}
+	if got := agent.state.Secrets().WiFiPassword; got != "correct horse" {
+		t.Errorf("WiFi password was not persisted, got %q", got)
+	}
+	data, err := os.ReadFile(filepath.Join(stateDir, "state.json"))
+	if err != nil {
+		t.Fatalf("Failed to read state file: %v", err)
+	}
+	if strings.Contains(string(data), "correct horse") {
+		t.Error("State file contains the WiFi password")
+	}
+	var logs bytes.Buffer
+	slog.New(slog.NewTextHandler(&logs, nil)).Info("configured", "wifi", *agent.config.WiFi)
+	if strings.Contains(logs.String(), "correct horse") {
+		t.Errorf("WiFi password was logged: %s", logs.String())
+	}
+
+	// Configuring again without force is rejected
+	_, err = service.ConfigureDevice(ctx, connect.NewRequest(&agentpb.ConfigureDeviceRequest{
+		ApiEndpoint: "https://other.example.com",
+	}))
+	if connect.CodeOf(err) != connect.CodeFailedPrecondition {
+		t.Errorf("Expected FailedPrecondition, got %v", err)
+	}
+	if saved := agent.state.Get().Configuration; saved.APIEndpoint != "https://fleet.example.com" {
+		t.Errorf("Rejected configuration was applied: %+v", saved)
+	}
+
+	// And replaces the configuration with force
+	resp, err = service.ConfigureDevice(ctx, connect.NewRequest(&agentpb.ConfigureDeviceRequest{
+		ApiEndpoint: "https://other.example.com",
+		Force:       true,
+	}))
+	if err != nil {
+		t.Fatalf("Failed to reconfigure device: %v", err)
+	}
+	if !strings.Contains(resp.Msg.Message, "reconfigured") {
+		t.Errorf("Unexpected message: %q", resp.Msg.Message)
+	}
+
+	// Invalid settings are rejected before anything is applied
+	for _, req := range []*agentpb.ConfigureDeviceRequest{
+		{},
+		{ApiEndpoint: "fleet.example.com"},
+		{ApiEndpoint: "ftp://fleet.example.com"},
+		{ApiEndpoint: "https://"},
+		{ApiEndpoint: "https://fleet.example.com", Wifi: &agentpb.WifiConfig{Ssid: "office", Password: "short"}},
+		{ApiEndpoint: "https://fleet.example.com", Wifi: &agentpb.WifiConfig{}},
+	} {
+		req.Force = true
+		_, err := service.ConfigureDevice(ctx, connect.NewRequest(req))
+		if connect.CodeOf(err) != connect.CodeInvalidArgument {
+			t.Errorf("Expected InvalidArgument for %+v, got %v", req, err)
+		}
+	}
+}
+
+// newUpdateKey returns a base64 encoded public key for Config and the
+// private key to sign updates with
+func newUpdateKey(t *testing.T) (string, ed25519.PrivateKey) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(publicKey), privateKey
+}
+
+// signUpdate returns the checksum and signature of binary
+func signUpdate(privateKey ed25519.PrivateKey, binary []byte) (string, string) {
+	sum := sha256.Sum256(binary)
+	return hex.EncodeToString(sum[:]), base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, sum[:]))
+}
+
+func TestSelfUpdate(t *testing.T) {
+	publicKey, _ := newUpdateKey(t)
+	for _, enabled := range []bool{false, true} {
+		cfg := &Config{
+			DeviceID:          "test-device",
+			StorageDir:        t.TempDir(),
+			TelemetryInterval: 60,
+			DisableMDNS:       true,
+			SelfUpdate:        enabled,
+			UpdatePublicKey:   publicKey,
+		}
+		agent := New(cfg)
+		restarted := false
+		agent.restart = func() error {
+			restarted = true
+			return nil
+		}
+		if err := agent.Start(); err != nil {
+			t.Fatalf("Failed to start agent: %v", err)
+		}
+
+		info := update.UpdateInfo{Version: "2.0.0", SHA256: "not the checksum"}
+		err := agent.Update(context.Background(), strings.NewReader("new agent"), info)
+		if !enabled {
+			if !errors.Is(err, ErrSelfUpdateDisabled) {
+				t.Errorf("Update with self-update disabled = %v, want ErrSelfUpdateDisabled", err)
+			}
+		} else {
+			if err == nil {
+				t.Error("Expected an update with a bad checksum to fail")
+			}
+			history := agent.State().Get().UpdateHistory
+			if len(history) != 1 || history[0].Version != "2.0.0" || history[0].Success {
+				t.Errorf("Unexpected update history: %+v", history)
+			}
+		}
+		if restarted {
+			t.Error("Agent restarted after a refused update")
+		}
+		agent.Stop()
+	}
+}
+
+// trialBootloader is an A/B bootloader that booted slot b on trial
+type trialBootloader struct {
+	committed update.Slot
+}
+
+func (b *trialBootloader) Booted() (update.Slot, error)    { return update.SlotB, nil }
+func (b *trialBootloader) Committed() (update.Slot, error) { return b.committed, nil }
+func (b *trialBootloader) TryBoot(update.Slot) error       { return nil }
+func (b *trialBootloader) Commit(slot update.Slot) error {
+	b.committed = slot
+	return nil
+}
+
+func TestCommitUpdate(t *testing.T) {
+	agent := New(&Config{DeviceID: "test-device", DisableMDNS: true})
+	service := NewDaemonService(agent)
+	ctx := context.Background()
+
+	_, err := service.CommitUpdate(ctx, connect.NewRequest(&agentpb.CommitUpdateRequest{}))
+	if connect.CodeOf(err) != connect.CodeFailedPrecondition {
+		t.Errorf("Expected FailedPrecondition without A/B slots, got %v", err)
+	}
+
+	boot := &trialBootloader{committed: update.SlotA}
+	agent.abUpdater, err = update.NewABUpdater(boot, map[update.Slot]string{
+		update.SlotA: "/dev/null",
+		update.SlotB: "/dev/null",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create A/B updater: %v", err)
+	}
+
+	resp, err := service.CommitUpdate(ctx, connect.NewRequest(&agentpb.CommitUpdateRequest{}))
+	if err != nil {
+		t.Fatalf("Failed to commit update: %v", err)
+	}
+	if resp.Msg.Slot != "b" || !resp.Msg.Committed || boot.committed != update.SlotB {
+		t.Errorf("Unexpected commit: %+v, committed slot %s", resp.Msg, boot.committed)
+	}
+
+	// Committing again is a no-op
+	resp, err = service.CommitUpdate(ctx, connect.NewRequest(&agentpb.CommitUpdateRequest{}))
+	if err != nil {
+		t.Fatalf("Failed to commit update: %v", err)
+	}
+	if resp.Msg.Slot != "b" || resp.Msg.Committed {
+		t.Errorf("Unexpected second commit: %+v", resp.Msg)
+	}
+}
+
+func TestSelfUpdateRequiresPublicKey(t *testing.T) {
+	agent := New(&Config{
+		DeviceID:          "test-device",
+		StorageDir:        t.TempDir(),
+		TelemetryInterval: 60,
+		DisableMDNS:       true,
+		SelfUpdate:        true,
+	})
+	if err := agent.Start(); !errors.Is(err, ErrSelfUpdateUnsigned) {
+		agent.Stop()
+		t.Fatalf("Start with self-update and no public key = %v, want ErrSelfUpdateUnsigned", err)
+	}
+}
+
+// newScratchUpdater returns an updater for a scratch executable rather than
+// the test binary
+func newScratchUpdater(t *testing.T) (*update.Updater, string) {
+	execPath := filepath.Join(t.TempDir(), "fleetd")
+	if err := os.WriteFile(execPath, []byte("#!/bin/sh\n# current\n"), 0755); err != nil {
+		t.Fatalf("Failed to write executable: %v", err)
+	}
+	updater, err := update.NewForExecutable(t.TempDir(), execPath)
+	if err != nil {
+		t.Fatalf("Failed to create updater: %v", err)
+	}
+	return updater, execPath
+}
+
+func TestUpdateAgent(t *testing.T) {
+	publicKey, privateKey := newUpdateKey(t)
+	newBinary := []byte("#!/bin/sh\nexit 0\n")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(newBinary)
+	}))
+	defer server.Close()
+	sum, signature := signUpdate(privateKey, newBinary)
+	req := &agentpb.UpdateAgentRequest{
+		Url:       server.URL,
+		Version:   "2.0.0",
+		Sha256:    sum,
+		Signature: signature,
+	}
+
+	for _, enabled := range []bool{false, true} {
+		agent := New(&Config{
+			DeviceID:          "test-device",
+			StorageDir:        t.TempDir(),
+			TelemetryInterval: 60,
+			DisableMDNS:       true,
+			SelfUpdate:        enabled,
+			UpdatePublicKey:   publicKey,
+		})
+		restarted := make(chan struct{})
+		agent.restart = func() error {
+			close(restarted)
+			return nil
+		}
+		updater, execPath := newScratchUpdater(t)
+		agent.updater = updater
+		if err := agent.Start(); err != nil {
+			t.Fatalf("Failed to start agent: %v", err)
+		}
+
+		service := NewDaemonService(agent)
+		_, err := service.UpdateAgent(context.Background(), connect.NewRequest(req))
+		if !enabled {
+			if connect.CodeOf(err) != connect.CodeFailedPrecondition {
+				t.Errorf("Expected FailedPrecondition with self-update disabled, got %v", err)
+			}
+			agent.Stop()
+			continue
+		}
+		if err != nil {
+			t.Fatalf("UpdateAgent failed: %v", err)
+		}
+
+		select {
+		case <-restarted:
+		case <-time.After(10 * time.Second):
+			t.Fatal("Agent did not restart into the update")
+		}
+		installed, err := os.ReadFile(execPath)
+		if err != nil || !bytes.Equal(installed, newBinary) {
+			t.Errorf("Expected the update to be installed, got %q (%v)", installed, err)
+		}
+
+		// An unsigned binary is refused
+		unsigned := &agentpb.UpdateAgentRequest{Url: req.Url, Version: req.Version, Sha256: req.Sha256}
+		if _, err := service.UpdateAgent(context.Background(), connect.NewRequest(unsigned)); err == nil {
+			t.Error("Expected an unsigned update to be refused")
+		}
+		agent.Stop()
+	}
+}
+
+func TestSelfUpdateRollbackOnStartFailure(t *testing.T) {
+	publicKey, privateKey := newUpdateKey(t)
+	updater, execPath := newScratchUpdater(t)
+	updater.SetPublicKey(mustParseKey(t, publicKey))
+
+	// Install an update, leaving it on trial for the next start
+	newBinary := []byte("#!/bin/sh\nexit 0\n")
+	sum, signature := signUpdate(privateKey, newBinary)
+	info := update.UpdateInfo{Version: "2.0.0", SHA256: sum, Signature: signature}
+	if err := updater.Update(context.Background(), bytes.NewReader(newBinary), info); err != nil {
+		t.Fatalf("Failed to install update: %v", err)
+	}
+
+	// The updated agent can't bind its RPC port
+	taken, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("Failed to take a port: %v", err)
+	}
+	defer taken.Close()
+
+	agent := New(&Config{
+		DeviceID:          "test-device",
+		StorageDir:        t.TempDir(),
+		TelemetryInterval: 60,
+		DisableMDNS:       true,
+		RPCPort:           taken.Addr().(*net.TCPAddr).Port,
+		SelfUpdate:        true,
+		UpdatePublicKey:   publicKey,
+	})
+	agent.updater = updater
+	restarted := false
+	agent.restart = func() error {
+		restarted = true
+		return nil
+	}
+	if err := agent.Start(); err == nil {
+		agent.Stop()
+		t.Fatal("Expected start to fail with the RPC port taken")
+	}
+
+	if !restarted {
+		t.Error("Agent did not restart after rolling back")
+	}
+	if got := readExec(t, execPath); got != "#!/bin/sh\n# current\n" {
+		t.Errorf("Expected the previous binary to be restored, got %q", got)
+	}
+	// The start failure itself is recorded, not a later readiness check
+	history := agent.State().Get().UpdateHistory
+	if len(history) != 1 || history[0].Version != "2.0.0" || history[0].Success ||
+		!strings.Contains(history[0].ErrorDetail, "failed to create listener") {
+		t.Errorf("Unexpected update history: %+v", history)
+	}
+}
+
+func mustParseKey(t *testing.T, encoded string) ed25519.PublicKey {
+	key, err := update.ParsePublicKey(encoded)
+	if err != nil {
+		t.Fatalf("Failed to parse key: %v", err)
+	}
+	return key
+}
+
+func readExec(t *testing.T, path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read executable: %v", err)
+	}
+	return string(data)
+}
+
+// committedBootloader is an A/B bootloader running its committed slot a
+type committedBootloader struct {
+	tried update.Slot
+}
+
+func (b *committedBootloader) Booted() (update.Slot, error)    { return update.SlotA, nil }
+func (b *committedBootloader) Committed() (update.Slot, error) { return update.SlotA, nil }
+func (b *committedBootloader) Commit(update.Slot) error        { return nil }
+func (b *committedBootloader) TryBoot(slot update.Slot) error {
+	b.tried = slot
+	return nil
+}
+
+func TestInstallImage(t *testing.T) {
+	image := []byte("system image")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(image)
+	}))
+	defer server.Close()
+	sum := sha256.Sum256(image)
+	req := &agentpb.InstallImageRequest{
+		Url:     server.URL,
+		Version: "2.0.0",
+		Sha256:  hex.EncodeToString(sum[:]),
+	}
+
+	agent := New(&Config{DeviceID: "test-device", DisableMDNS: true})
+	service := NewDaemonService(agent)
+	ctx := context.Background()
+
+	_, err := service.InstallImage(ctx, connect.NewRequest(req))
+	if connect.CodeOf(err) != connect.CodeFailedPrecondition {
+		t.Errorf("Expected FailedPrecondition without A/B slots, got %v", err)
+	}
+
+	dir := t.TempDir()
+	devices := map[update.Slot]string{
+		update.SlotA: filepath.Join(dir, "a"),
+		update.SlotB: filepath.Join(dir, "b"),
+	}
+	for _, device := range devices {
+		if err := os.WriteFile(device, nil, 0600); err != nil {
+			t.Fatalf("Failed to create slot device: %v", err)
+		}
+	}
+	boot := &committedBootloader{}
+	agent.abUpdater, err = update.NewABUpdater(boot, devices)
+	if err != nil {
+		t.Fatalf("Failed to create A/B updater: %v", err)
+	}
+
+	resp, err := service.InstallImage(ctx, connect.NewRequest(req))
+	if err != nil {
+		t.Fatalf("Failed to install image: %v", err)
+	}
+	if resp.Msg.Slot != "b" || boot.tried != update.SlotB {
+		t.Errorf("Expected slot b to be tried, got %+v, tried %q", resp.Msg, boot.tried)
+	}
+	written, err := os.ReadFile(devices[update.SlotB])
+	if err != nil || !bytes.Equal(written, image) {
+		t.Errorf("Expected the image in slot b, got %q (%v)", written, err)
+	}
+}
+
+func TestDeployBinaryFromURL(t *testing.T) {
+	if testing.Short() {
+		t.Skip("measures a throttled download")
+	}
+
+	const (
+		limitKBps = 32
+		size      = 64 * 1024
+	)
+	binary := bytes.Repeat([]byte("x"), size)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(binary)
+	}))
+	defer server.Close()
+
+	storageDir := t.TempDir()
+	agent := New(&Config{
+		DeviceID:          "test-device",
+		StorageDir:        storageDir,
+		TelemetryInterval: 60,
+		DisableMDNS:       true,
+		MaxDownloadKBps:   limitKBps,
+	})
+	if err := agent.Start(); err != nil {
+		t.Fatalf("Failed to start agent: %v", err)
+	}
+	defer agent.Stop()
+	service := NewDaemonService(agent)
+	ctx := context.Background()
+
+	_, err := service.DeployBinary(ctx, connect.NewRequest(&agentpb.DeployBinaryRequest{
+		Name: "app",
+		Data: binary,
+		Url:  server.URL,
+	}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("Expected InvalidArgument for both data and url, got %v", err)
+	}
+
+	start := time.Now()
+	_, err = service.DeployBinary(ctx, connect.NewRequest(&agentpb.DeployBinaryRequest{
+		Name: "app",
+		Url:  server.URL,
+	}))
+	if err != nil {
+		t.Fatalf("Failed to deploy binary: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	installed, err := os.ReadFile(filepath.Join(storageDir, "runtime", "app"))
+	if err != nil || !bytes.Equal(installed, binary) {
+		t.Errorf("Expected the download to be deployed (%v)", err)
+	}
+	// Only the cap is asserted: a loaded machine may download slower
+	if kbps := float64(size) / 1024 / elapsed.Seconds(); kbps > limitKBps*1.15 {
+		t.Errorf("Download took %v, %.1f KiB/s, want at most %d KiB/s", elapsed, kbps, limitKBps)
+	}
+}
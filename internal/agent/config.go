@@ -2,8 +2,6 @@ package agent
 
 import (
 	"flag"
-
-	"github.com/google/uuid"
 )
 
 // Config holds the agent configuration
@@ -37,6 +35,28 @@ type Config struct {
 
 	// ServiceType is the mDNS service type to use
 	ServiceType string
+
+	// UpdatePublicKey is the base64 encoded Ed25519 key of the fleet. When set,
+	// updates are only applied if their signature verifies against it.
+	UpdatePublicKey string
+
+	// SelfUpdate allows the agent binary to be replaced remotely. It requires
+	// UpdatePublicKey, so only signed binaries are accepted. An updated
+	// binary that fails to start and become ready is rolled back.
+	SelfUpdate bool
+
+	// SlotDevices enables A/B system updates on devices booted by U-Boot,
+	// naming the partition of each slot: a=/dev/mmcblk0p2,b=/dev/mmcblk0p3
+	SlotDevices string
+
+	// MaxDownloadKBps caps the speed of binary, image and update downloads in
+	// KiB per second, for devices on metered or cellular links. Zero means no
+	// cap.
+	MaxDownloadKBps int
+
+	// SecretsDir holds the secrets provisioned on the device, one file per
+	// secret. Binaries reference them in their environment as ${secret:name}.
+	SecretsDir string
 }
 
 const (
@@ -47,7 +67,7 @@ const (
 // DefaultConfig returns a Config with default values
 func DefaultConfig() *Config {
 	return &Config{
-		DeviceID:            uuid.New().String(),
+		DeviceID:            generateDeviceID(),
 		StorageDir:          "/var/lib/fleetd",
 		ServerURL:           "http://localhost:8080",
 		EnableMDNS:          true,
@@ -67,6 +87,11 @@ func ParseFlags() *Config {
 	flag.StringVar(&cfg.ServerURL, "server-url", cfg.ServerURL, "URL of the fleet management server")
 	flag.BoolVar(&cfg.DisableMDNS, "disable-mdns", false, "Disable mDNS discovery")
 	flag.IntVar(&cfg.RPCPort, "rpc-port", cfg.RPCPort, "Port to use for the local RPC server")
+	flag.StringVar(&cfg.UpdatePublicKey, "update-public-key", cfg.UpdatePublicKey, "Base64 Ed25519 public key used to verify updates")
+	flag.BoolVar(&cfg.SelfUpdate, "self-update", cfg.SelfUpdate, "Allow the agent binary to be updated remotely (requires -update-public-key)")
+	flag.StringVar(&cfg.SlotDevices, "slot-devices", cfg.SlotDevices, "Partitions of the A/B slots, e.g. a=/dev/mmcblk0p2,b=/dev/mmcblk0p3")
+	flag.IntVar(&cfg.MaxDownloadKBps, "max-download-kbps", cfg.MaxDownloadKBps, "Maximum download speed of binaries and updates in KiB/s (0 for unlimited)")
+	flag.StringVar(&cfg.SecretsDir, "secrets-dir", cfg.SecretsDir, "Directory of secrets binaries can reference as ${secret:name}")
 	flag.Parse()
 	return cfg
 }
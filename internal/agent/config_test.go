@@ -1,6 +1,9 @@
 package agent
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -19,3 +22,55 @@ func TestDefaultConfig(t *testing.T) {
 		t.Errorf("Expected UpdateCheckInterval to be 24, got %d", cfg.UpdateCheckInterval)
 	}
 }
+
+func TestHardwareID(t *testing.T) {
+	dir := t.TempDir()
+	machineID := filepath.Join(dir, "machine-id")
+	if err := os.WriteFile(machineID, []byte("0123456789abcdef0123456789abcdef\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := hardwareIDFiles
+	defer func() { hardwareIDFiles = saved }()
+	hardwareIDFiles = []string{filepath.Join(dir, "missing"), machineID}
+
+	id := HardwareID()
+	if id == "" {
+		t.Fatal("Expected a hardware ID")
+	}
+	if strings.Contains(id, "0123456789abcdef") {
+		t.Errorf("Expected the raw machine ID not to be exposed, got %s", id)
+	}
+	if got := generateDeviceID(); got != id {
+		t.Errorf("Expected device ID %s to be derived from the hardware ID, got %s", id, got)
+	}
+	if got := DefaultConfig().DeviceID; got != id {
+		t.Errorf("Expected default device ID to be stable, got %s", got)
+	}
+
+	// The board serial is preferred over machine-id, which changes when the
+	// device is re-flashed
+	serial := filepath.Join(dir, "serial-number")
+	if err := os.WriteFile(serial, []byte("10000000abcdef01\x00"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hardwareIDFiles = []string{filepath.Join(dir, "missing"), serial, machineID}
+	serialID := HardwareID()
+	if serialID == "" || serialID == id {
+		t.Errorf("Expected the ID to be derived from the serial, got %s", serialID)
+	}
+	if err := os.WriteFile(serial, []byte("10000000abcdef01"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := HardwareID(); got != serialID {
+		t.Errorf("Expected the trailing NUL to be ignored, got %s, want %s", got, serialID)
+	}
+
+	hardwareIDFiles = []string{filepath.Join(dir, "missing")}
+	if id := HardwareID(); id != "" {
+		t.Errorf("Expected no hardware ID, got %s", id)
+	}
+	if generateDeviceID() == generateDeviceID() {
+		t.Error("Expected random device IDs without a hardware ID")
+	}
+}
@@ -2,10 +2,15 @@ package agent
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"os"
+	"time"
 
 	"connectrpc.com/connect"
 	agentpb "fleetd.sh/gen/agent/v1"
+	"fleetd.sh/internal/update"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -23,19 +28,32 @@ func (s *DaemonService) DeployBinary(
 ) (*connect.Response[agentpb.DeployBinaryResponse], error) {
 	slog.Info("Received deploy binary request",
 		"name", req.Msg.Name,
-		"size", len(req.Msg.Data))
+		"size", len(req.Msg.Data),
+		"url", req.Msg.Url)
 
-	if err := s.agent.DeployBinary(req.Msg.Name, req.Msg.Data); err != nil {
+	if (req.Msg.Url == "") == (len(req.Msg.Data) == 0) {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("exactly one of data and url is required"))
+	}
+
+	var err error
+	if req.Msg.Url != "" {
+		err = s.agent.DeployBinaryFrom(ctx, req.Msg.Name, req.Msg.Url, req.Msg.Signature)
+	} else {
+		err = s.agent.DeployBinary(req.Msg.Name, req.Msg.Data, req.Msg.Signature)
+	}
+	if err != nil {
 		slog.Error("Failed to deploy binary",
 			"error", err,
 			"name", req.Msg.Name,
-			"size", len(req.Msg.Data))
+			"size", len(req.Msg.Data),
+			"url", req.Msg.Url)
 		return nil, connect.NewError(connect.CodeInternal, err)
 	}
 
 	slog.Info("Successfully deployed binary",
 		"name", req.Msg.Name,
-		"size", len(req.Msg.Data))
+		"size", len(req.Msg.Data),
+		"url", req.Msg.Url)
 	return connect.NewResponse(&agentpb.DeployBinaryResponse{}), nil
 }
 
@@ -43,7 +61,7 @@ func (s *DaemonService) StartBinary(
 	ctx context.Context,
 	req *connect.Request[agentpb.StartBinaryRequest],
 ) (*connect.Response[agentpb.StartBinaryResponse], error) {
-	if err := s.agent.StartBinary(req.Msg.Name, req.Msg.Args); err != nil {
+	if err := s.agent.StartBinary(req.Msg.Name, req.Msg.Args, req.Msg.Env); err != nil {
 		return nil, connect.NewError(connect.CodeInternal, err)
 	}
 	return connect.NewResponse(&agentpb.StartBinaryResponse{}), nil
@@ -83,6 +101,91 @@ func (s *DaemonService) ListBinaries(
 	return connect.NewResponse(resp), nil
 }
 
+func (s *DaemonService) StreamLogs(
+	ctx context.Context,
+	req *connect.Request[agentpb.StreamLogsRequest],
+	stream *connect.ServerStream[agentpb.StreamLogsResponse],
+) error {
+	if req.Msg.Name == "" {
+		return connect.NewError(connect.CodeInvalidArgument, errors.New("name is required"))
+	}
+
+	var since time.Time
+	if req.Msg.Since != nil {
+		since = req.Msg.Since.AsTime()
+	}
+
+	err := s.agent.StreamLogs(ctx, req.Msg.Name, int(req.Msg.TailLines), since, req.Msg.Follow, req.Msg.Stderr, func(line string) error {
+		return stream.Send(&agentpb.StreamLogsResponse{Line: line})
+	})
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return connect.NewError(connect.CodeNotFound, err)
+		}
+		return connect.NewError(connect.CodeInternal, err)
+	}
+	return nil
+}
+
+func (s *DaemonService) InstallImage(
+	ctx context.Context,
+	req *connect.Request[agentpb.InstallImageRequest],
+) (*connect.Response[agentpb.InstallImageResponse], error) {
+	if req.Msg.Url == "" || req.Msg.Sha256 == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("url and sha256 are required"))
+	}
+	slot, err := s.agent.InstallImageFrom(ctx, req.Msg.Url, update.UpdateInfo{
+		Version:   req.Msg.Version,
+		SHA256:    req.Msg.Sha256,
+		Signature: req.Msg.Signature,
+	})
+	if err != nil {
+		if errors.Is(err, ErrABUpdatesDisabled) {
+			return nil, connect.NewError(connect.CodeFailedPrecondition, err)
+		}
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to install image: %v", err))
+	}
+	return connect.NewResponse(&agentpb.InstallImageResponse{Slot: string(slot)}), nil
+}
+
+func (s *DaemonService) CommitUpdate(
+	ctx context.Context,
+	req *connect.Request[agentpb.CommitUpdateRequest],
+) (*connect.Response[agentpb.CommitUpdateResponse], error) {
+	slot, committed, err := s.agent.CommitUpdate()
+	if err != nil {
+		if errors.Is(err, ErrABUpdatesDisabled) {
+			return nil, connect.NewError(connect.CodeFailedPrecondition, err)
+		}
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to commit update: %v", err))
+	}
+	return connect.NewResponse(&agentpb.CommitUpdateResponse{
+		Slot:      string(slot),
+		Committed: committed,
+	}), nil
+}
+
+func (s *DaemonService) UpdateAgent(
+	ctx context.Context,
+	req *connect.Request[agentpb.UpdateAgentRequest],
+) (*connect.Response[agentpb.UpdateAgentResponse], error) {
+	if req.Msg.Url == "" || req.Msg.Sha256 == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("url and sha256 are required"))
+	}
+	err := s.agent.SelfUpdate(ctx, req.Msg.Url, update.UpdateInfo{
+		Version:   req.Msg.Version,
+		SHA256:    req.Msg.Sha256,
+		Signature: req.Msg.Signature,
+	})
+	if err != nil {
+		if errors.Is(err, ErrSelfUpdateDisabled) {
+			return nil, connect.NewError(connect.CodeFailedPrecondition, err)
+		}
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to update agent: %v", err))
+	}
+	return connect.NewResponse(&agentpb.UpdateAgentResponse{}), nil
+}
+
 func (s *DaemonService) GetDeviceInfo(
 	ctx context.Context,
 	req *connect.Request[emptypb.Empty],
@@ -113,17 +216,9 @@ func (s *DaemonService) ConfigureDevice(
 	ctx context.Context,
 	req *connect.Request[agentpb.ConfigureDeviceRequest],
 ) (*connect.Response[agentpb.ConfigureDeviceResponse], error) {
-	err := s.agent.Configure(Configuration{
-		DeviceName:  req.Msg.DeviceName,
-		APIEndpoint: req.Msg.ApiEndpoint,
-	})
+	resp, err := configureDevice(s.agent, req.Msg)
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInternal, err)
+		return nil, err
 	}
-
-	info := s.agent.GetDeviceInfo()
-	return connect.NewResponse(&agentpb.ConfigureDeviceResponse{
-		Success: true,
-		ApiKey:  info.APIKey,
-	}), nil
+	return connect.NewResponse(resp), nil
 }
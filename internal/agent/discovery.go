@@ -2,7 +2,9 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
 	"connectrpc.com/connect"
 	agentpb "fleetd.sh/gen/agent/v1"
@@ -47,28 +49,52 @@ func (s *DiscoveryService) ConfigureDevice(
 	ctx context.Context,
 	req *connect.Request[agentpb.ConfigureDeviceRequest],
 ) (*connect.Response[agentpb.ConfigureDeviceResponse], error) {
-	if req.Msg.ApiEndpoint == "" {
-		return nil, connect.NewError(
-			connect.CodeInvalidArgument,
-			fmt.Errorf("api_endpoint is required"),
-		)
+	resp, err := configureDevice(s.agent, req.Msg)
+	if err != nil {
+		return nil, err
 	}
+	return connect.NewResponse(resp), nil
+}
 
-	err := s.agent.Configure(Configuration{
-		DeviceName:  req.Msg.DeviceName,
-		APIEndpoint: req.Msg.ApiEndpoint,
-	})
-	if err != nil {
+// configureDevice applies a ConfigureDeviceRequest to the agent. Invalid
+// settings are rejected with InvalidArgument, and a device that is already
+// configured with FailedPrecondition unless the request sets force.
+func configureDevice(a *Agent, req *agentpb.ConfigureDeviceRequest) (*agentpb.ConfigureDeviceResponse, error) {
+	cfg := Configuration{
+		DeviceName:  strings.TrimSpace(req.DeviceName),
+		APIEndpoint: strings.TrimSpace(req.ApiEndpoint),
+		FleetID:     strings.TrimSpace(req.FleetId),
+		Force:       req.Force,
+	}
+	if req.Wifi != nil {
+		cfg.WiFi = &WiFiConfig{SSID: req.Wifi.Ssid, Password: req.Wifi.Password}
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	wasConfigured := a.GetDeviceInfo().Configured
+	if err := a.Configure(cfg); err != nil {
+		if errors.Is(err, ErrAlreadyConfigured) {
+			return nil, connect.NewError(connect.CodeFailedPrecondition,
+				fmt.Errorf("%w; set force to replace the configuration", err))
+		}
 		return nil, connect.NewError(connect.CodeInternal, err)
 	}
 
-	info := s.agent.GetDeviceInfo()
-	resp := &agentpb.ConfigureDeviceResponse{
+	message := "Device configured successfully"
+	if wasConfigured {
+		message = "Device reconfigured successfully"
+	}
+	if cfg.FleetID != "" {
+		message += fmt.Sprintf("; it will join fleet %s", cfg.FleetID)
+	}
+
+	info := a.GetDeviceInfo()
+	return &agentpb.ConfigureDeviceResponse{
 		Success:  true,
 		DeviceId: info.DeviceID,
 		ApiKey:   info.APIKey,
-		Message:  "Device configured successfully",
-	}
-
-	return connect.NewResponse(resp), nil
+		Message:  message,
+	}, nil
 }
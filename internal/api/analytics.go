@@ -55,20 +55,30 @@ func (s *AnalyticsService) GetDeviceMetrics(ctx context.Context, req *connect.Re
 		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to check device: %v", err))
 	}
 
+	start := req.Msg.TimeRange.GetStartTime().AsTime()
+	end := req.Msg.TimeRange.GetEndTime().AsTime()
+	if !end.After(start) {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("time_range end must be after start"))
+	}
+	if req.Msg.StepSeconds < 0 {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("step_seconds must not be negative"))
+	}
+
 	// Build query for known metric columns
-	query := `SELECT 
-		'cpu_usage' as metric_name, cpu_usage as value, timestamp FROM device_metric WHERE cpu_usage IS NOT NULL
+	query := `SELECT metric_name, value, timestamp FROM (
+		SELECT device_id, 'cpu_usage' as metric_name, cpu_usage as value, timestamp FROM device_metric WHERE cpu_usage IS NOT NULL
 		UNION ALL
-		SELECT 'memory_usage' as metric_name, memory_usage as value, timestamp FROM device_metric WHERE memory_usage IS NOT NULL
+		SELECT device_id, 'memory_usage' as metric_name, memory_usage as value, timestamp FROM device_metric WHERE memory_usage IS NOT NULL
 		UNION ALL
-		SELECT 'disk_usage' as metric_name, disk_usage as value, timestamp FROM device_metric WHERE disk_usage IS NOT NULL
+		SELECT device_id, 'disk_usage' as metric_name, disk_usage as value, timestamp FROM device_metric WHERE disk_usage IS NOT NULL
 		UNION ALL
-		SELECT 'network_rx_bytes' as metric_name, CAST(network_rx_bytes as REAL) as value, timestamp FROM device_metric WHERE network_rx_bytes IS NOT NULL
+		SELECT device_id, 'network_rx_bytes' as metric_name, CAST(network_rx_bytes as REAL) as value, timestamp FROM device_metric WHERE network_rx_bytes IS NOT NULL
 		UNION ALL
-		SELECT 'network_tx_bytes' as metric_name, CAST(network_tx_bytes as REAL) as value, timestamp FROM device_metric WHERE network_tx_bytes IS NOT NULL`
+		SELECT device_id, 'network_tx_bytes' as metric_name, CAST(network_tx_bytes as REAL) as value, timestamp FROM device_metric WHERE network_tx_bytes IS NOT NULL
+	)`
 
-	query += ` AND device_id = ? AND timestamp BETWEEN ? AND ?`
-	args := []interface{}{req.Msg.DeviceId, req.Msg.TimeRange.StartTime.AsTime(), req.Msg.TimeRange.EndTime.AsTime()}
+	query += ` WHERE device_id = ? AND julianday(timestamp) BETWEEN julianday(?) AND julianday(?)`
+	args := []interface{}{req.Msg.DeviceId, start.UTC().Format(time.RFC3339Nano), end.UTC().Format(time.RFC3339Nano)}
 
 	if len(req.Msg.MetricNames) > 0 {
 		placeholders := make([]string, len(req.Msg.MetricNames))
@@ -79,7 +89,7 @@ func (s *AnalyticsService) GetDeviceMetrics(ctx context.Context, req *connect.Re
 		query += fmt.Sprintf(" AND metric_name IN (%s)", strings.Join(placeholders, ","))
 	}
 
-	query += " ORDER BY timestamp ASC"
+	query += " ORDER BY metric_name, timestamp ASC"
 
 	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -87,8 +97,11 @@ func (s *AnalyticsService) GetDeviceMetrics(ctx context.Context, req *connect.Re
 	}
 	defer rows.Close()
 
-	// Group metrics by name
-	metricsByName := make(map[string]*pb.MetricSeries)
+	// Group metrics by name, keeping the order of the query
+	var (
+		names  []string
+		points = make(map[string][]metricPoint)
+	)
 	for rows.Next() {
 		var (
 			name         string
@@ -100,40 +113,135 @@ func (s *AnalyticsService) GetDeviceMetrics(ctx context.Context, req *connect.Re
 			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to scan metric: %v", err))
 		}
 
-		series, ok := metricsByName[name]
-		if !ok {
-			series = &pb.MetricSeries{
-				Name:   name,
-				Values: make([]*pb.MetricValue, 0),
-			}
-			metricsByName[name] = series
-		}
-
-		timestamp, err := time.Parse(time.RFC3339, timestampStr)
+		timestamp, err := parseSQLiteTime(timestampStr)
 		if err != nil {
 			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to parse timestamp: %v", err))
 		}
 
-		metricValue := &pb.MetricValue{
-			Timestamp: timestamppb.New(timestamp),
-			Value:     &pb.MetricValue_Numeric{Numeric: value},
+		if _, ok := points[name]; !ok {
+			names = append(names, name)
 		}
-		series.Values = append(series.Values, metricValue)
+		points[name] = append(points[name], metricPoint{timestamp: timestamp, value: value})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to iterate metrics: %v", err))
+	}
+
+	maxPoints := maxMetricPoints
+	if n := int(req.Msg.MaxPoints); n > 0 && n < maxPoints {
+		maxPoints = n
+	}
+	step := time.Duration(req.Msg.StepSeconds) * time.Second
+	if step == 0 {
+		// Raw points are only downsampled when a series exceeds the cap
+		for _, name := range names {
+			if len(points[name]) > maxPoints {
+				step = minStep(end.Sub(start), maxPoints)
+				break
+			}
+		}
+	} else if step < minStep(end.Sub(start), maxPoints) {
+		step = minStep(end.Sub(start), maxPoints)
 	}
 
-	// Convert map to slice
-	metrics := make([]*pb.MetricSeries, 0, len(metricsByName))
-	for _, series := range metricsByName {
+	metrics := make([]*pb.MetricSeries, 0, len(names))
+	for _, name := range names {
+		series := &pb.MetricSeries{Name: name}
+		if step > 0 {
+			series.Values = aggregateMetrics(points[name], start, end, step, req.Msg.Aggregation)
+		} else {
+			series.Values = make([]*pb.MetricValue, 0, len(points[name]))
+			for _, p := range points[name] {
+				series.Values = append(series.Values, &pb.MetricValue{
+					Timestamp: timestamppb.New(p.timestamp),
+					Value:     &pb.MetricValue_Numeric{Numeric: p.value},
+				})
+			}
+		}
 		metrics = append(metrics, series)
 	}
 
 	return &connect.Response[pb.GetDeviceMetricsResponse]{
 		Msg: &pb.GetDeviceMetricsResponse{
-			Metrics: metrics,
+			Metrics:     metrics,
+			StepSeconds: int64(step / time.Second),
 		},
 	}, nil
 }
 
+// maxMetricPoints caps the number of points returned per metric series
+const maxMetricPoints = 1000
+
+type metricPoint struct {
+	timestamp time.Time
+	value     float64
+}
+
+// minStep returns the smallest whole-second bucket width that splits span
+// into at most maxPoints buckets
+func minStep(span time.Duration, maxPoints int) time.Duration {
+	step := (span + time.Duration(maxPoints) - 1) / time.Duration(maxPoints)
+	return ((step + time.Second - 1) / time.Second) * time.Second
+}
+
+// aggregateMetrics groups points sorted by time into buckets of width step
+// starting at start and aggregates each non-empty bucket. Buckets are
+// timestamped with their start time.
+func aggregateMetrics(points []metricPoint, start, end time.Time, step time.Duration, aggregation pb.MetricAggregation) []*pb.MetricValue {
+	buckets := int((end.Sub(start) + step - 1) / step)
+
+	var (
+		values  []*pb.MetricValue
+		current = -1
+		count   int
+		result  float64
+	)
+	flush := func() {
+		if current < 0 {
+			return
+		}
+		if aggregation == pb.MetricAggregation_METRIC_AGGREGATION_AVG ||
+			aggregation == pb.MetricAggregation_METRIC_AGGREGATION_UNSPECIFIED {
+			result /= float64(count)
+		}
+		values = append(values, &pb.MetricValue{
+			Timestamp: timestamppb.New(start.Add(time.Duration(current) * step)),
+			Value:     &pb.MetricValue_Numeric{Numeric: result},
+		})
+	}
+
+	for _, p := range points {
+		// Points exactly at the end of the range belong to the last bucket
+		bucket := int(p.timestamp.Sub(start) / step)
+		if bucket >= buckets {
+			bucket = buckets - 1
+		}
+		if bucket != current {
+			flush()
+			current, count, result = bucket, 0, 0
+		}
+
+		count++
+		switch aggregation {
+		case pb.MetricAggregation_METRIC_AGGREGATION_MIN:
+			if count == 1 || p.value < result {
+				result = p.value
+			}
+		case pb.MetricAggregation_METRIC_AGGREGATION_MAX:
+			if count == 1 || p.value > result {
+				result = p.value
+			}
+		case pb.MetricAggregation_METRIC_AGGREGATION_COUNT:
+			result = float64(count)
+		default:
+			result += p.value
+		}
+	}
+	flush()
+
+	return values
+}
+
 func (s *AnalyticsService) GetUpdateAnalytics(ctx context.Context, req *connect.Request[pb.GetUpdateAnalyticsRequest]) (*connect.Response[pb.GetUpdateAnalyticsResponse], error) {
 	query := `SELECT c.id, c.name, c.total_devices,
 				c.updated_devices as successful,
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const deviceColumns = `id, name, type, version, api_key, metadata, last_seen, created_at, updated_at, status, hardware_id, fleet_id`
+
+// DeviceArchiver moves devices that have been idle for too long out of the
+// device table into device_archive. Archived devices are restored
+// automatically when they heartbeat or register again.
+type DeviceArchiver struct {
+	db        *sql.DB
+	idleAfter time.Duration
+	clock     func() time.Time
+}
+
+// NewDeviceArchiver creates an archiver for devices idle longer than idleAfter
+func NewDeviceArchiver(db *sql.DB, idleAfter time.Duration) *DeviceArchiver {
+	return &DeviceArchiver{db: db, idleAfter: idleAfter, clock: time.Now}
+}
+
+// WithClock sets the clock used to judge which devices are idle
+func (a *DeviceArchiver) WithClock(clock func() time.Time) *DeviceArchiver {
+	a.clock = clock
+	return a
+}
+
+// Run archives idle devices every interval until ctx is done
+func (a *DeviceArchiver) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := a.ArchiveIdle(ctx)
+			if err != nil {
+				slog.Error("Failed to archive idle devices", "error", err)
+				continue
+			}
+			if n > 0 {
+				slog.Info("Archived idle devices", "count", n)
+			}
+		}
+	}
+}
+
+// ArchiveIdle moves every device not seen within the idle period to the
+// archive and returns how many were moved. Devices that never sent a
+// heartbeat are judged by their creation time.
+func (a *DeviceArchiver) ArchiveIdle(ctx context.Context) (int, error) {
+	// Rows such as device_update keep referencing archived devices so their
+	// history survives a restore, so foreign key checks are disabled while
+	// the devices are moved. That is done on a connection of its own, which
+	// is discarded afterwards rather than returned to the pool.
+	conn, err := a.db.Conn(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer func() {
+		conn.Raw(func(any) error { return driver.ErrBadConn })
+		conn.Close()
+	}()
+
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys=OFF"); err != nil {
+		return 0, fmt.Errorf("failed to disable foreign keys: %w", err)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Both statements use the same cutoff, so a device that becomes idle
+	// between them is neither lost nor archived twice. last_seen and
+	// created_at are written by CURRENT_TIMESTAMP, so the cutoff uses the
+	// same format and compares as a string, which keeps idx_device_last_seen
+	// usable.
+	cutoff := a.clock().UTC().Add(-a.idleAfter).Format(time.DateTime)
+	const idle = `(last_seen < ? OR (last_seen IS NULL AND created_at < ?))`
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO device_archive (`+deviceColumns+`)
+		 SELECT `+deviceColumns+` FROM device WHERE `+idle,
+		cutoff, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to copy idle devices: %w", err)
+	}
+	archived, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	// Only devices that made it into the archive are removed
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM device WHERE id IN (SELECT id FROM device_archive WHERE `+idle+`)`,
+		cutoff, cutoff); err != nil {
+		return 0, fmt.Errorf("failed to remove idle devices: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return int(archived), nil
+}
+
+// restoreArchivedDevice moves a device back from the archive. It reports
+// whether the device was found there.
+func restoreArchivedDevice(ctx context.Context, db *sql.DB, deviceID string) (bool, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO device (`+deviceColumns+`)
+		 SELECT `+deviceColumns+` FROM device_archive WHERE id = ?`,
+		deviceID)
+	if err != nil {
+		return false, fmt.Errorf("failed to restore device: %w", err)
+	}
+	restored, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if restored == 0 {
+		return false, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM device_archive WHERE id = ?", deviceID); err != nil {
+		return false, fmt.Errorf("failed to remove archived device: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
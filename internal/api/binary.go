@@ -11,6 +11,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"connectrpc.com/connect"
@@ -21,10 +22,24 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// BinaryService stores binaries content-addressed by their SHA256, so
+// identical uploads share a single blob on disk. A blob is removed once the
+// last binary referencing it is deleted.
 type BinaryService struct {
 	rpc.UnimplementedBinaryServiceHandler
 	db          *sql.DB
 	storagePath string
+
+	// blobMu serializes adding and dropping blob references
+	blobMu sync.Mutex
+}
+
+// BinaryStorageStats describes how much disk deduplication saves
+type BinaryStorageStats struct {
+	Binaries     int   // Binaries referencing stored blobs
+	Blobs        int   // Distinct blobs on disk
+	LogicalBytes int64 // Total size of all binaries
+	StoredBytes  int64 // Total size of all blobs
 }
 
 func NewBinaryService(db *sql.DB, storagePath string) (*BinaryService, error) {
@@ -35,6 +50,11 @@ func NewBinaryService(db *sql.DB, storagePath string) (*BinaryService, error) {
 	return &BinaryService{db: db, storagePath: storagePath}, nil
 }
 
+// blobPath returns where the blob with the given SHA256 is stored
+func (s *BinaryService) blobPath(sha256sum string) string {
+	return filepath.Join(s.storagePath, "blobs", sha256sum[:2], sha256sum)
+}
+
 func (s *BinaryService) UploadBinary(ctx context.Context, stream *connect.ClientStream[pb.UploadBinaryRequest]) (*connect.Response[pb.UploadBinaryResponse], error) {
 	var (
 		metadata   *pb.BinaryMetadata
@@ -42,12 +62,13 @@ func (s *BinaryService) UploadBinary(ctx context.Context, stream *connect.Client
 		hasher     = sha256.New()
 		size       int64
 		binaryID   = uuid.New().String()
-		binaryPath string
 	)
 
 	defer func() {
 		if binaryFile != nil {
 			binaryFile.Close()
+			// The upload is moved into the blob store on success
+			os.Remove(binaryFile.Name())
 		}
 	}()
 
@@ -68,9 +89,8 @@ func (s *BinaryService) UploadBinary(ctx context.Context, stream *connect.Client
 				return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("metadata already received"))
 			}
 			metadata = data.Metadata
-			binaryPath = filepath.Join(s.storagePath, binaryID)
 			var err error
-			binaryFile, err = os.Create(binaryPath)
+			binaryFile, err = os.CreateTemp(s.storagePath, ".upload-*")
 			if err != nil {
 				return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to create binary file: %v", err))
 			}
@@ -101,7 +121,28 @@ func (s *BinaryService) UploadBinary(ctx context.Context, stream *connect.Client
 		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to marshal metadata: %v", err))
 	}
 
+	if err := binaryFile.Close(); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to write binary file: %v", err))
+	}
+
 	sha256sum := hex.EncodeToString(hasher.Sum(nil))
+	binaryPath := s.blobPath(sha256sum)
+
+	s.blobMu.Lock()
+	defer s.blobMu.Unlock()
+
+	// Identical content is stored once; the upload is dropped if the blob exists
+	if _, err := os.Stat(binaryPath); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(binaryPath), 0755); err != nil {
+			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to create blob directory: %v", err))
+		}
+		if err := os.Rename(binaryFile.Name(), binaryPath); err != nil {
+			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to store blob: %v", err))
+		}
+	} else if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to check blob: %v", err))
+	}
+
 	_, err = s.db.ExecContext(ctx,
 		`INSERT INTO binary (id, name, version, platform, architecture, size, sha256, metadata, storage_path)
 		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
@@ -270,3 +311,66 @@ func (s *BinaryService) ListBinaries(ctx context.Context, req *connect.Request[p
 		NextPageToken: nextPageToken,
 	}), nil
 }
+
+func (s *BinaryService) DeleteBinary(ctx context.Context, req *connect.Request[pb.DeleteBinaryRequest]) (*connect.Response[pb.DeleteBinaryResponse], error) {
+	s.blobMu.Lock()
+	defer s.blobMu.Unlock()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	var storagePath string
+	err = tx.QueryRowContext(ctx, "SELECT storage_path FROM binary WHERE id = ?", req.Msg.Id).Scan(&storagePath)
+	if err == sql.ErrNoRows {
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("binary not found"))
+	}
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to get binary: %v", err))
+	}
+
+	var campaigns int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM update_campaign WHERE binary_id = ?", req.Msg.Id).Scan(&campaigns); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to check campaigns: %v", err))
+	}
+	if campaigns > 0 {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("binary is used by an update campaign"))
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM binary WHERE id = ?", req.Msg.Id); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to delete binary: %v", err))
+	}
+
+	// The blob is only removed when no other binary references it
+	var references int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM binary WHERE storage_path = ?", storagePath).Scan(&references); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to count blob references: %v", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to commit transaction: %v", err))
+	}
+
+	if references == 0 {
+		if err := os.Remove(storagePath); err != nil && !os.IsNotExist(err) {
+			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to remove blob: %v", err))
+		}
+	}
+
+	return connect.NewResponse(&pb.DeleteBinaryResponse{}), nil
+}
+
+// StorageStats reports how many blobs back the stored binaries
+func (s *BinaryService) StorageStats(ctx context.Context) (BinaryStorageStats, error) {
+	var stats BinaryStorageStats
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*), COUNT(DISTINCT storage_path), COALESCE(SUM(size), 0),
+		 COALESCE((SELECT SUM(size) FROM (SELECT MAX(size) AS size FROM binary GROUP BY storage_path)), 0)
+		 FROM binary`).Scan(&stats.Binaries, &stats.Blobs, &stats.LogicalBytes, &stats.StoredBytes)
+	if err != nil {
+		return BinaryStorageStats{}, fmt.Errorf("failed to get storage stats: %w", err)
+	}
+	return stats, nil
+}
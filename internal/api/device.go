@@ -8,21 +8,30 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	pb "fleetd.sh/gen/fleetd/v1"
 	rpc "fleetd.sh/gen/fleetd/v1/fleetpbconnect"
 
 	"connectrpc.com/connect"
 	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type DeviceService struct {
 	rpc.UnimplementedDeviceServiceHandler
-	db *sql.DB
+	db    *sql.DB
+	clock func() time.Time
 }
 
 func NewDeviceService(db *sql.DB) *DeviceService {
-	return &DeviceService{db: db}
+	return &DeviceService{db: db, clock: time.Now}
+}
+
+// WithClock sets the clock used to judge maintenance windows
+func (s *DeviceService) WithClock(clock func() time.Time) *DeviceService {
+	s.clock = clock
+	return s
 }
 
 func generateAPIKey() (string, error) {
@@ -34,21 +43,37 @@ func generateAPIKey() (string, error) {
 }
 
 func (s *DeviceService) Register(ctx context.Context, req *connect.Request[pb.RegisterRequest]) (*connect.Response[pb.RegisterResponse], error) {
-	deviceID := uuid.New().String()
-	apiKey, err := generateAPIKey()
+	metadata, err := json.Marshal(req.Msg.Capabilities)
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to generate API key: %v", err))
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to marshal capabilities: %v", err))
 	}
 
-	metadata, err := json.Marshal(req.Msg.Capabilities)
+	if req.Msg.HardwareId != "" {
+		resp, err := s.reregister(ctx, req.Msg, string(metadata))
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, err)
+		}
+		if resp != nil {
+			return connect.NewResponse(resp), nil
+		}
+	}
+
+	deviceID := uuid.New().String()
+	apiKey, err := generateAPIKey()
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to marshal capabilities: %v", err))
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to generate API key: %v", err))
 	}
 
 	_, err = s.db.ExecContext(ctx,
-		`INSERT INTO device (id, name, type, version, api_key, metadata)
-		 VALUES (?, ?, ?, ?, ?, ?)`,
-		deviceID, req.Msg.Name, req.Msg.Type, req.Msg.Version, apiKey, string(metadata))
+		`INSERT INTO device (id, name, type, version, api_key, metadata, hardware_id)
+		 VALUES (?, ?, ?, ?, ?, ?, NULLIF(?, ''))`,
+		deviceID, req.Msg.Name, req.Msg.Type, req.Msg.Version, apiKey, string(metadata), req.Msg.HardwareId)
+	if err != nil && req.Msg.HardwareId != "" {
+		// A concurrent registration may have claimed the hardware ID first
+		if resp, rerr := s.reregister(ctx, req.Msg, string(metadata)); rerr == nil && resp != nil {
+			return connect.NewResponse(resp), nil
+		}
+	}
 	if err != nil {
 		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to insert device: %v", err))
 	}
@@ -59,27 +84,151 @@ func (s *DeviceService) Register(ctx context.Context, req *connect.Request[pb.Re
 	}), nil
 }
 
+// reregister refreshes the device already registered with the request's
+// hardware ID, restoring it from the archive if needed. It returns nil if no
+// device has that hardware ID.
+func (s *DeviceService) reregister(ctx context.Context, req *pb.RegisterRequest, metadata string) (*pb.RegisterResponse, error) {
+	var archivedID string
+	err := s.db.QueryRowContext(ctx,
+		"SELECT id FROM device_archive WHERE hardware_id = ?", req.HardwareId).Scan(&archivedID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to check archived devices: %v", err)
+	}
+	if archivedID != "" {
+		if _, err := restoreArchivedDevice(ctx, s.db, archivedID); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	resp := &pb.RegisterResponse{Reregistered: true}
+	err = tx.QueryRowContext(ctx,
+		"SELECT id, api_key FROM device WHERE hardware_id = ?", req.HardwareId).Scan(&resp.DeviceId, &resp.ApiKey)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find device by hardware_id: %v", err)
+	}
+
+	if req.RotateApiKey {
+		if resp.ApiKey, err = generateAPIKey(); err != nil {
+			return nil, fmt.Errorf("failed to generate API key: %v", err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE device SET name = ?, type = ?, version = ?, metadata = ?, api_key = ?, updated_at = CURRENT_TIMESTAMP
+		 WHERE id = ?`,
+		req.Name, req.Type, req.Version, metadata, resp.ApiKey, resp.DeviceId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update device: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return resp, nil
+}
+
 func (s *DeviceService) Heartbeat(ctx context.Context, req *connect.Request[pb.HeartbeatRequest]) (*connect.Response[pb.HeartbeatResponse], error) {
-	// Update last_seen timestamp
+	rows, err := s.touchLastSeen(ctx, req.Msg.DeviceId)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	if rows == 0 {
+		// The device may have been archived while it was idle
+		restored, err := restoreArchivedDevice(ctx, s.db, req.Msg.DeviceId)
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, err)
+		}
+		if !restored {
+			return nil, connect.NewError(connect.CodeNotFound, errors.New("device not found"))
+		}
+		if _, err := s.touchLastSeen(ctx, req.Msg.DeviceId); err != nil {
+			return nil, connect.NewError(connect.CodeInternal, err)
+		}
+	}
+
+	resp, err := s.pendingUpdate(ctx, req.Msg.DeviceId)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	return connect.NewResponse(resp), nil
+}
+
+// pendingUpdate reports the oldest update pending for a device whose
+// campaign's maintenance window is open. If all pending updates are held,
+// it reports when the first window opens.
+func (s *DeviceService) pendingUpdate(ctx context.Context, deviceID string) (*pb.HeartbeatResponse, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT c.id, c.maintenance_window
+		 FROM device_update du
+		 JOIN update_campaign c ON c.id = du.campaign_id
+		 WHERE du.device_id = ? AND du.status = ? AND c.status IN (?, ?)
+		 ORDER BY c.created_at, c.id`,
+		deviceID, pb.DeviceUpdateStatus_DEVICE_UPDATE_STATUS_PENDING,
+		pb.UpdateCampaignStatus_UPDATE_CAMPAIGN_STATUS_CREATED, pb.UpdateCampaignStatus_UPDATE_CAMPAIGN_STATUS_IN_PROGRESS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending updates: %v", err)
+	}
+	defer rows.Close()
+
+	now := s.clock()
+	resp := &pb.HeartbeatResponse{}
+	var opensAt time.Time
+	for rows.Next() {
+		var campaignID, stored string
+		if err := rows.Scan(&campaignID, &stored); err != nil {
+			return nil, fmt.Errorf("failed to scan pending update: %v", err)
+		}
+		w, err := unmarshalMaintenanceWindow(stored)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal maintenance window: %v", err)
+		}
+		window, err := parseMaintenanceWindow(w)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maintenance window of campaign %s: %v", campaignID, err)
+		}
+
+		if window == nil || window.open(now) {
+			resp.HasUpdate = true
+			resp.UpdateId = campaignID
+			return resp, nil
+		}
+		if next := window.next(now); opensAt.IsZero() || next.Before(opensAt) {
+			opensAt = next
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query pending updates: %v", err)
+	}
+
+	if !opensAt.IsZero() {
+		resp.UpdateWindowOpensAt = timestamppb.New(opensAt)
+	}
+	return resp, nil
+}
+
+// touchLastSeen updates the device's last_seen timestamp and returns the number of rows affected
+func (s *DeviceService) touchLastSeen(ctx context.Context, deviceID string) (int64, error) {
 	result, err := s.db.ExecContext(ctx,
 		`UPDATE device SET last_seen = CURRENT_TIMESTAMP WHERE id = ?`,
-		req.Msg.DeviceId)
+		deviceID)
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to update last_seen: %v", err))
+		return 0, fmt.Errorf("failed to update last_seen: %v", err)
 	}
 
 	rows, err := result.RowsAffected()
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to get rows affected: %v", err))
+		return 0, fmt.Errorf("failed to get rows affected: %v", err)
 	}
-	if rows == 0 {
-		return nil, connect.NewError(connect.CodeNotFound, errors.New("device not found"))
-	}
-
-	// TODO: Check for pending updates when implemented
-	return connect.NewResponse(&pb.HeartbeatResponse{
-		HasUpdate: false,
-	}), nil
+	return rows, nil
 }
 
 func (s *DeviceService) ReportStatus(ctx context.Context, req *connect.Request[pb.ReportStatusRequest]) (*connect.Response[pb.ReportStatusResponse], error) {
@@ -109,21 +258,67 @@ func (s *DeviceService) ReportStatus(ctx context.Context, req *connect.Request[p
 }
 
 func (s *DeviceService) GetDevice(ctx context.Context, req *connect.Request[pb.GetDeviceRequest]) (*connect.Response[pb.GetDeviceResponse], error) {
-	row := s.db.QueryRowContext(ctx, "SELECT id, name, type, version, metadata, last_seen FROM device WHERE id = ?", req.Msg.DeviceId)
+	row := s.db.QueryRowContext(ctx, "SELECT id, name, type, version, metadata, last_seen, status, fleet_id FROM device WHERE id = ?", req.Msg.DeviceId)
 	if err := row.Err(); err != nil {
 		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to get device: %v", err))
 	}
 
-	var device pb.Device
-	if err := row.Scan(&device.Id, &device.Name, &device.Type, &device.Version, &device.Metadata, &device.LastSeen); err != nil {
+	device, err := scanDevice(row)
+	if err == sql.ErrNoRows {
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("device not found"))
+	}
+	if err != nil {
 		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to scan device: %v", err))
 	}
+	if err := attachTags(ctx, s.db, []*pb.Device{device}); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to get device tags: %v", err))
+	}
 
-	return connect.NewResponse(&pb.GetDeviceResponse{Device: &device}), nil
+	return connect.NewResponse(&pb.GetDeviceResponse{Device: device}), nil
 }
 
 func (s *DeviceService) ListDevices(ctx context.Context, req *connect.Request[pb.ListDevicesRequest]) (*connect.Response[pb.ListDevicesResponse], error) {
-	rows, err := s.db.QueryContext(ctx, "SELECT id, name, type, version, metadata, last_seen FROM device")
+	query := "SELECT id, name, type, version, metadata, last_seen, status, fleet_id FROM device WHERE 1=1"
+	args := []interface{}{}
+
+	if req.Msg.Type != "" {
+		query += " AND type = ?"
+		args = append(args, req.Msg.Type)
+	}
+	if req.Msg.Version != "" {
+		query += " AND version = ?"
+		args = append(args, req.Msg.Version)
+	}
+	if req.Msg.Status != "" {
+		query += " AND status = ?"
+		args = append(args, req.Msg.Status)
+	}
+	if req.Msg.FleetId != "" {
+		query += " AND fleet_id = ?"
+		args = append(args, req.Msg.FleetId)
+	}
+	if req.Msg.Selector != "" {
+		sel, err := parseSelector(req.Msg.Selector)
+		if err != nil {
+			return nil, err
+		}
+		clause, selectorArgs := selectorClause(sel)
+		query += " AND " + clause
+		args = append(args, selectorArgs...)
+	}
+
+	// Pages are ordered by ID, and the token is the last ID of the previous page
+	if req.Msg.PageToken != "" {
+		query += " AND id > ?"
+		args = append(args, req.Msg.PageToken)
+	}
+	query += " ORDER BY id"
+	if req.Msg.PageSize > 0 {
+		query += " LIMIT ?"
+		args = append(args, req.Msg.PageSize+1) // Get one extra to determine if there are more pages
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to list devices: %v", err))
 	}
@@ -131,14 +326,71 @@ func (s *DeviceService) ListDevices(ctx context.Context, req *connect.Request[pb
 
 	var devices []*pb.Device
 	for rows.Next() {
-		var device pb.Device
-		if err := rows.Scan(&device.Id, &device.Name, &device.Type, &device.Version, &device.Metadata, &device.LastSeen); err != nil {
+		device, err := scanDevice(rows)
+		if err != nil {
 			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to scan device: %v", err))
 		}
-		devices = append(devices, &device)
+		devices = append(devices, device)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to list devices: %v", err))
+	}
+
+	var nextPageToken string
+	if req.Msg.PageSize > 0 && len(devices) > int(req.Msg.PageSize) {
+		devices = devices[:req.Msg.PageSize]
+		nextPageToken = devices[len(devices)-1].Id
+	}
+	if err := attachTags(ctx, s.db, devices); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to get device tags: %v", err))
+	}
+
+	return connect.NewResponse(&pb.ListDevicesResponse{
+		Devices:       devices,
+		NextPageToken: nextPageToken,
+	}), nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanDevice scans a row of id, name, type, version, metadata, last_seen, status, fleet_id
+func scanDevice(row rowScanner) (*pb.Device, error) {
+	var (
+		device   pb.Device
+		metadata string
+		lastSeen sql.NullString
+	)
+	if err := row.Scan(&device.Id, &device.Name, &device.Type, &device.Version, &metadata, &lastSeen, &device.Status, &device.FleetId); err != nil {
+		return nil, err
+	}
+	if metadata != "" {
+		// Metadata is free-form JSON; only string values are exposed
+		var raw map[string]any
+		if err := json.Unmarshal([]byte(metadata), &raw); err == nil {
+			device.Metadata = make(map[string]string, len(raw))
+			for k, v := range raw {
+				if str, ok := v.(string); ok {
+					device.Metadata[k] = str
+				}
+			}
+		}
+	}
+	if lastSeen.Valid {
+		if t, err := parseSQLiteTime(lastSeen.String); err == nil {
+			device.LastSeen = timestamppb.New(t)
+		}
 	}
+	return &device, nil
+}
 
-	return connect.NewResponse(&pb.ListDevicesResponse{Devices: devices}), nil
+// parseSQLiteTime parses timestamps written by CURRENT_TIMESTAMP or as RFC3339
+func parseSQLiteTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateTime, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
 }
 
 func (s *DeviceService) DeleteDevice(ctx context.Context, req *connect.Request[pb.DeleteDeviceRequest]) (*connect.Response[pb.DeleteDeviceResponse], error) {
@@ -157,3 +409,179 @@ func (s *DeviceService) DeleteDevice(ctx context.Context, req *connect.Request[p
 
 	return connect.NewResponse(&pb.DeleteDeviceResponse{}), nil
 }
+
+func (s *DeviceService) BatchUpdateDeviceStatus(ctx context.Context, req *connect.Request[pb.BatchUpdateDeviceStatusRequest]) (*connect.Response[pb.BatchUpdateDeviceStatusResponse], error) {
+	if len(req.Msg.DeviceIds) == 0 && req.Msg.Selector == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("device_ids or selector is required"))
+	}
+	if req.Msg.Status == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("status is required"))
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	// Devices matching the selector are resolved inside the transaction and
+	// updated after the listed ones
+	deviceIDs := req.Msg.DeviceIds
+	if req.Msg.Selector != "" {
+		sel, err := parseSelector(req.Msg.Selector)
+		if err != nil {
+			return nil, err
+		}
+		clause, args := selectorClause(sel)
+		rows, err := tx.QueryContext(ctx, "SELECT id FROM device WHERE "+clause+" ORDER BY id", args...)
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to query devices: %v", err))
+		}
+		deviceIDs = append([]string(nil), deviceIDs...)
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to scan device ID: %v", err))
+			}
+			deviceIDs = append(deviceIDs, id)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to query devices: %v", err))
+		}
+	}
+
+	var (
+		results []*pb.DeviceStatusResult
+		updated int32
+		seen    = make(map[string]bool)
+	)
+	for _, deviceID := range deviceIDs {
+		if seen[deviceID] {
+			continue
+		}
+		seen[deviceID] = true
+
+		result := &pb.DeviceStatusResult{DeviceId: deviceID}
+		results = append(results, result)
+
+		err := tx.QueryRowContext(ctx, "SELECT status FROM device WHERE id = ?", deviceID).Scan(&result.PreviousStatus)
+		if err == sql.ErrNoRows {
+			result.Error = "device not found"
+			continue
+		}
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to get device status: %v", err))
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`UPDATE device SET status = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+			req.Msg.Status, deviceID)
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to update device status: %v", err))
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO device_status_event (device_id, previous_status, status, reason)
+			 VALUES (?, ?, ?, ?)`,
+			deviceID, result.PreviousStatus, req.Msg.Status, req.Msg.Reason)
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to record status event: %v", err))
+		}
+
+		result.Success = true
+		updated++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to commit transaction: %v", err))
+	}
+
+	return connect.NewResponse(&pb.BatchUpdateDeviceStatusResponse{
+		Results:      results,
+		UpdatedCount: updated,
+	}), nil
+}
+
+func (s *DeviceService) BatchRegisterDevices(ctx context.Context, req *connect.Request[pb.BatchRegisterDevicesRequest]) (*connect.Response[pb.BatchRegisterDevicesResponse], error) {
+	if len(req.Msg.Devices) == 0 {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("devices is required"))
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	var (
+		results    = make([]*pb.BatchRegisterResult, 0, len(req.Msg.Devices))
+		registered int32
+		seen       = make(map[string]bool)
+	)
+	for i, spec := range req.Msg.Devices {
+		result := &pb.BatchRegisterResult{
+			Index:      int32(i),
+			HardwareId: spec.HardwareId,
+		}
+		results = append(results, result)
+
+		if spec.Name == "" || spec.Type == "" {
+			result.ErrorCode = connect.CodeInvalidArgument.String()
+			result.Error = "name and type are required"
+			continue
+		}
+
+		if spec.HardwareId != "" {
+			if seen[spec.HardwareId] {
+				result.ErrorCode = connect.CodeAlreadyExists.String()
+				result.Error = "duplicate hardware_id in request"
+				continue
+			}
+			seen[spec.HardwareId] = true
+
+			var existingID string
+			err := tx.QueryRowContext(ctx,
+				`SELECT id FROM device WHERE hardware_id = ?
+				 UNION ALL SELECT id FROM device_archive WHERE hardware_id = ?`,
+				spec.HardwareId, spec.HardwareId).Scan(&existingID)
+			if err == nil {
+				result.ErrorCode = connect.CodeAlreadyExists.String()
+				result.Error = fmt.Sprintf("hardware_id already registered to device %s", existingID)
+				continue
+			}
+			if err != sql.ErrNoRows {
+				return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to check hardware_id: %v", err))
+			}
+		}
+
+		apiKey, err := generateAPIKey()
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to generate API key: %v", err))
+		}
+		deviceID := uuid.New().String()
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO device (id, name, type, version, api_key, hardware_id)
+			 VALUES (?, ?, ?, ?, ?, NULLIF(?, ''))`,
+			deviceID, spec.Name, spec.Type, spec.Version, apiKey, spec.HardwareId)
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to insert device: %v", err))
+		}
+
+		result.DeviceId = deviceID
+		result.ApiKey = apiKey
+		registered++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to commit transaction: %v", err))
+	}
+
+	return connect.NewResponse(&pb.BatchRegisterDevicesResponse{
+		Results:         results,
+		RegisteredCount: registered,
+	}), nil
+}
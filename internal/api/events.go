@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"fleetd.sh/internal/webhook"
+
+	"github.com/google/uuid"
+)
+
+// EventPublisher delivers device and deployment events, usually to webhooks
+type EventPublisher interface {
+	Publish(ctx context.Context, event webhook.Event) error
+}
+
+// DeviceOfflineNotifier returns a HeartbeatTracker status change handler that
+// publishes a device.offline event whenever a device goes offline
+func DeviceOfflineNotifier(publisher EventPublisher) func(context.Context, StatusChange) {
+	return func(ctx context.Context, change StatusChange) {
+		if change.Status != DeviceStatusOffline {
+			return
+		}
+		publishEvent(ctx, publisher, webhook.EventDeviceOffline, change.Time, map[string]any{
+			"device_id":       change.DeviceID,
+			"previous_status": change.PreviousStatus,
+			"status":          change.Status,
+		})
+	}
+}
+
+// publishEvent publishes an event in the background, since webhook delivery
+// may retry for a while and should not hold up the caller
+func publishEvent(ctx context.Context, publisher EventPublisher, eventType webhook.EventType, at time.Time, data map[string]any) {
+	if publisher == nil {
+		return
+	}
+
+	event := webhook.Event{
+		ID:        uuid.New().String(),
+		Type:      eventType,
+		Timestamp: at,
+		Data:      data,
+	}
+	go func() {
+		if err := publisher.Publish(context.WithoutCancel(ctx), event); err != nil {
+			slog.Error("Failed to publish event", "type", eventType, "id", event.ID, "error", err)
+		}
+	}()
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	pb "fleetd.sh/gen/fleetd/v1"
+
+	"connectrpc.com/connect"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// DefaultFleetID is the fleet devices belong to until they are added to
+// another one
+const DefaultFleetID = "default"
+
+func (s *DeviceService) CreateFleet(ctx context.Context, req *connect.Request[pb.CreateFleetRequest]) (*connect.Response[pb.CreateFleetResponse], error) {
+	name := strings.TrimSpace(req.Msg.Name)
+	if name == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("fleet name is required"))
+	}
+
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM fleet WHERE name = ?)", name).Scan(&exists); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to check fleet name: %v", err))
+	}
+	if exists {
+		return nil, connect.NewError(connect.CodeAlreadyExists, fmt.Errorf("fleet %q already exists", name))
+	}
+
+	fleetID := uuid.New().String()
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO fleet (id, name, description) VALUES (?, ?, ?)",
+		fleetID, name, req.Msg.Description)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to insert fleet: %v", err))
+	}
+
+	fleet, err := scanFleet(s.db.QueryRowContext(ctx,
+		"SELECT id, name, description, created_at FROM fleet WHERE id = ?", fleetID))
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to get fleet: %v", err))
+	}
+
+	return connect.NewResponse(&pb.CreateFleetResponse{Fleet: fleet}), nil
+}
+
+func (s *DeviceService) ListFleets(ctx context.Context, req *connect.Request[pb.ListFleetsRequest]) (*connect.Response[pb.ListFleetsResponse], error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, name, description, created_at FROM fleet ORDER BY name")
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to list fleets: %v", err))
+	}
+	defer rows.Close()
+
+	var fleets []*pb.Fleet
+	for rows.Next() {
+		fleet, err := scanFleet(rows)
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to scan fleet: %v", err))
+		}
+		fleets = append(fleets, fleet)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to list fleets: %v", err))
+	}
+
+	return connect.NewResponse(&pb.ListFleetsResponse{Fleets: fleets}), nil
+}
+
+// AddDeviceToFleet moves a device from the default fleet into another one.
+// Devices already in a different fleet must be removed from it first, so a
+// device is never silently taken from the fleet it was assigned to.
+func (s *DeviceService) AddDeviceToFleet(ctx context.Context, req *connect.Request[pb.AddDeviceToFleetRequest]) (*connect.Response[pb.AddDeviceToFleetResponse], error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	if err := checkFleetExists(ctx, tx, req.Msg.FleetId); err != nil {
+		return nil, err
+	}
+	current, err := deviceFleet(ctx, tx, req.Msg.DeviceId)
+	if err != nil {
+		return nil, err
+	}
+	if current != DefaultFleetID && current != req.Msg.FleetId {
+		return nil, connect.NewError(connect.CodeFailedPrecondition,
+			fmt.Errorf("device %s already belongs to fleet %s", req.Msg.DeviceId, current))
+	}
+
+	if current != req.Msg.FleetId {
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE device SET fleet_id = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
+			req.Msg.FleetId, req.Msg.DeviceId); err != nil {
+			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to update device fleet: %v", err))
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to commit transaction: %v", err))
+	}
+
+	return connect.NewResponse(&pb.AddDeviceToFleetResponse{PreviousFleetId: current}), nil
+}
+
+// RemoveDeviceFromFleet returns a device to the default fleet
+func (s *DeviceService) RemoveDeviceFromFleet(ctx context.Context, req *connect.Request[pb.RemoveDeviceFromFleetRequest]) (*connect.Response[pb.RemoveDeviceFromFleetResponse], error) {
+	if req.Msg.FleetId == DefaultFleetID {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("devices cannot be removed from the default fleet"))
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	current, err := deviceFleet(ctx, tx, req.Msg.DeviceId)
+	if err != nil {
+		return nil, err
+	}
+	if current != req.Msg.FleetId {
+		return nil, connect.NewError(connect.CodeFailedPrecondition,
+			fmt.Errorf("device %s does not belong to fleet %s", req.Msg.DeviceId, req.Msg.FleetId))
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE device SET fleet_id = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?",
+		DefaultFleetID, req.Msg.DeviceId); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to update device fleet: %v", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to commit transaction: %v", err))
+	}
+
+	return connect.NewResponse(&pb.RemoveDeviceFromFleetResponse{Success: true}), nil
+}
+
+// checkFleetExists returns a NotFound error if there is no fleet with fleetID
+func checkFleetExists(ctx context.Context, tx *sql.Tx, fleetID string) error {
+	var exists bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM fleet WHERE id = ?)", fleetID).Scan(&exists); err != nil {
+		return connect.NewError(connect.CodeInternal, fmt.Errorf("failed to check fleet: %v", err))
+	}
+	if !exists {
+		return connect.NewError(connect.CodeNotFound, fmt.Errorf("fleet %s not found", fleetID))
+	}
+	return nil
+}
+
+// deviceFleet returns the fleet a device belongs to, or a NotFound error
+func deviceFleet(ctx context.Context, tx *sql.Tx, deviceID string) (string, error) {
+	var fleetID string
+	err := tx.QueryRowContext(ctx, "SELECT fleet_id FROM device WHERE id = ?", deviceID).Scan(&fleetID)
+	if err == sql.ErrNoRows {
+		return "", connect.NewError(connect.CodeNotFound, fmt.Errorf("device %s not found", deviceID))
+	}
+	if err != nil {
+		return "", connect.NewError(connect.CodeInternal, fmt.Errorf("failed to get device fleet: %v", err))
+	}
+	return fleetID, nil
+}
+
+// scanFleet scans a row of id, name, description, created_at
+func scanFleet(row rowScanner) (*pb.Fleet, error) {
+	var (
+		fleet     pb.Fleet
+		createdAt string
+	)
+	if err := row.Scan(&fleet.Id, &fleet.Name, &fleet.Description, &createdAt); err != nil {
+		return nil, err
+	}
+	if t, err := parseSQLiteTime(createdAt); err == nil {
+		fleet.CreatedAt = timestamppb.New(t)
+	}
+	return &fleet, nil
+}
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Liveness statuses managed by the HeartbeatTracker. Other statuses, such as
+// those set through BatchUpdateDeviceStatus, are left alone.
+const (
+	DeviceStatusUnknown  = "unknown"
+	DeviceStatusOnline   = "online"
+	DeviceStatusDegraded = "degraded"
+	DeviceStatusOffline  = "offline"
+)
+
+// StatusChange describes a device moving between liveness statuses
+type StatusChange struct {
+	DeviceID       string
+	PreviousStatus string
+	Status         string
+	Time           time.Time
+}
+
+// HeartbeatTrackerConfig configures when devices are considered degraded or offline
+type HeartbeatTrackerConfig struct {
+	Interval      time.Duration // Expected time between heartbeats
+	DegradedAfter int           // Missed heartbeats before a device is degraded
+	OfflineAfter  int           // Missed heartbeats before a device is offline
+}
+
+// DefaultHeartbeatTrackerConfig returns a HeartbeatTrackerConfig with default values
+func DefaultHeartbeatTrackerConfig() HeartbeatTrackerConfig {
+	return HeartbeatTrackerConfig{
+		Interval:      time.Minute,
+		DegradedAfter: 2,
+		OfflineAfter:  5,
+	}
+}
+
+// HeartbeatTracker derives device liveness from last_seen. Each sweep moves
+// devices between online, degraded and offline with a few indexed queries,
+// records the transitions in device_status_event and reports them to the
+// status change handler.
+type HeartbeatTracker struct {
+	db       *sql.DB
+	cfg      HeartbeatTrackerConfig
+	clock    func() time.Time
+	onChange func(context.Context, StatusChange)
+}
+
+// NewHeartbeatTracker creates a new HeartbeatTracker
+func NewHeartbeatTracker(db *sql.DB, cfg HeartbeatTrackerConfig) *HeartbeatTracker {
+	return &HeartbeatTracker{db: db, cfg: cfg, clock: time.Now}
+}
+
+// WithClock sets the clock used to judge missed heartbeats
+func (t *HeartbeatTracker) WithClock(clock func() time.Time) *HeartbeatTracker {
+	t.clock = clock
+	return t
+}
+
+// OnStatusChange sets a handler called for every transition after it is committed
+func (t *HeartbeatTracker) OnStatusChange(fn func(context.Context, StatusChange)) *HeartbeatTracker {
+	t.onChange = fn
+	return t
+}
+
+// Run sweeps once per heartbeat interval until ctx is done
+func (t *HeartbeatTracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := t.Sweep(ctx); err != nil {
+				slog.Error("Failed to sweep device heartbeats", "error", err)
+			}
+		}
+	}
+}
+
+// Sweep updates the liveness status of every device and returns the transitions made
+func (t *HeartbeatTracker) Sweep(ctx context.Context) ([]StatusChange, error) {
+	now := t.clock().UTC()
+	// last_seen is written by CURRENT_TIMESTAMP, so thresholds use the same
+	// format and compare as strings, which keeps idx_device_last_seen usable
+	degradedBefore := now.Add(-time.Duration(t.cfg.DegradedAfter) * t.cfg.Interval).Format(time.DateTime)
+	offlineBefore := now.Add(-time.Duration(t.cfg.OfflineAfter) * t.cfg.Interval).Format(time.DateTime)
+
+	transitions := []struct {
+		status string
+		from   []string
+		where  string
+		args   []any
+	}{
+		{
+			status: DeviceStatusOffline,
+			from:   []string{DeviceStatusUnknown, DeviceStatusOnline, DeviceStatusDegraded},
+			where:  "last_seen < ?",
+			args:   []any{offlineBefore},
+		},
+		{
+			status: DeviceStatusDegraded,
+			from:   []string{DeviceStatusUnknown, DeviceStatusOnline},
+			where:  "last_seen < ? AND last_seen >= ?",
+			args:   []any{degradedBefore, offlineBefore},
+		},
+		{
+			status: DeviceStatusOnline,
+			from:   []string{DeviceStatusUnknown, DeviceStatusDegraded, DeviceStatusOffline},
+			where:  "last_seen >= ?",
+			args:   []any{degradedBefore},
+		},
+	}
+
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var changes []StatusChange
+	for _, tr := range transitions {
+		where := fmt.Sprintf("status IN (%s) AND %s", placeholders(len(tr.from)), tr.where)
+		args := append(stringArgs(tr.from), tr.args...)
+
+		rows, err := tx.QueryContext(ctx, "SELECT id, status FROM device WHERE "+where, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find %s devices: %w", tr.status, err)
+		}
+		var batch []StatusChange
+		for rows.Next() {
+			change := StatusChange{Status: tr.status, Time: now}
+			if err := rows.Scan(&change.DeviceID, &change.PreviousStatus); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan device: %w", err)
+			}
+			batch = append(batch, change)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to iterate devices: %w", err)
+		}
+		if len(batch) == 0 {
+			continue
+		}
+
+		_, err = tx.ExecContext(ctx,
+			"UPDATE device SET status = ?, updated_at = CURRENT_TIMESTAMP WHERE "+where,
+			append([]any{tr.status}, args...)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to mark devices %s: %w", tr.status, err)
+		}
+
+		for _, change := range batch {
+			_, err := tx.ExecContext(ctx,
+				`INSERT INTO device_status_event (device_id, previous_status, status, reason)
+				 VALUES (?, ?, ?, 'heartbeat')`,
+				change.DeviceID, change.PreviousStatus, change.Status)
+			if err != nil {
+				return nil, fmt.Errorf("failed to record status event: %w", err)
+			}
+		}
+		changes = append(changes, batch...)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if t.onChange != nil {
+		for _, change := range changes {
+			t.onChange(ctx, change)
+		}
+	}
+	return changes, nil
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+func stringArgs(values []string) []any {
+	args := make([]any, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	return args
+}
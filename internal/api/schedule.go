@@ -0,0 +1,139 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	// Maintenance windows name time zones, which hosts may not have data for
+	_ "time/tzdata"
+
+	pb "fleetd.sh/gen/fleetd/v1"
+
+	"connectrpc.com/connect"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// maintenanceWindow is a parsed pb.MaintenanceWindow
+type maintenanceWindow struct {
+	start, end time.Duration // Since midnight, local to loc
+	days       map[time.Weekday]bool
+	loc        *time.Location
+}
+
+// parseMaintenanceWindow validates w, returning nil for a nil window
+func parseMaintenanceWindow(w *pb.MaintenanceWindow) (*maintenanceWindow, error) {
+	if w == nil {
+		return nil, nil
+	}
+	start, err := parseTimeOfDay(w.StartTime)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid maintenance window start_time: %v", err))
+	}
+	end, err := parseTimeOfDay(w.EndTime)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid maintenance window end_time: %v", err))
+	}
+	if start == end {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("maintenance window start_time and end_time must differ"))
+	}
+
+	loc := time.UTC
+	if w.TimeZone != "" {
+		loc, err = time.LoadLocation(w.TimeZone)
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid maintenance window time_zone: %v", err))
+		}
+	}
+
+	window := &maintenanceWindow{start: start, end: end, loc: loc}
+	if len(w.Days) > 0 {
+		window.days = make(map[time.Weekday]bool, len(w.Days))
+		for _, day := range w.Days {
+			weekday, ok := weekdays[strings.ToLower(day)]
+			if !ok {
+				return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid maintenance window day %q", day))
+			}
+			window.days[weekday] = true
+		}
+	}
+	return window, nil
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func (w *maintenanceWindow) opensOn(day time.Weekday) bool {
+	return w.days == nil || w.days[day]
+}
+
+// open reports whether the window is open at t
+func (w *maintenanceWindow) open(t time.Time) bool {
+	local := t.In(w.loc)
+	// Wall clock time, which is what the window is written in even on days
+	// with a DST change
+	clock := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute +
+		time.Duration(local.Second())*time.Second
+	day := local.Weekday()
+
+	if w.start < w.end {
+		return w.opensOn(day) && clock >= w.start && clock < w.end
+	}
+	// Past midnight: open late on an opening day, or early the day after
+	return (w.opensOn(day) && clock >= w.start) || (w.opensOn((day+6)%7) && clock < w.end)
+}
+
+// next returns t if the window is open at t, and otherwise when it next
+// opens
+func (w *maintenanceWindow) next(t time.Time) time.Time {
+	if w.open(t) {
+		return t
+	}
+	local := t.In(w.loc)
+	for d := 0; d <= 7; d++ {
+		opens := time.Date(local.Year(), local.Month(), local.Day()+d,
+			int(w.start/time.Hour), int(w.start%time.Hour/time.Minute), 0, 0, w.loc)
+		if opens.After(t) && w.opensOn(opens.Weekday()) {
+			return opens
+		}
+	}
+	// Unreachable: a valid window opens at least once a week
+	return t
+}
+
+// marshalMaintenanceWindow encodes w for the update_campaign table
+func marshalMaintenanceWindow(w *pb.MaintenanceWindow) (string, error) {
+	if w == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(w)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func unmarshalMaintenanceWindow(s string) (*pb.MaintenanceWindow, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var w pb.MaintenanceWindow
+	if err := json.Unmarshal([]byte(s), &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	pb "fleetd.sh/gen/fleetd/v1"
+	"fleetd.sh/internal/control"
+
+	"connectrpc.com/connect"
+)
+
+// selectorFields are the device columns a selector matches directly. Any
+// other key is matched against the device's tags.
+var selectorFields = map[string]bool{
+	"id":       true,
+	"name":     true,
+	"type":     true,
+	"version":  true,
+	"status":   true,
+	"fleet_id": true,
+}
+
+// parseSelector parses a selector expression, reporting syntax errors as
+// invalid arguments
+func parseSelector(expr string) (control.Selector, error) {
+	sel, err := control.ParseSelector(expr)
+	if err != nil {
+		return control.Selector{}, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid selector: %v", err))
+	}
+	return sel, nil
+}
+
+// selectorClause returns a condition on the device table matching sel, for
+// use in a WHERE clause. Tag requirements use the device_tag key/value index.
+func selectorClause(sel control.Selector) (string, []any) {
+	var (
+		clauses []string
+		args    []any
+	)
+	for _, req := range sel.Requirements {
+		if selectorFields[req.Key] {
+			clauses = append(clauses, fmt.Sprintf("device.%s IN (%s)", req.Key, placeholders(len(req.Values))))
+			args = append(args, stringArgs(req.Values)...)
+			continue
+		}
+		clauses = append(clauses, fmt.Sprintf(
+			`EXISTS (SELECT 1 FROM device_tag
+			 WHERE device_tag.device_id = device.id AND device_tag.key = ? AND device_tag.value IN (%s))`,
+			placeholders(len(req.Values))))
+		args = append(args, req.Key)
+		args = append(args, stringArgs(req.Values)...)
+	}
+	if len(clauses) == 0 {
+		return "1=1", nil
+	}
+	return strings.Join(clauses, " AND "), args
+}
+
+func (s *DeviceService) SetDeviceTags(ctx context.Context, req *connect.Request[pb.SetDeviceTagsRequest]) (*connect.Response[pb.SetDeviceTagsResponse], error) {
+	for key := range req.Msg.Tags {
+		if !control.ValidKey(key) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid tag key %q", key))
+		}
+		if selectorFields[key] {
+			return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("tag key %q is reserved for a device field", key))
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM device WHERE id = ?)", req.Msg.DeviceId).Scan(&exists); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to check device: %v", err))
+	}
+	if !exists {
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("device %s not found", req.Msg.DeviceId))
+	}
+
+	if len(req.Msg.RemoveKeys) > 0 {
+		args := append([]any{req.Msg.DeviceId}, stringArgs(req.Msg.RemoveKeys)...)
+		if _, err := tx.ExecContext(ctx,
+			fmt.Sprintf("DELETE FROM device_tag WHERE device_id = ? AND key IN (%s)", placeholders(len(req.Msg.RemoveKeys))),
+			args...); err != nil {
+			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to remove tags: %v", err))
+		}
+	}
+	for key, value := range req.Msg.Tags {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO device_tag (device_id, key, value) VALUES (?, ?, ?)
+			 ON CONFLICT (device_id, key) DO UPDATE SET value = excluded.value`,
+			req.Msg.DeviceId, key, value); err != nil {
+			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to set tag: %v", err))
+		}
+	}
+
+	tags, err := deviceTags(ctx, tx, []string{req.Msg.DeviceId})
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to get tags: %v", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to commit transaction: %v", err))
+	}
+
+	return connect.NewResponse(&pb.SetDeviceTagsResponse{Tags: tags[req.Msg.DeviceId]}), nil
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// deviceTags returns the tags of each of the given devices, keyed by device ID
+func deviceTags(ctx context.Context, q queryer, deviceIDs []string) (map[string]map[string]string, error) {
+	tags := make(map[string]map[string]string)
+	if len(deviceIDs) == 0 {
+		return tags, nil
+	}
+
+	rows, err := q.QueryContext(ctx,
+		fmt.Sprintf("SELECT device_id, key, value FROM device_tag WHERE device_id IN (%s)", placeholders(len(deviceIDs))),
+		stringArgs(deviceIDs)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var deviceID, key, value string
+		if err := rows.Scan(&deviceID, &key, &value); err != nil {
+			return nil, err
+		}
+		if tags[deviceID] == nil {
+			tags[deviceID] = make(map[string]string)
+		}
+		tags[deviceID][key] = value
+	}
+	return tags, rows.Err()
+}
+
+// attachTags fills in the tags of each device
+func attachTags(ctx context.Context, q queryer, devices []*pb.Device) error {
+	ids := make([]string, len(devices))
+	for i, device := range devices {
+		ids[i] = device.Id
+	}
+	tags, err := deviceTags(ctx, q, ids)
+	if err != nil {
+		return err
+	}
+	for _, device := range devices {
+		device.Tags = tags[device.Id]
+	}
+	return nil
+}
@@ -2,15 +2,20 @@ package api
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 	"strings"
 	"time"
 
 	pb "fleetd.sh/gen/fleetd/v1"
 	rpc "fleetd.sh/gen/fleetd/v1/fleetpbconnect"
+	"fleetd.sh/internal/webhook"
 
 	"connectrpc.com/connect"
 	"github.com/google/uuid"
@@ -19,13 +24,21 @@ import (
 
 type UpdateService struct {
 	rpc.UnimplementedUpdateServiceHandler
-	db *sql.DB
+	db     *sql.DB
+	events EventPublisher
 }
 
 func NewUpdateService(db *sql.DB) *UpdateService {
 	return &UpdateService{db: db}
 }
 
+// WithEvents publishes deployment.succeeded and deployment.failed events when
+// campaigns finish
+func (s *UpdateService) WithEvents(publisher EventPublisher) *UpdateService {
+	s.events = publisher
+	return s
+}
+
 func (s *UpdateService) CreateUpdateCampaign(ctx context.Context, req *connect.Request[pb.CreateUpdateCampaignRequest]) (*connect.Response[pb.CreateUpdateCampaignResponse], error) {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -59,6 +72,14 @@ func (s *UpdateService) CreateUpdateCampaign(ctx context.Context, req *connect.R
 		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to marshal metadata: %v", err))
 	}
 
+	if _, err := parseMaintenanceWindow(req.Msg.MaintenanceWindow); err != nil {
+		return nil, err
+	}
+	window, err := marshalMaintenanceWindow(req.Msg.MaintenanceWindow)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to marshal maintenance window: %v", err))
+	}
+
 	// Count target devices
 	query := `SELECT id FROM device WHERE 1=1`
 	args := []interface{}{}
@@ -81,6 +102,16 @@ func (s *UpdateService) CreateUpdateCampaign(ctx context.Context, req *connect.R
 		// TODO: Add metadata filtering
 	}
 
+	if req.Msg.TargetSelector != "" {
+		sel, err := parseSelector(req.Msg.TargetSelector)
+		if err != nil {
+			return nil, err
+		}
+		clause, selectorArgs := selectorClause(sel)
+		query += " AND " + clause
+		args = append(args, selectorArgs...)
+	}
+
 	rows, err := tx.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to query devices: %v", err))
@@ -96,16 +127,27 @@ func (s *UpdateService) CreateUpdateCampaign(ctx context.Context, req *connect.R
 		deviceIDs = append(deviceIDs, id)
 	}
 
+	if req.Msg.ValidateOnly {
+		warnings, err := validateCampaign(ctx, tx, req.Msg, len(deviceIDs))
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to validate campaign: %v", err))
+		}
+		return connect.NewResponse(&pb.CreateUpdateCampaignResponse{
+			TargetDeviceCount: int32(len(deviceIDs)),
+			Warnings:          warnings,
+		}), nil
+	}
+
 	campaignID := uuid.New().String()
 	_, err = tx.ExecContext(ctx,
 		`INSERT INTO update_campaign (
 			id, name, description, binary_id, target_version,
-			target_platforms, target_architectures, target_metadata,
-			strategy, status, total_devices
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			target_platforms, target_architectures, target_metadata, target_selector,
+			maintenance_window, strategy, status, total_devices
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		campaignID, req.Msg.Name, req.Msg.Description, req.Msg.BinaryId, req.Msg.TargetVersion,
-		string(platforms), string(architectures), string(metadata),
-		req.Msg.Strategy, pb.UpdateCampaignStatus_UPDATE_CAMPAIGN_STATUS_CREATED, len(deviceIDs))
+		string(platforms), string(architectures), string(metadata), req.Msg.TargetSelector,
+		window, req.Msg.Strategy, pb.UpdateCampaignStatus_UPDATE_CAMPAIGN_STATUS_CREATED, len(deviceIDs))
 	if err != nil {
 		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to create campaign: %v", err))
 	}
@@ -148,31 +190,78 @@ func (s *UpdateService) CreateUpdateCampaign(ctx context.Context, req *connect.R
 
 	return &connect.Response[pb.CreateUpdateCampaignResponse]{
 		Msg: &pb.CreateUpdateCampaignResponse{
-			CampaignId: campaignID,
+			CampaignId:        campaignID,
+			TargetDeviceCount: int32(len(deviceIDs)),
 		},
 	}, nil
 }
 
+// validateCampaign checks a campaign request without creating it, returning
+// warnings about problems that would not stop the campaign from being
+// created but would likely make it fail or do nothing
+func validateCampaign(ctx context.Context, tx *sql.Tx, req *pb.CreateUpdateCampaignRequest, targets int) ([]string, error) {
+	var (
+		warnings    []string
+		version     string
+		sha256sum   string
+		size        int64
+		storagePath string
+	)
+	err := tx.QueryRowContext(ctx,
+		"SELECT version, sha256, size, storage_path FROM binary WHERE id = ?",
+		req.BinaryId).Scan(&version, &sha256sum, &size, &storagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if targets == 0 {
+		warnings = append(warnings, "no devices match the campaign targets")
+	}
+	if req.TargetVersion == "" {
+		warnings = append(warnings, "target_version is not set")
+	} else if req.TargetVersion != version {
+		warnings = append(warnings, fmt.Sprintf("target_version %s does not match binary version %s", req.TargetVersion, version))
+	}
+
+	// Devices verify the checksum after downloading, so a corrupt or missing
+	// blob fails every device
+	f, err := os.Open(storagePath)
+	if err != nil {
+		return append(warnings, fmt.Sprintf("binary content is not available: %v", err)), nil
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return append(warnings, fmt.Sprintf("failed to read binary content: %v", err)), nil
+	}
+	if n != size || hex.EncodeToString(h.Sum(nil)) != sha256sum {
+		warnings = append(warnings, "binary content does not match its recorded size and checksum")
+	}
+	return warnings, nil
+}
+
 func (s *UpdateService) GetUpdateCampaign(ctx context.Context, req *connect.Request[pb.GetUpdateCampaignRequest]) (*connect.Response[pb.GetUpdateCampaignResponse], error) {
 	var (
 		campaign     pb.UpdateCampaign
 		platforms    string
 		archs        string
 		metadata     string
+		window       string
 		createdAtStr string
 		updatedAtStr string
 	)
 
 	err := s.db.QueryRowContext(ctx,
 		`SELECT id, name, description, binary_id, target_version,
-			target_platforms, target_architectures, target_metadata,
-			strategy, status, total_devices, updated_devices, failed_devices,
+			target_platforms, target_architectures, target_metadata, target_selector,
+			maintenance_window, strategy, status, total_devices, updated_devices, failed_devices,
 			created_at, updated_at
 		 FROM update_campaign WHERE id = ?`,
 		req.Msg.CampaignId).Scan(
 		&campaign.Id, &campaign.Name, &campaign.Description,
 		&campaign.BinaryId, &campaign.TargetVersion,
-		&platforms, &archs, &metadata,
+		&platforms, &archs, &metadata, &campaign.TargetSelector, &window,
 		&campaign.Strategy, &campaign.Status,
 		&campaign.TotalDevices, &campaign.UpdatedDevices, &campaign.FailedDevices,
 		&createdAtStr, &updatedAtStr)
@@ -192,6 +281,9 @@ func (s *UpdateService) GetUpdateCampaign(ctx context.Context, req *connect.Requ
 	if err := json.Unmarshal([]byte(metadata), &campaign.TargetMetadata); err != nil {
 		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to unmarshal metadata: %v", err))
 	}
+	if campaign.MaintenanceWindow, err = unmarshalMaintenanceWindow(window); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to unmarshal maintenance window: %v", err))
+	}
 
 	// Parse timestamps in RFC3339 format
 	createdAt, err := time.Parse(time.RFC3339, createdAtStr)
@@ -215,8 +307,8 @@ func (s *UpdateService) GetUpdateCampaign(ctx context.Context, req *connect.Requ
 
 func (s *UpdateService) ListUpdateCampaigns(ctx context.Context, req *connect.Request[pb.ListUpdateCampaignsRequest]) (*connect.Response[pb.ListUpdateCampaignsResponse], error) {
 	query := `SELECT id, name, description, binary_id, target_version,
-		target_platforms, target_architectures, target_metadata,
-		strategy, status, total_devices, updated_devices, failed_devices,
+		target_platforms, target_architectures, target_metadata, target_selector,
+		maintenance_window, strategy, status, total_devices, updated_devices, failed_devices,
 		created_at, updated_at
 		FROM update_campaign WHERE 1=1`
 	args := []interface{}{}
@@ -248,6 +340,7 @@ func (s *UpdateService) ListUpdateCampaigns(ctx context.Context, req *connect.Re
 			platforms    string
 			archs        string
 			metadata     string
+			window       string
 			createdAtStr string
 			updatedAtStr string
 		)
@@ -255,7 +348,7 @@ func (s *UpdateService) ListUpdateCampaigns(ctx context.Context, req *connect.Re
 		err := rows.Scan(
 			&campaign.Id, &campaign.Name, &campaign.Description,
 			&campaign.BinaryId, &campaign.TargetVersion,
-			&platforms, &archs, &metadata,
+			&platforms, &archs, &metadata, &campaign.TargetSelector, &window,
 			&campaign.Strategy, &campaign.Status,
 			&campaign.TotalDevices, &campaign.UpdatedDevices, &campaign.FailedDevices,
 			&createdAtStr, &updatedAtStr)
@@ -272,6 +365,9 @@ func (s *UpdateService) ListUpdateCampaigns(ctx context.Context, req *connect.Re
 		if err := json.Unmarshal([]byte(metadata), &campaign.TargetMetadata); err != nil {
 			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to unmarshal metadata: %v", err))
 		}
+		if campaign.MaintenanceWindow, err = unmarshalMaintenanceWindow(window); err != nil {
+			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to unmarshal maintenance window: %v", err))
+		}
 
 		// Parse timestamps in RFC3339 format
 		createdAt, err := time.Parse(time.RFC3339, createdAtStr)
@@ -376,6 +472,9 @@ func (s *UpdateService) ReportUpdateStatus(ctx context.Context, req *connect.Req
 	case pb.DeviceUpdateStatus_DEVICE_UPDATE_STATUS_INSTALLED:
 		if previousStatus != pb.DeviceUpdateStatus_DEVICE_UPDATE_STATUS_INSTALLED {
 			updateSQL = "updated_devices = updated_devices + 1"
+			if err := recordInstalledVersion(ctx, tx, req.Msg.DeviceId, req.Msg.CampaignId); err != nil {
+				return nil, connect.NewError(connect.CodeInternal, err)
+			}
 		}
 	case pb.DeviceUpdateStatus_DEVICE_UPDATE_STATUS_FAILED,
 		pb.DeviceUpdateStatus_DEVICE_UPDATE_STATUS_ROLLED_BACK:
@@ -412,21 +511,252 @@ func (s *UpdateService) ReportUpdateStatus(ctx context.Context, req *connect.Req
 		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to get campaign stats: %v", err))
 	}
 
+	finished := false
 	if updatedDevices+failedDevices >= totalDevices {
-		_, err = tx.ExecContext(ctx,
-			"UPDATE update_campaign SET status = ?, updated_at = datetime('now') WHERE id = ?",
+		result, err := tx.ExecContext(ctx,
+			"UPDATE update_campaign SET status = ?, updated_at = datetime('now') WHERE id = ? AND status != ?",
 			pb.UpdateCampaignStatus_UPDATE_CAMPAIGN_STATUS_COMPLETED,
-			req.Msg.CampaignId)
+			req.Msg.CampaignId,
+			pb.UpdateCampaignStatus_UPDATE_CAMPAIGN_STATUS_COMPLETED)
 		if err != nil {
 			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to update campaign status: %v", err))
 		}
+		completed, err := result.RowsAffected()
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to get rows affected: %v", err))
+		}
+		finished = completed > 0
 	}
 
 	if err := tx.Commit(); err != nil {
 		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to commit transaction: %v", err))
 	}
 
+	if finished {
+		eventType := webhook.EventDeploymentSucceeded
+		if failedDevices > 0 {
+			eventType = webhook.EventDeploymentFailed
+		}
+		publishEvent(ctx, s.events, eventType, time.Now(), map[string]any{
+			"campaign_id":     req.Msg.CampaignId,
+			"total_devices":   totalDevices,
+			"updated_devices": updatedDevices,
+			"failed_devices":  failedDevices,
+		})
+	}
+
 	return &connect.Response[pb.ReportUpdateStatusResponse]{
 		Msg: &pb.ReportUpdateStatusResponse{Success: true},
 	}, nil
 }
+
+// recordInstalledVersion appends the campaign's version to the device's
+// version history and makes it the device's current version
+func recordInstalledVersion(ctx context.Context, tx *sql.Tx, deviceID, campaignID string) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO device_version_history (device_id, campaign_id, binary_id, version, sha256)
+		 SELECT ?, c.id, c.binary_id, c.target_version, b.sha256
+		 FROM update_campaign c JOIN binary b ON b.id = c.binary_id
+		 WHERE c.id = ?`,
+		deviceID, campaignID)
+	if err != nil {
+		return fmt.Errorf("failed to record version history: %v", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE device SET version = (SELECT target_version FROM update_campaign WHERE id = ?),
+		 updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		campaignID, deviceID)
+	if err != nil {
+		return fmt.Errorf("failed to update device version: %v", err)
+	}
+	return nil
+}
+
+func (s *UpdateService) RollbackUpdateCampaign(ctx context.Context, req *connect.Request[pb.RollbackUpdateCampaignRequest]) (*connect.Response[pb.RollbackUpdateCampaignResponse], error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to begin transaction: %v", err))
+	}
+	defer tx.Rollback()
+
+	var (
+		name     string
+		strategy pb.UpdateStrategy
+	)
+	err = tx.QueryRowContext(ctx,
+		"SELECT name, strategy FROM update_campaign WHERE id = ?",
+		req.Msg.CampaignId).Scan(&name, &strategy)
+	if err == sql.ErrNoRows {
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("campaign not found"))
+	}
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to get campaign: %v", err))
+	}
+
+	// Rolling back twice returns the rollback campaigns created the first time
+	existing, err := queryStrings(ctx, tx,
+		"SELECT id FROM update_campaign WHERE rollback_of = ? ORDER BY id", req.Msg.CampaignId)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to check existing rollback: %v", err))
+	}
+	if len(existing) > 0 {
+		return connect.NewResponse(&pb.RollbackUpdateCampaignResponse{CampaignIds: existing}), nil
+	}
+
+	// For every device that installed this campaign, find the version installed before it
+	rows, err := tx.QueryContext(ctx,
+		`SELECT h.device_id,
+			(SELECT p.binary_id FROM device_version_history p
+			 WHERE p.device_id = h.device_id AND p.id < h.id
+			 ORDER BY p.id DESC LIMIT 1),
+			(SELECT p.version FROM device_version_history p
+			 WHERE p.device_id = h.device_id AND p.id < h.id
+			 ORDER BY p.id DESC LIMIT 1)
+		 FROM device_version_history h
+		 WHERE h.campaign_id = ?
+		 ORDER BY h.device_id`,
+		req.Msg.CampaignId)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to query version history: %v", err))
+	}
+
+	type target struct {
+		version string
+		devices []string
+	}
+	var (
+		targets   = make(map[string]*target)
+		binaryIDs []string
+		skipped   []string
+	)
+	for rows.Next() {
+		var (
+			deviceID string
+			binaryID sql.NullString
+			version  sql.NullString
+		)
+		if err := rows.Scan(&deviceID, &binaryID, &version); err != nil {
+			rows.Close()
+			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to scan version history: %v", err))
+		}
+		if !binaryID.Valid {
+			skipped = append(skipped, deviceID)
+			continue
+		}
+		t, ok := targets[binaryID.String]
+		if !ok {
+			t = &target{version: version.String}
+			targets[binaryID.String] = t
+			binaryIDs = append(binaryIDs, binaryID.String)
+		}
+		t.devices = append(t.devices, deviceID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to read version history: %v", err))
+	}
+
+	if len(targets) == 0 {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, errors.New("no previous version to roll back to"))
+	}
+
+	status := pb.UpdateCampaignStatus_UPDATE_CAMPAIGN_STATUS_CREATED
+	if strategy == pb.UpdateStrategy_UPDATE_STRATEGY_IMMEDIATE {
+		status = pb.UpdateCampaignStatus_UPDATE_CAMPAIGN_STATUS_IN_PROGRESS
+	}
+
+	var campaignIDs []string
+	for _, binaryID := range binaryIDs {
+		t := targets[binaryID]
+		campaignID := uuid.New().String()
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO update_campaign (
+				id, name, description, binary_id, target_version,
+				target_platforms, target_architectures, target_metadata,
+				strategy, status, total_devices, rollback_of
+			) VALUES (?, ?, ?, ?, ?, '[]', '[]', '{}', ?, ?, ?, ?)`,
+			campaignID, "Rollback of "+name,
+			fmt.Sprintf("Roll back campaign %s to version %s", req.Msg.CampaignId, t.version),
+			binaryID, t.version, strategy, status, len(t.devices), req.Msg.CampaignId)
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to create rollback campaign: %v", err))
+		}
+
+		for _, deviceID := range t.devices {
+			_, err = tx.ExecContext(ctx,
+				`INSERT INTO device_update (device_id, campaign_id, status)
+				 VALUES (?, ?, ?)`,
+				deviceID, campaignID, pb.DeviceUpdateStatus_DEVICE_UPDATE_STATUS_PENDING)
+			if err != nil {
+				return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to create device update: %v", err))
+			}
+		}
+		campaignIDs = append(campaignIDs, campaignID)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE update_campaign SET status = ?, updated_at = datetime('now') WHERE id = ?",
+		pb.UpdateCampaignStatus_UPDATE_CAMPAIGN_STATUS_ROLLED_BACK, req.Msg.CampaignId)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to update campaign status: %v", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to commit transaction: %v", err))
+	}
+
+	return connect.NewResponse(&pb.RollbackUpdateCampaignResponse{
+		CampaignIds:      campaignIDs,
+		SkippedDeviceIds: skipped,
+	}), nil
+}
+
+func (s *UpdateService) GetDeviceVersionHistory(ctx context.Context, req *connect.Request[pb.GetDeviceVersionHistoryRequest]) (*connect.Response[pb.GetDeviceVersionHistoryResponse], error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT campaign_id, binary_id, version, sha256, installed_at
+		 FROM device_version_history WHERE device_id = ? ORDER BY id DESC`,
+		req.Msg.DeviceId)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to get version history: %v", err))
+	}
+	defer rows.Close()
+
+	var versions []*pb.DeviceVersion
+	for rows.Next() {
+		var (
+			version        pb.DeviceVersion
+			installedAtStr string
+		)
+		if err := rows.Scan(&version.CampaignId, &version.BinaryId, &version.Version, &version.Sha256, &installedAtStr); err != nil {
+			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to scan version history: %v", err))
+		}
+		installedAt, err := time.Parse(time.RFC3339, installedAtStr)
+		if err != nil {
+			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to parse installed_at timestamp: %v", err))
+		}
+		version.InstalledAt = timestamppb.New(installedAt)
+		versions = append(versions, &version)
+	}
+
+	return connect.NewResponse(&pb.GetDeviceVersionHistoryResponse{Versions: versions}), nil
+}
+
+// queryStrings returns the single string column selected by query
+func queryStrings(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
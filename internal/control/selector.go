@@ -0,0 +1,264 @@
+// Package control holds the control-plane logic shared by the device,
+// deployment and query APIs.
+package control
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Requirement matches devices whose Key has one of Values
+type Requirement struct {
+	Key    string
+	Values []string
+}
+
+// Selector selects devices that meet all of its requirements. The zero
+// Selector selects every device.
+type Selector struct {
+	Requirements []Requirement
+}
+
+// ParseSelector parses a selector expression such as
+//
+//	type=rpi AND region in (us-east, us-west) AND env="prod"
+//
+// Requirements are joined with AND and compare a key against a single value
+// with = or against a list of values with in. Keywords are case-insensitive.
+// Values containing spaces or punctuation can be double quoted. An empty
+// expression selects every device.
+func ParseSelector(expr string) (Selector, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return Selector{}, err
+	}
+
+	p := &parser{tokens: tokens}
+	var sel Selector
+	if p.done() {
+		return sel, nil
+	}
+	for {
+		req, err := p.requirement()
+		if err != nil {
+			return Selector{}, err
+		}
+		sel.Requirements = append(sel.Requirements, req)
+
+		if p.done() {
+			return sel, nil
+		}
+		if tok := p.next(); !tok.isKeyword("and") {
+			return Selector{}, fmt.Errorf("expected AND at offset %d, got %q", tok.pos, tok.text)
+		}
+	}
+}
+
+// Matches reports whether a device with the given labels meets every
+// requirement
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, req := range s.Requirements {
+		value, ok := labels[req.Key]
+		if !ok || !contains(req.Values, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Empty reports whether the selector selects every device
+func (s Selector) Empty() bool {
+	return len(s.Requirements) == 0
+}
+
+// String formats the selector in the syntax accepted by ParseSelector
+func (s Selector) String() string {
+	parts := make([]string, len(s.Requirements))
+	for i, req := range s.Requirements {
+		if len(req.Values) == 1 {
+			parts[i] = req.Key + "=" + quote(req.Values[0])
+			continue
+		}
+		values := make([]string, len(req.Values))
+		for j, v := range req.Values {
+			values[j] = quote(v)
+		}
+		parts[i] = req.Key + " in (" + strings.Join(values, ", ") + ")"
+	}
+	return strings.Join(parts, " AND ")
+}
+
+// ValidKey reports whether key can be used unquoted in a selector expression
+func ValidKey(key string) bool {
+	if key == "" || strings.EqualFold(key, "and") || strings.EqualFold(key, "in") {
+		return false
+	}
+	for _, r := range key {
+		if !isWordRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func quote(value string) string {
+	for _, r := range value {
+		if !isWordRune(r) {
+			return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+		}
+	}
+	if value == "" || strings.EqualFold(value, "and") || strings.EqualFold(value, "in") {
+		return `"` + value + `"`
+	}
+	return value
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenString
+	tokenSymbol
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) isKeyword(keyword string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.text, keyword)
+}
+
+func (t token) isSymbol(symbol string) bool {
+	return t.kind == tokenSymbol && t.text == symbol
+}
+
+// isWordRune reports whether r may appear in an unquoted key or value
+func isWordRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+		strings.ContainsRune("_-./:", r)
+}
+
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			i++
+		case r == '=' || r == '(' || r == ')' || r == ',':
+			tokens = append(tokens, token{kind: tokenSymbol, text: string(r), pos: i})
+			i++
+		case r == '"':
+			start := i
+			var sb strings.Builder
+			for i++; ; i++ {
+				if i >= len(runes) {
+					return nil, fmt.Errorf("unterminated string at offset %d", start)
+				}
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				} else if runes[i] == '"' {
+					break
+				}
+				sb.WriteRune(runes[i])
+			}
+			i++
+			tokens = append(tokens, token{kind: tokenString, text: sb.String(), pos: start})
+		case isWordRune(r):
+			start := i
+			for i < len(runes) && isWordRune(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: string(runes[start:i]), pos: start})
+		default:
+			return nil, fmt.Errorf("unexpected character %q at offset %d", r, i)
+		}
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) next() token {
+	if p.done() {
+		return token{kind: tokenSymbol, text: "end of expression", pos: -1}
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+	return tok
+}
+
+func (p *parser) requirement() (Requirement, error) {
+	key := p.next()
+	if key.kind != tokenWord || key.isKeyword("and") || key.isKeyword("in") {
+		return Requirement{}, fmt.Errorf("expected key, got %q", key.text)
+	}
+
+	op := p.next()
+	switch {
+	case op.isSymbol("="):
+		value, err := p.value()
+		if err != nil {
+			return Requirement{}, err
+		}
+		return Requirement{Key: key.text, Values: []string{value}}, nil
+	case op.isKeyword("in"):
+		values, err := p.list()
+		if err != nil {
+			return Requirement{}, err
+		}
+		return Requirement{Key: key.text, Values: values}, nil
+	default:
+		return Requirement{}, fmt.Errorf("expected = or in after %q, got %q", key.text, op.text)
+	}
+}
+
+func (p *parser) value() (string, error) {
+	tok := p.next()
+	if tok.kind == tokenString || tok.kind == tokenWord && !tok.isKeyword("and") && !tok.isKeyword("in") {
+		return tok.text, nil
+	}
+	return "", fmt.Errorf("expected value, got %q", tok.text)
+}
+
+func (p *parser) list() ([]string, error) {
+	if tok := p.next(); !tok.isSymbol("(") {
+		return nil, fmt.Errorf("expected ( after in, got %q", tok.text)
+	}
+	var values []string
+	for {
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		tok := p.next()
+		if tok.isSymbol(")") {
+			return values, nil
+		}
+		if !tok.isSymbol(",") {
+			return nil, fmt.Errorf("expected , or ) in value list, got %q", tok.text)
+		}
+	}
+}
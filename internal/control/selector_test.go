@@ -0,0 +1,92 @@
+package control
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []Requirement
+	}{
+		{"", nil},
+		{"   ", nil},
+		{"env=prod", []Requirement{{Key: "env", Values: []string{"prod"}}}},
+		{"type = raspberry-pi AND region in (us-east, us-west)", []Requirement{
+			{Key: "type", Values: []string{"raspberry-pi"}},
+			{Key: "region", Values: []string{"us-east", "us-west"}},
+		}},
+		{"env=prod and zone IN (a)", []Requirement{
+			{Key: "env", Values: []string{"prod"}},
+			{Key: "zone", Values: []string{"a"}},
+		}},
+		{`site="Main St, 12" AND owner="say \"hi\""`, []Requirement{
+			{Key: "site", Values: []string{"Main St, 12"}},
+			{Key: "owner", Values: []string{`say "hi"`}},
+		}},
+		{`k8s.io/arch=arm64 AND version in ("1.0", 2.0)`, []Requirement{
+			{Key: "k8s.io/arch", Values: []string{"arm64"}},
+			{Key: "version", Values: []string{"1.0", "2.0"}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			sel, err := ParseSelector(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, sel.Requirements)
+
+			// Formatting and parsing again gives the same selector
+			again, err := ParseSelector(sel.String())
+			require.NoError(t, err)
+			assert.Equal(t, sel, again)
+		})
+	}
+}
+
+func TestParseSelector_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"env",
+		"env=",
+		"=prod",
+		"env==prod",
+		"env=prod AND",
+		"env=prod env=dev",
+		"env=prod OR env=dev",
+		"env in prod",
+		"env in ()",
+		"env in (a, b",
+		"env in (a b)",
+		`env="prod`,
+		"env=prod;",
+		"and=prod",
+		"env=in",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := ParseSelector(expr)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSelectorMatches(t *testing.T) {
+	sel, err := ParseSelector("type=rpi AND region in (us-east, us-west)")
+	require.NoError(t, err)
+
+	assert.True(t, sel.Matches(map[string]string{"type": "rpi", "region": "us-east", "env": "prod"}))
+	assert.True(t, sel.Matches(map[string]string{"type": "rpi", "region": "us-west"}))
+	assert.False(t, sel.Matches(map[string]string{"type": "rpi", "region": "eu-west"}))
+	assert.False(t, sel.Matches(map[string]string{"type": "rpi"}))
+
+	assert.True(t, Selector{}.Matches(nil))
+}
+
+func TestValidKey(t *testing.T) {
+	assert.True(t, ValidKey("env"))
+	assert.True(t, ValidKey("k8s.io/arch"))
+	assert.False(t, ValidKey(""))
+	assert.False(t, ValidKey("my key"))
+	assert.False(t, ValidKey("AND"))
+}
@@ -0,0 +1,84 @@
+package discovery
+
+import (
+	"net"
+	"strconv"
+	"strings"
+)
+
+// TXT record keys published by fleetd devices
+const (
+	TXTDeviceID     = "deviceid"
+	TXTAPIEndpoint  = "api"
+	TXTVersion      = "version"
+	TXTAPIPort      = "port"
+	TXTCapabilities = "caps"
+)
+
+// DeviceAnnouncement is what a fleetd device advertises about itself over mDNS
+type DeviceAnnouncement struct {
+	DeviceID     string
+	APIEndpoint  string   // Fleet API endpoint the device is configured with, if any
+	Version      string   // Agent version
+	APIPort      int      // Port of the agent's local RPC server
+	Capabilities []string // Services the agent offers, e.g. "discovery"
+
+	// Where the announcement came from
+	Host string
+	Addr net.IP
+	Port int
+}
+
+// TXT returns the announcement's TXT record fields. Empty values are omitted.
+func (a DeviceAnnouncement) TXT() []string {
+	var port string
+	if a.APIPort > 0 {
+		port = strconv.Itoa(a.APIPort)
+	}
+
+	var fields []string
+	for _, kv := range [][2]string{
+		{TXTDeviceID, a.DeviceID},
+		{TXTAPIEndpoint, a.APIEndpoint},
+		{TXTVersion, a.Version},
+		{TXTAPIPort, port},
+		{TXTCapabilities, strings.Join(a.Capabilities, ",")},
+	} {
+		if kv[1] != "" {
+			fields = append(fields, kv[0]+"="+kv[1])
+		}
+	}
+	return fields
+}
+
+// ParseAnnouncement reads an announcement from TXT record fields of the form
+// key=value. Unknown keys, fields without a value and invalid ports are
+// ignored.
+func ParseAnnouncement(fields []string) DeviceAnnouncement {
+	var a DeviceAnnouncement
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(key) {
+		case TXTDeviceID:
+			a.DeviceID = value
+		case TXTAPIEndpoint:
+			a.APIEndpoint = value
+		case TXTVersion:
+			a.Version = value
+		case TXTAPIPort:
+			if port, err := strconv.Atoi(value); err == nil && port > 0 && port < 65536 {
+				a.APIPort = port
+			}
+		case TXTCapabilities:
+			for _, capability := range strings.Split(value, ",") {
+				if capability = strings.TrimSpace(capability); capability != "" {
+					a.Capabilities = append(a.Capabilities, capability)
+				}
+			}
+		}
+	}
+	return a
+}
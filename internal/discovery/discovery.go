@@ -4,7 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"strings"
+	"sort"
 	"sync"
 	"time"
 
@@ -16,6 +16,13 @@ const (
 	DefaultServiceName = "_fleetd._tcp"
 )
 
+// Browser finds fleetd devices on the local network
+type Browser interface {
+	BrowseAnnouncements(ctx context.Context, timeout time.Duration) ([]DeviceAnnouncement, error)
+}
+
+var _ Browser = (*Discovery)(nil)
+
 // Discovery handles mDNS service discovery
 type Discovery struct {
 	deviceID    string
@@ -25,6 +32,13 @@ type Discovery struct {
 	mu          sync.Mutex
 	ctx         context.Context
 	cancel      context.CancelFunc
+
+	// announcement holds the TXT fields advertised besides the device ID
+	announcement DeviceAnnouncement
+
+	// query sends an mDNS query and delivers responses to params.Entries
+	// until params.Timeout passes
+	query func(params *mdns.QueryParam) error
 }
 
 // New creates a new Discovery instance
@@ -39,6 +53,7 @@ func New(deviceID string, port int, serviceType string) *Discovery {
 		serviceType: serviceType,
 		ctx:         ctx,
 		cancel:      cancel,
+		query:       mdns.Query,
 	}
 }
 
@@ -54,13 +69,53 @@ func NewBrowser(serviceType string) *Discovery {
 		serviceType: serviceType,
 		ctx:         ctx,
 		cancel:      cancel,
+		query:       mdns.Query,
+	}
+}
+
+// WithAnnouncement sets the details advertised in TXT records along with the
+// device ID
+func (d *Discovery) WithAnnouncement(a DeviceAnnouncement) *Discovery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.announcement = a
+	return d
+}
+
+// Announce replaces the advertised details, re-advertising the device if
+// it is already being advertised
+func (d *Discovery) Announce(a DeviceAnnouncement) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.announcement = a
+	if d.server == nil {
+		return nil
 	}
+	d.server.Shutdown()
+	d.server = nil
+	return d.start()
 }
 
 // Start begins advertising the device on the network
 func (d *Discovery) Start() error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
+	return d.start()
+}
+
+// TXT returns the TXT record fields the device is advertised with
+func (d *Discovery) TXT() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	a := d.announcement
+	a.DeviceID = d.deviceID
+	return a.TXT()
+}
+
+func (d *Discovery) start() error {
+	announcement := d.announcement
+	announcement.DeviceID = d.deviceID
 
 	// Get host information
 	host, err := os.Hostname()
@@ -76,9 +131,7 @@ func (d *Discovery) Start() error {
 		"",
 		d.port,
 		nil,
-		[]string{
-			fmt.Sprintf("deviceid=%s", d.deviceID),
-		},
+		announcement.TXT(),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create mDNS service: %w", err)
@@ -111,31 +164,48 @@ func (d *Discovery) Stop() error {
 	return nil
 }
 
-// Browse looks for other fleetd devices on the network
+// Browse looks for other fleetd devices on the network and returns their IDs
 func (d *Discovery) Browse(ctx context.Context, timeout time.Duration) ([]string, error) {
-	devices := make(map[string]bool)
+	announcements, err := d.BrowseAnnouncements(ctx, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, len(announcements))
+	for i, a := range announcements {
+		result[i] = a.DeviceID
+	}
+	return result, nil
+}
+
+// BrowseAnnouncements looks for other fleetd devices on the network for up
+// to timeout and returns their announcements, one per device ID, sorted by
+// device ID
+func (d *Discovery) BrowseAnnouncements(ctx context.Context, timeout time.Duration) ([]DeviceAnnouncement, error) {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < timeout {
+		timeout = time.Until(deadline)
+	}
+
+	devices := make(map[string]DeviceAnnouncement)
 	entriesCh := make(chan *mdns.ServiceEntry, 10)
+	done := make(chan struct{})
 
-	// Start collecting entries
+	// Collect entries until the query finishes
 	go func() {
-		for {
-			select {
-			case entry, ok := <-entriesCh:
-				if !ok {
-					return
-				}
-				for _, field := range entry.InfoFields {
-					if strings.HasPrefix(field, "deviceid=") {
-						deviceID := strings.TrimPrefix(field, "deviceid=")
-						// Skip self-discovery
-						if deviceID != d.deviceID {
-							devices[deviceID] = true
-						}
-					}
-				}
-			case <-ctx.Done():
-				return
+		defer close(done)
+		for entry := range entriesCh {
+			a := ParseAnnouncement(entry.InfoFields)
+			// Skip self-discovery and services that are not fleetd devices
+			if a.DeviceID == "" || a.DeviceID == d.deviceID {
+				continue
+			}
+			a.Host = entry.Host
+			a.Addr = entry.AddrV4
+			if a.Addr == nil {
+				a.Addr = entry.AddrV6
 			}
+			a.Port = entry.Port
+			devices[a.DeviceID] = a
 		}
 	}()
 
@@ -145,22 +215,18 @@ func (d *Discovery) Browse(ctx context.Context, timeout time.Duration) ([]string
 	params.Entries = entriesCh
 	params.DisableIPv6 = true
 
-	// Perform query
-	if err := mdns.Query(params); err != nil {
+	// Perform query, which returns once the timeout has passed
+	err := d.query(params)
+	close(entriesCh)
+	<-done
+	if err != nil {
 		return nil, fmt.Errorf("mdns query failed: %w", err)
 	}
 
-	// Wait for timeout or context cancellation
-	select {
-	case <-time.After(timeout):
-	case <-ctx.Done():
-	}
-
-	// Convert map to slice
-	var result []string
-	for device := range devices {
-		result = append(result, device)
+	result := make([]DeviceAnnouncement, 0, len(devices))
+	for _, a := range devices {
+		result = append(result, a)
 	}
-
+	sort.Slice(result, func(i, j int) bool { return result[i].DeviceID < result[j].DeviceID })
 	return result, nil
 }
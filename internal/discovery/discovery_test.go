@@ -2,9 +2,13 @@ package discovery
 
 import (
 	"context"
+	"net"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/hashicorp/mdns"
 )
 
 const (
@@ -184,3 +188,76 @@ func TestDiscoveryBrowseTimeout(t *testing.T) {
 		t.Fatalf("Browse failed with short timeout: %v", err)
 	}
 }
+
+func TestAnnouncementTXT(t *testing.T) {
+	a := DeviceAnnouncement{
+		DeviceID:     "device-1",
+		APIEndpoint:  "https://fleet.example.com",
+		Version:      "1.2.3",
+		APIPort:      8080,
+		Capabilities: []string{"daemon", "discovery"},
+	}
+	txt := a.TXT()
+	if len(txt) != 5 || txt[0] != "deviceid=device-1" || txt[4] != "caps=daemon,discovery" {
+		t.Fatalf("unexpected TXT fields: %v", txt)
+	}
+	if got := ParseAnnouncement(txt); !reflect.DeepEqual(got, a) {
+		t.Errorf("round trip = %+v, want %+v", got, a)
+	}
+
+	// Values may contain '=', unknown and malformed fields are ignored
+	got := ParseAnnouncement([]string{"DeviceID=device-2", "api=http://host/?a=b", "foo=bar", "novalue", "port=99999"})
+	if got.DeviceID != "device-2" || got.APIEndpoint != "http://host/?a=b" || got.Version != "" || got.APIPort != 0 {
+		t.Errorf("unexpected announcement: %+v", got)
+	}
+}
+
+func TestBrowseAnnouncements(t *testing.T) {
+	b := NewBrowser("")
+	b.deviceID = "self"
+
+	var queried string
+	b.query = func(params *mdns.QueryParam) error {
+		queried = params.Service
+		entries := []*mdns.ServiceEntry{
+			{Host: "b.local.", AddrV4: net.IPv4(10, 0, 0, 2), Port: 8080, InfoFields: []string{"deviceid=device-b", "version=1.0.0"}},
+			{Host: "a.local.", AddrV4: net.IPv4(10, 0, 0, 1), Port: 8080, InfoFields: []string{"deviceid=device-a", "api=https://fleet"}},
+			// Repeated responses from the same device are merged
+			{Host: "b.local.", AddrV4: net.IPv4(10, 0, 0, 2), Port: 8080, InfoFields: []string{"deviceid=device-b", "version=1.0.1"}},
+			// Our own announcement and other services are skipped
+			{Host: "self.local.", InfoFields: []string{"deviceid=self"}},
+			{Host: "printer.local.", InfoFields: []string{"model=laser"}},
+		}
+		for _, entry := range entries {
+			params.Entries <- entry
+		}
+		return nil
+	}
+
+	announcements, err := b.BrowseAnnouncements(context.Background(), time.Second)
+	if err != nil {
+		t.Fatalf("BrowseAnnouncements failed: %v", err)
+	}
+	if queried != DefaultServiceName {
+		t.Errorf("queried service %q, want %q", queried, DefaultServiceName)
+	}
+	if len(announcements) != 2 {
+		t.Fatalf("expected 2 announcements, got %+v", announcements)
+	}
+
+	a, b2 := announcements[0], announcements[1]
+	if a.DeviceID != "device-a" || a.APIEndpoint != "https://fleet" || a.Host != "a.local." || !a.Addr.Equal(net.IPv4(10, 0, 0, 1)) || a.Port != 8080 {
+		t.Errorf("unexpected announcement: %+v", a)
+	}
+	if b2.DeviceID != "device-b" || b2.Version != "1.0.1" {
+		t.Errorf("unexpected announcement: %+v", b2)
+	}
+
+	ids, err := b.Browse(context.Background(), time.Second)
+	if err != nil {
+		t.Fatalf("Browse failed: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != "device-a" || ids[1] != "device-b" {
+		t.Errorf("unexpected device IDs: %v", ids)
+	}
+}
@@ -0,0 +1,99 @@
+// Package health provides the checks behind the standard
+// grpc.health.v1.Health service, served with connectrpc.com/grpchealth, so
+// that load balancers, Kubernetes probes and tools like grpc-health-probe can
+// check the Connect servers.
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"fleetd.sh/internal/migrations"
+
+	"connectrpc.com/connect"
+	"connectrpc.com/grpchealth"
+)
+
+// Check reports whether something a service depends on is usable
+type Check func(ctx context.Context) error
+
+// Checker is a grpchealth.Checker that runs checks. A service is serving
+// while all of its checks pass and not serving otherwise. The empty service
+// name stands for the server as a whole and runs every check.
+type Checker struct {
+	mu      sync.RWMutex
+	checks  map[string][]Check
+	timeout time.Duration
+}
+
+var _ grpchealth.Checker = (*Checker)(nil)
+
+// NewChecker creates a Checker reporting on the given services, which have
+// no checks until AddCheck is called
+func NewChecker(services ...string) *Checker {
+	c := &Checker{
+		checks:  map[string][]Check{"": nil},
+		timeout: 5 * time.Second,
+	}
+	for _, service := range services {
+		c.checks[service] = nil
+	}
+	return c
+}
+
+// AddCheck adds a check to service, registering the service if needed
+func (c *Checker) AddCheck(service string, check Check) *Checker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[service] = append(c.checks[service], check)
+	return c
+}
+
+// Check implements grpchealth.Checker. Unknown services fail with
+// connect.CodeNotFound, as the health checking protocol requires.
+func (c *Checker) Check(ctx context.Context, req *grpchealth.CheckRequest) (*grpchealth.CheckResponse, error) {
+	c.mu.RLock()
+	var checks []Check
+	if req.Service == "" {
+		for _, cs := range c.checks {
+			checks = append(checks, cs...)
+		}
+	} else {
+		var ok bool
+		checks, ok = c.checks[req.Service]
+		if !ok {
+			c.mu.RUnlock()
+			return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("unknown service %q", req.Service))
+		}
+	}
+	c.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	for _, check := range checks {
+		if err := check(ctx); err != nil {
+			return &grpchealth.CheckResponse{Status: grpchealth.StatusNotServing}, nil
+		}
+	}
+	return &grpchealth.CheckResponse{Status: grpchealth.StatusServing}, nil
+}
+
+// DBCheck fails when db is unreachable or its schema is not fully migrated
+func DBCheck(db *sql.DB) Check {
+	return func(ctx context.Context) error {
+		if err := db.PingContext(ctx); err != nil {
+			return fmt.Errorf("database unreachable: %w", err)
+		}
+		pending, err := migrations.Pending(db)
+		if err != nil {
+			return fmt.Errorf("failed to check migrations: %w", err)
+		}
+		if pending {
+			return fmt.Errorf("database migrations are not complete")
+		}
+		return nil
+	}
+}
@@ -0,0 +1,100 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"fleetd.sh/internal/migrations"
+
+	"connectrpc.com/connect"
+	"connectrpc.com/grpchealth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+const testService = "fleetd.v1.DeviceService"
+
+// JSON names of the statuses in grpchealth's copy of the health proto
+const (
+	serving    = "SERVING_STATUS_SERVING"
+	notServing = "SERVING_STATUS_NOT_SERVING"
+)
+
+func newTestServer(t *testing.T, c *Checker) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle(grpchealth.NewHandler(c))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// check calls Check with the Connect protocol's JSON encoding, returning the
+// status name or the error code
+func check(t *testing.T, server *httptest.Server, service string) string {
+	body := `{"service":"` + service + `"}`
+	resp, err := http.Post(server.URL+"/grpc.health.v1.Health/Check", "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var msg struct {
+		Status string `json:"status"`
+		Code   string `json:"code"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&msg))
+	if resp.StatusCode != http.StatusOK {
+		return msg.Code
+	}
+	return msg.Status
+}
+
+func openDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestHealth_DB(t *testing.T) {
+	db := openDB(t)
+	_, _, err := migrations.MigrateUp(db)
+	require.NoError(t, err)
+
+	server := newTestServer(t, NewChecker().AddCheck(testService, DBCheck(db)))
+	assert.Equal(t, serving, check(t, server, testService))
+	assert.Equal(t, serving, check(t, server, ""))
+
+	require.NoError(t, db.Close())
+	assert.Equal(t, notServing, check(t, server, testService))
+	assert.Equal(t, notServing, check(t, server, ""))
+}
+
+func TestHealth_PendingMigrations(t *testing.T) {
+	db := openDB(t)
+	server := newTestServer(t, NewChecker().AddCheck(testService, DBCheck(db)))
+	assert.Equal(t, notServing, check(t, server, testService))
+
+	_, _, err := migrations.MigrateUp(db)
+	require.NoError(t, err)
+	assert.Equal(t, serving, check(t, server, testService))
+
+	_, _, err = migrations.MigrateDown(db, 1)
+	require.NoError(t, err)
+	assert.Equal(t, notServing, check(t, server, testService))
+}
+
+func TestHealth_UnknownService(t *testing.T) {
+	c := NewChecker(testService)
+	server := newTestServer(t, c)
+	assert.Equal(t, serving, check(t, server, testService))
+	assert.Equal(t, connect.CodeNotFound.String(), check(t, server, "fleetd.v1.Unknown"))
+
+	_, err := c.Check(context.Background(), &grpchealth.CheckRequest{Service: "fleetd.v1.Unknown"})
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+}
@@ -0,0 +1,110 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+
+	// DefaultReadinessTimeout bounds each dependency check, so a hanging
+	// dependency can't stall the orchestrator's probe
+	DefaultReadinessTimeout = 2 * time.Second
+)
+
+// Dependency is something a server needs before it can take traffic
+type Dependency struct {
+	Name  string
+	Check Check
+	// Optional dependencies are reported but don't fail readiness
+	Optional bool
+}
+
+// DependencyStatus is the readiness of one dependency
+type DependencyStatus struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Optional bool   `json:"optional,omitempty"`
+}
+
+// Readiness is the body of a readiness response
+type Readiness struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyStatus `json:"dependencies,omitempty"`
+}
+
+const (
+	statusOK   = "ok"
+	statusDown = "down"
+)
+
+// LivenessHandler answers 200 for as long as the process can serve HTTP. It
+// checks nothing else, so a struggling dependency doesn't get the process
+// restarted.
+func LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, Readiness{Status: statusOK})
+	})
+}
+
+// ReadinessHandler checks deps concurrently, each bounded by timeout, and
+// reports every dependency's status. It answers 503 if any dependency that
+// isn't optional is down.
+func ReadinessHandler(timeout time.Duration, deps ...Dependency) http.Handler {
+	if timeout <= 0 {
+		timeout = DefaultReadinessTimeout
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		resp := Readiness{Status: statusOK, Dependencies: make(map[string]DependencyStatus, len(deps))}
+		var (
+			mu sync.Mutex
+			wg sync.WaitGroup
+		)
+		for _, dep := range deps {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				status := DependencyStatus{Status: statusOK, Optional: dep.Optional}
+				if err := dep.Check(ctx); err != nil {
+					status.Status = statusDown
+					status.Error = err.Error()
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				resp.Dependencies[dep.Name] = status
+				if status.Status == statusDown && !dep.Optional {
+					resp.Status = statusDown
+				}
+			}()
+		}
+		wg.Wait()
+
+		code := http.StatusOK
+		if resp.Status != statusOK {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, resp)
+	})
+}
+
+// Register mounts the liveness and readiness endpoints on mux
+func Register(mux *http.ServeMux, timeout time.Duration, deps ...Dependency) {
+	mux.Handle(LivenessPath, LivenessHandler())
+	mux.Handle(ReadinessPath, ReadinessHandler(timeout, deps...))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
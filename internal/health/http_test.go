@@ -0,0 +1,105 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"fleetd.sh/internal/migrations"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDependency is a dependency whose availability the test controls
+type fakeDependency struct {
+	down atomic.Bool
+	hang atomic.Bool
+}
+
+func (f *fakeDependency) check(ctx context.Context) error {
+	if f.hang.Load() {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	if f.down.Load() {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func get(t *testing.T, server *httptest.Server, path string) (int, Readiness) {
+	resp, err := http.Get(server.URL + path)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	var body Readiness
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	return resp.StatusCode, body
+}
+
+func TestReadiness_Transitions(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	_, _, err = migrations.MigrateUp(db)
+	require.NoError(t, err)
+
+	var cache, influx fakeDependency
+	mux := http.NewServeMux()
+	Register(mux, 50*time.Millisecond,
+		Dependency{Name: "database", Check: DBCheck(db)},
+		Dependency{Name: "cache", Check: cache.check, Optional: true},
+		Dependency{Name: "influxdb", Check: influx.check},
+	)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	status, body := get(t, server, ReadinessPath)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "ok", body.Status)
+	assert.Len(t, body.Dependencies, 3)
+
+	// Optional dependencies are reported but don't fail readiness
+	cache.down.Store(true)
+	status, body = get(t, server, ReadinessPath)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, DependencyStatus{Status: "down", Error: "connection refused", Optional: true}, body.Dependencies["cache"])
+
+	influx.down.Store(true)
+	status, body = get(t, server, ReadinessPath)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, "down", body.Status)
+	assert.Equal(t, "down", body.Dependencies["influxdb"].Status)
+	assert.Equal(t, "ok", body.Dependencies["database"].Status)
+
+	// A hanging dependency times out instead of stalling the probe
+	influx.down.Store(false)
+	influx.hang.Store(true)
+	start := time.Now()
+	status, body = get(t, server, ReadinessPath)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Contains(t, body.Dependencies["influxdb"].Error, "deadline exceeded")
+
+	influx.hang.Store(false)
+	status, _ = get(t, server, ReadinessPath)
+	assert.Equal(t, http.StatusOK, status)
+
+	// Liveness is unaffected by dependencies
+	require.NoError(t, db.Close())
+	status, body = get(t, server, ReadinessPath)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, "down", body.Dependencies["database"].Status)
+	status, body = get(t, server, LivenessPath)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "ok", body.Status)
+}
@@ -0,0 +1,242 @@
+package inventory
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// deviceColumns are the device table columns that can be compared and synced
+var deviceColumns = map[string]bool{
+	"id":          true,
+	"name":        true,
+	"type":        true,
+	"version":     true,
+	"hardware_id": true,
+}
+
+// syncableColumns are the device columns that may be overwritten from an external source
+var syncableColumns = map[string]bool{
+	"name":    true,
+	"type":    true,
+	"version": true,
+}
+
+// Record is a single device entry from an inventory, keyed by a matching field
+type Record struct {
+	Key    string
+	Fields map[string]string
+}
+
+// Mismatch describes a field whose value differs between fleetd and the external inventory
+type Mismatch struct {
+	Key      string
+	Field    string
+	Fleet    string
+	External string
+}
+
+// Report is the result of reconciling fleetd's inventory with an external one
+type Report struct {
+	// Matched lists keys present in both inventories with identical compared fields
+	Matched []string
+	// Mismatched lists field differences for keys present in both inventories
+	Mismatched []Mismatch
+	// FleetOnly lists keys known to fleetd but missing from the external inventory
+	FleetOnly []string
+	// ExternalOnly lists keys in the external inventory that fleetd does not know
+	ExternalOnly []string
+}
+
+// LoadCSV reads an external inventory from CSV. The first row is the header
+// and keyColumn names the column used to match devices.
+func LoadCSV(r io.Reader, keyColumn string) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("empty inventory")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	keyIndex := -1
+	for i, column := range header {
+		header[i] = strings.TrimSpace(column)
+		if header[i] == keyColumn {
+			keyIndex = i
+		}
+	}
+	if keyIndex < 0 {
+		return nil, fmt.Errorf("key column %q not found in header", keyColumn)
+	}
+
+	var records []Record
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read row: %w", err)
+		}
+
+		record := Record{Key: row[keyIndex], Fields: make(map[string]string, len(header))}
+		if record.Key == "" {
+			continue
+		}
+		for i, column := range header {
+			record.Fields[column] = row[i]
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// LoadDevices reads fleetd's device inventory keyed by keyColumn, one of
+// id, name, type, version or hardware_id. Devices with an empty key are skipped.
+func LoadDevices(ctx context.Context, db *sql.DB, keyColumn string) ([]Record, error) {
+	if !deviceColumns[keyColumn] {
+		return nil, fmt.Errorf("unsupported key column %q", keyColumn)
+	}
+
+	rows, err := db.QueryContext(ctx,
+		"SELECT id, name, type, version, COALESCE(hardware_id, '') FROM device")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query devices: %w", err)
+	}
+	defer rows.Close()
+
+	var records []Record
+	for rows.Next() {
+		var id, name, deviceType, version, hardwareID string
+		if err := rows.Scan(&id, &name, &deviceType, &version, &hardwareID); err != nil {
+			return nil, fmt.Errorf("failed to scan device: %w", err)
+		}
+
+		fields := map[string]string{
+			"id":          id,
+			"name":        name,
+			"type":        deviceType,
+			"version":     version,
+			"hardware_id": hardwareID,
+		}
+		if fields[keyColumn] == "" {
+			continue
+		}
+		records = append(records, Record{Key: fields[keyColumn], Fields: fields})
+	}
+
+	return records, rows.Err()
+}
+
+// Reconcile compares fleet and external inventories on the given fields.
+// Fields missing from an external record are not compared. All lists in the
+// report are sorted by key.
+func Reconcile(fleet, external []Record, fields []string) *Report {
+	externalByKey := make(map[string]Record, len(external))
+	for _, record := range external {
+		externalByKey[record.Key] = record
+	}
+
+	report := &Report{}
+	seen := make(map[string]bool, len(fleet))
+	for _, local := range fleet {
+		seen[local.Key] = true
+
+		remote, ok := externalByKey[local.Key]
+		if !ok {
+			report.FleetOnly = append(report.FleetOnly, local.Key)
+			continue
+		}
+
+		matched := true
+		for _, field := range fields {
+			value, ok := remote.Fields[field]
+			if !ok || value == local.Fields[field] {
+				continue
+			}
+			matched = false
+			report.Mismatched = append(report.Mismatched, Mismatch{
+				Key:      local.Key,
+				Field:    field,
+				Fleet:    local.Fields[field],
+				External: value,
+			})
+		}
+		if matched {
+			report.Matched = append(report.Matched, local.Key)
+		}
+	}
+
+	for _, record := range external {
+		if !seen[record.Key] {
+			report.ExternalOnly = append(report.ExternalOnly, record.Key)
+		}
+	}
+
+	sort.Strings(report.Matched)
+	sort.Strings(report.FleetOnly)
+	sort.Strings(report.ExternalOnly)
+	sort.SliceStable(report.Mismatched, func(i, j int) bool {
+		if report.Mismatched[i].Key != report.Mismatched[j].Key {
+			return report.Mismatched[i].Key < report.Mismatched[j].Key
+		}
+		return report.Mismatched[i].Field < report.Mismatched[j].Field
+	})
+
+	return report
+}
+
+// Sync copies the external values of the selected fields onto fleetd devices
+// for every mismatch in the report. Only name, type and version can be synced.
+// It returns the number of updated fields.
+func Sync(ctx context.Context, db *sql.DB, report *Report, keyColumn string, fields []string) (int, error) {
+	if !deviceColumns[keyColumn] {
+		return 0, fmt.Errorf("unsupported key column %q", keyColumn)
+	}
+
+	selected := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if !syncableColumns[field] {
+			return 0, fmt.Errorf("field %q cannot be synced", field)
+		}
+		selected[field] = true
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	updated := 0
+	for _, mismatch := range report.Mismatched {
+		if !selected[mismatch.Field] {
+			continue
+		}
+
+		// Column names come from the allowlists above, never from input
+		_, err := tx.ExecContext(ctx,
+			fmt.Sprintf("UPDATE device SET %s = ?, updated_at = CURRENT_TIMESTAMP WHERE %s = ?", mismatch.Field, keyColumn),
+			mismatch.External, mismatch.Key)
+		if err != nil {
+			return 0, fmt.Errorf("failed to sync %s for %s: %w", mismatch.Field, mismatch.Key, err)
+		}
+		updated++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return updated, nil
+}
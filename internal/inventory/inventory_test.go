@@ -0,0 +1,97 @@
+package inventory
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"fleetd.sh/internal/migrations"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func setupDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	_, _, err = migrations.MigrateUp(db)
+	require.NoError(t, err)
+
+	devices := []struct{ id, name, deviceType, version, hardwareID string }{
+		{"dev-1", "kiosk-1", "raspberry-pi", "1.0.0", "hw-1"},
+		{"dev-2", "kiosk-2", "raspberry-pi", "1.0.0", "hw-2"},
+		{"dev-3", "kiosk-3", "raspberry-pi", "1.0.0", "hw-3"},
+	}
+	for _, d := range devices {
+		_, err := db.Exec(
+			`INSERT INTO device (id, name, type, version, api_key, hardware_id) VALUES (?, ?, ?, ?, 'key', ?)`,
+			d.id, d.name, d.deviceType, d.version, d.hardwareID)
+		require.NoError(t, err)
+	}
+
+	return db
+}
+
+const externalCSV = `hardware_id,name,type,location
+hw-1,kiosk-1,raspberry-pi,store-1
+hw-2,lobby-display,raspberry-pi,store-2
+hw-4,kiosk-4,raspberry-pi,store-4
+`
+
+func TestReconcile(t *testing.T) {
+	db := setupDB(t)
+	ctx := context.Background()
+
+	fleet, err := LoadDevices(ctx, db, "hardware_id")
+	require.NoError(t, err)
+	require.Len(t, fleet, 3)
+
+	external, err := LoadCSV(strings.NewReader(externalCSV), "hardware_id")
+	require.NoError(t, err)
+	require.Len(t, external, 3)
+
+	report := Reconcile(fleet, external, []string{"name", "type", "version"})
+
+	assert.Equal(t, []string{"hw-1"}, report.Matched)
+	assert.Equal(t, []Mismatch{{Key: "hw-2", Field: "name", Fleet: "kiosk-2", External: "lobby-display"}}, report.Mismatched)
+	assert.Equal(t, []string{"hw-3"}, report.FleetOnly)
+	assert.Equal(t, []string{"hw-4"}, report.ExternalOnly)
+}
+
+func TestSync(t *testing.T) {
+	db := setupDB(t)
+	ctx := context.Background()
+
+	fleet, err := LoadDevices(ctx, db, "hardware_id")
+	require.NoError(t, err)
+	external, err := LoadCSV(strings.NewReader(externalCSV), "hardware_id")
+	require.NoError(t, err)
+
+	report := Reconcile(fleet, external, []string{"name"})
+
+	_, err = Sync(ctx, db, report, "hardware_id", []string{"hardware_id"})
+	assert.Error(t, err, "key columns must not be syncable")
+
+	updated, err := Sync(ctx, db, report, "hardware_id", []string{"name"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, updated)
+
+	var name string
+	require.NoError(t, db.QueryRow("SELECT name FROM device WHERE id = 'dev-2'").Scan(&name))
+	assert.Equal(t, "lobby-display", name)
+
+	fleet, err = LoadDevices(ctx, db, "hardware_id")
+	require.NoError(t, err)
+	report = Reconcile(fleet, external, []string{"name"})
+	assert.Empty(t, report.Mismatched)
+}
+
+func TestLoadCSVMissingKeyColumn(t *testing.T) {
+	_, err := LoadCSV(strings.NewReader("name,type\nkiosk-1,raspberry-pi\n"), "hardware_id")
+	assert.Error(t, err)
+}
@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"connectrpc.com/connect"
+	"fleetd.sh/internal/config"
+	"github.com/google/uuid"
+)
+
+const (
+	// RequestIDHeader carries the request ID used to correlate access log entries
+	RequestIDHeader = "X-Request-ID"
+	// DeviceIDHeader identifies the calling device
+	DeviceIDHeader = "X-Device-ID"
+)
+
+// AccessLogConfig configures access logging for a service
+type AccessLogConfig struct {
+	Service string       // Service name attached to every entry
+	Level   slog.Level   // Level at which successful requests are logged
+	Logger  *slog.Logger // Destination logger, defaults to slog.Default()
+}
+
+// AccessLogConfigFromEnv builds an AccessLogConfig for service, reading the
+// level from FLEETD_ACCESS_LOG_LEVEL (debug, info, warn or error)
+func AccessLogConfigFromEnv(service string) AccessLogConfig {
+	cfg := AccessLogConfig{Service: service, Level: slog.LevelInfo}
+
+	value := config.GetStringFromEnv("FLEETD_ACCESS_LOG_LEVEL", "info")
+	if err := cfg.Level.UnmarshalText([]byte(value)); err != nil {
+		slog.With("key", "FLEETD_ACCESS_LOG_LEVEL").With("value", value).With("error", err).Error("error parsing log level, using default value")
+		cfg.Level = slog.LevelInfo
+	}
+	return cfg
+}
+
+// AccessLogInterceptor writes one structured log entry per handled RPC
+type AccessLogInterceptor struct {
+	cfg AccessLogConfig
+}
+
+// NewAccessLogInterceptor creates a new AccessLogInterceptor
+func NewAccessLogInterceptor(cfg AccessLogConfig) *AccessLogInterceptor {
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	return &AccessLogInterceptor{cfg: cfg}
+}
+
+// WrapUnary implements connect.Interceptor
+func (i *AccessLogInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+
+		start := time.Now()
+		requestID := ensureRequestID(req.Header().Get(RequestIDHeader))
+		resp, err := next(ctx, req)
+
+		if resp != nil {
+			resp.Header().Set(RequestIDHeader, requestID)
+		}
+		i.log(ctx, req.Spec().Procedure, req.HTTPMethod(), req.Peer().Addr,
+			req.Header().Get(DeviceIDHeader), requestID, start, err)
+		return resp, err
+	}
+}
+
+// WrapStreamingClient implements connect.Interceptor
+func (i *AccessLogInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+// WrapStreamingHandler implements connect.Interceptor
+func (i *AccessLogInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		start := time.Now()
+		requestID := ensureRequestID(conn.RequestHeader().Get(RequestIDHeader))
+		conn.ResponseHeader().Set(RequestIDHeader, requestID)
+
+		err := next(ctx, conn)
+
+		i.log(ctx, conn.Spec().Procedure, "POST", conn.Peer().Addr,
+			conn.RequestHeader().Get(DeviceIDHeader), requestID, start, err)
+		return err
+	}
+}
+
+func (i *AccessLogInterceptor) log(ctx context.Context, procedure, method, remoteAddr, principal, requestID string, start time.Time, err error) {
+	code := "ok"
+	level := i.cfg.Level
+	if err != nil {
+		code = connect.CodeOf(err).String()
+		// Failed requests are never logged below warn
+		if level < slog.LevelWarn {
+			level = slog.LevelWarn
+		}
+	}
+	if principal == "" {
+		principal = "anonymous"
+	}
+
+	i.cfg.Logger.LogAttrs(ctx, level, "access",
+		slog.String("service", i.cfg.Service),
+		slog.String("method", method),
+		slog.String("procedure", procedure),
+		slog.String("code", code),
+		slog.Duration("duration", time.Since(start)),
+		slog.String("principal", principal),
+		slog.String("request_id", requestID),
+		slog.String("remote_addr", remoteAddr),
+	)
+}
+
+func ensureRequestID(requestID string) string {
+	if requestID != "" {
+		return requestID
+	}
+	return uuid.New().String()
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAccessLog(level, loggerLevel slog.Level) (*AccessLogInterceptor, *bytes.Buffer) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: loggerLevel}))
+	return NewAccessLogInterceptor(AccessLogConfig{
+		Service: "device",
+		Level:   level,
+		Logger:  logger,
+	}), &buf
+}
+
+func decodeEntries(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestAccessLog_UnaryEntry(t *testing.T) {
+	interceptor, buf := newTestAccessLog(slog.LevelInfo, slog.LevelInfo)
+
+	handler := interceptor.WrapUnary(func(_ context.Context, _ connect.AnyRequest) (connect.AnyResponse, error) {
+		data := "ok"
+		return connect.NewResponse(&data), nil
+	})
+
+	req := connect.NewRequest(&struct{}{})
+	req.Header().Set(RequestIDHeader, "req-123")
+	req.Header().Set(DeviceIDHeader, "device-1")
+
+	resp, err := handler(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "req-123", resp.Header().Get(RequestIDHeader))
+
+	entries := decodeEntries(t, buf)
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	assert.Equal(t, "access", entry["msg"])
+	assert.Equal(t, "INFO", entry["level"])
+	assert.Equal(t, "device", entry["service"])
+	assert.Equal(t, "ok", entry["code"])
+	assert.Equal(t, "device-1", entry["principal"])
+	assert.Equal(t, "req-123", entry["request_id"])
+	assert.Contains(t, entry, "procedure")
+	assert.Contains(t, entry, "method")
+	assert.Contains(t, entry, "duration")
+}
+
+func TestAccessLog_ErrorEntry(t *testing.T) {
+	interceptor, buf := newTestAccessLog(slog.LevelInfo, slog.LevelInfo)
+
+	handler := interceptor.WrapUnary(func(_ context.Context, _ connect.AnyRequest) (connect.AnyResponse, error) {
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("device not found"))
+	})
+
+	_, err := handler(context.Background(), connect.NewRequest(&struct{}{}))
+	require.Error(t, err)
+
+	entries := decodeEntries(t, buf)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "WARN", entries[0]["level"])
+	assert.Equal(t, "not_found", entries[0]["code"])
+	assert.Equal(t, "anonymous", entries[0]["principal"])
+	assert.NotEmpty(t, entries[0]["request_id"])
+}
+
+func TestAccessLog_Level(t *testing.T) {
+	// Entries at debug are dropped by an info logger
+	interceptor, buf := newTestAccessLog(slog.LevelDebug, slog.LevelInfo)
+
+	handler := interceptor.WrapUnary(func(_ context.Context, _ connect.AnyRequest) (connect.AnyResponse, error) {
+		data := "ok"
+		return connect.NewResponse(&data), nil
+	})
+
+	_, err := handler(context.Background(), connect.NewRequest(&struct{}{}))
+	require.NoError(t, err)
+	assert.Empty(t, buf.String())
+
+	t.Setenv("FLEETD_ACCESS_LOG_LEVEL", "debug")
+	assert.Equal(t, slog.LevelDebug, AccessLogConfigFromEnv("device").Level)
+
+	t.Setenv("FLEETD_ACCESS_LOG_LEVEL", "bogus")
+	assert.Equal(t, slog.LevelInfo, AccessLogConfigFromEnv("device").Level)
+}
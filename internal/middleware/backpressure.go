@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"connectrpc.com/connect"
+)
+
+const (
+	// RetryAfterHeader tells a rejected client how many seconds to wait before retrying
+	RetryAfterHeader = "Retry-After"
+	// SuggestedIntervalHeader tells a client how many seconds to wait between reports
+	SuggestedIntervalHeader = "X-Suggested-Interval"
+)
+
+// BackpressureConfig configures when the server asks clients to slow down
+type BackpressureConfig struct {
+	SoftLimit         int           // In-flight requests above which clients are asked to report less often
+	HardLimit         int           // In-flight requests above which requests are rejected
+	RetryAfter        time.Duration // Wait suggested to rejected clients
+	SuggestedInterval time.Duration // Reporting interval suggested while above the soft limit
+}
+
+// DefaultBackpressureConfig returns a BackpressureConfig with default values
+func DefaultBackpressureConfig() BackpressureConfig {
+	return BackpressureConfig{
+		SoftLimit:         256,
+		HardLimit:         1024,
+		RetryAfter:        30 * time.Second,
+		SuggestedInterval: 5 * time.Minute,
+	}
+}
+
+// Backpressure is a connect.Interceptor that sheds load and hints clients to
+// back off based on the number of requests in flight. Above the soft limit
+// responses carry a suggested reporting interval; above the hard limit
+// requests fail with ResourceExhausted and a retry-after hint.
+type Backpressure struct {
+	cfg      BackpressureConfig
+	inFlight atomic.Int64
+}
+
+// NewBackpressure creates a new Backpressure interceptor
+func NewBackpressure(cfg BackpressureConfig) *Backpressure {
+	return &Backpressure{cfg: cfg}
+}
+
+// InFlight returns the number of requests currently being handled
+func (b *Backpressure) InFlight() int {
+	return int(b.inFlight.Load())
+}
+
+// WrapUnary implements connect.Interceptor
+func (b *Backpressure) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+
+		inFlight := b.inFlight.Add(1)
+		defer b.inFlight.Add(-1)
+
+		if b.cfg.HardLimit > 0 && inFlight > int64(b.cfg.HardLimit) {
+			return nil, b.overloaded()
+		}
+
+		resp, err := next(ctx, req)
+		if resp != nil && b.cfg.SoftLimit > 0 && inFlight > int64(b.cfg.SoftLimit) {
+			setSeconds(resp.Header(), SuggestedIntervalHeader, b.cfg.SuggestedInterval)
+		}
+		return resp, err
+	}
+}
+
+// WrapStreamingClient implements connect.Interceptor
+func (b *Backpressure) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+// WrapStreamingHandler implements connect.Interceptor
+func (b *Backpressure) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		inFlight := b.inFlight.Add(1)
+		defer b.inFlight.Add(-1)
+
+		if b.cfg.HardLimit > 0 && inFlight > int64(b.cfg.HardLimit) {
+			return b.overloaded()
+		}
+		if b.cfg.SoftLimit > 0 && inFlight > int64(b.cfg.SoftLimit) {
+			setSeconds(conn.ResponseHeader(), SuggestedIntervalHeader, b.cfg.SuggestedInterval)
+		}
+		return next(ctx, conn)
+	}
+}
+
+func (b *Backpressure) overloaded() error {
+	err := connect.NewError(connect.CodeResourceExhausted, errors.New("server overloaded"))
+	setSeconds(err.Meta(), RetryAfterHeader, b.cfg.RetryAfter)
+	setSeconds(err.Meta(), SuggestedIntervalHeader, b.cfg.SuggestedInterval)
+	return err
+}
+
+func setSeconds(header http.Header, key string, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	seconds := int64((d + time.Second - 1) / time.Second)
+	header.Set(key, strconv.FormatInt(seconds, 10))
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackpressure_Hints(t *testing.T) {
+	bp := NewBackpressure(BackpressureConfig{
+		SoftLimit:         1,
+		HardLimit:         2,
+		RetryAfter:        30 * time.Second,
+		SuggestedInterval: 90 * time.Second,
+	})
+
+	release := make(chan struct{})
+	handler := bp.WrapUnary(func(_ context.Context, _ connect.AnyRequest) (connect.AnyResponse, error) {
+		<-release
+		data := "ok"
+		return connect.NewResponse(&data), nil
+	})
+	quick := bp.WrapUnary(func(_ context.Context, _ connect.AnyRequest) (connect.AnyResponse, error) {
+		data := "ok"
+		return connect.NewResponse(&data), nil
+	})
+
+	// Without load no hints are sent
+	resp, err := quick(context.Background(), connect.NewRequest(&struct{}{}))
+	require.NoError(t, err)
+	assert.Empty(t, resp.Header().Get(SuggestedIntervalHeader))
+
+	// Fill up to the hard limit with requests that block
+	var wg sync.WaitGroup
+	responses := make(chan connect.AnyResponse, 2)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := handler(context.Background(), connect.NewRequest(&struct{}{}))
+			if err == nil {
+				responses <- resp
+			}
+		}()
+	}
+	require.Eventually(t, func() bool { return bp.InFlight() == 2 }, time.Second, time.Millisecond)
+
+	// Above the hard limit requests are rejected with retry hints
+	_, err = quick(context.Background(), connect.NewRequest(&struct{}{}))
+	require.Error(t, err)
+	assert.Equal(t, connect.CodeResourceExhausted, connect.CodeOf(err))
+	var connectErr *connect.Error
+	require.ErrorAs(t, err, &connectErr)
+	assert.Equal(t, "30", connectErr.Meta().Get(RetryAfterHeader))
+	assert.Equal(t, "90", connectErr.Meta().Get(SuggestedIntervalHeader))
+
+	close(release)
+	wg.Wait()
+	close(responses)
+
+	// The request that pushed past the soft limit carries a suggested interval
+	var hinted int
+	for resp := range responses {
+		if resp.Header().Get(SuggestedIntervalHeader) == "90" {
+			hinted++
+		}
+	}
+	assert.Equal(t, 1, hinted)
+	assert.Equal(t, 0, bp.InFlight())
+}
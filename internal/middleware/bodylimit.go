@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+
+	"connectrpc.com/connect"
+	"fleetd.sh/internal/config"
+)
+
+// BodyLimitConfig configures the maximum request body size
+type BodyLimitConfig struct {
+	MaxBytes  int64            // Default limit for every endpoint
+	Endpoints map[string]int64 // Per-endpoint limits keyed by procedure, e.g. /fleetd.v1.BinaryService/UploadBinary
+}
+
+// BodyLimitConfigFromEnv builds a BodyLimitConfig, reading the default limit
+// from MAX_BODY_SIZE and the binary upload limit from MAX_UPLOAD_SIZE, both in
+// bytes
+func BodyLimitConfigFromEnv() BodyLimitConfig {
+	return BodyLimitConfig{
+		MaxBytes: int64(config.GetIntFromEnv("MAX_BODY_SIZE", 4<<20)),
+		Endpoints: map[string]int64{
+			"/fleetd.v1.BinaryService/UploadBinary": int64(config.GetIntFromEnv("MAX_UPLOAD_SIZE", 1<<30)),
+		},
+	}
+}
+
+// limit returns the body size limit for path, or zero for no limit
+func (c BodyLimitConfig) limit(path string) int64 {
+	if limit, ok := c.Endpoints[path]; ok {
+		return limit
+	}
+	return c.MaxBytes
+}
+
+// BodyLimit returns HTTP middleware that caps request body sizes. Requests
+// declaring a larger Content-Length are rejected with 413 before the handler
+// runs. Other bodies are cut off at the limit, so handlers never read more
+// than the limit into memory, and the response status becomes 413 once the
+// handler hits it.
+func BodyLimit(cfg BodyLimitConfig) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit := cfg.limit(r.URL.Path)
+			if limit <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if r.ContentLength > limit {
+				w.Header().Set("Connection", "close")
+				WriteError(w, http.StatusRequestEntityTooLarge, connect.CodeResourceExhausted, "request body too large")
+				return
+			}
+
+			body := &limitedBody{ReadCloser: http.MaxBytesReader(w, r.Body, limit)}
+			r.Body = body
+			next.ServeHTTP(&bodyLimitWriter{ResponseWriter: w, body: body}, r)
+		})
+	}
+}
+
+// limitedBody records whether the handler read past the body size limit
+type limitedBody struct {
+	io.ReadCloser
+	exceeded atomic.Bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		b.exceeded.Store(true)
+	}
+	return n, err
+}
+
+// bodyLimitWriter replaces the handler's status with 413 when the body was
+// too large, whatever error the handler reports for the failed read
+type bodyLimitWriter struct {
+	http.ResponseWriter
+	body        *limitedBody
+	wroteHeader bool
+}
+
+func (w *bodyLimitWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if w.body.exceeded.Load() {
+		code = http.StatusRequestEntityTooLarge
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *bodyLimitWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush implements http.Flusher for streaming handlers
+func (w *bodyLimitWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *bodyLimitWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
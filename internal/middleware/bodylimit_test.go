@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestBodyLimit_ContentLength(t *testing.T) {
+	called := false
+	handler := BodyLimit(BodyLimitConfig{
+		MaxBytes:  16,
+		Endpoints: map[string]int64{"/upload": 1024},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		_, err := io.Copy(io.Discard, r.Body)
+		require.NoError(t, err)
+	}))
+
+	// Over the limit is rejected before the handler runs
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/telemetry", strings.NewReader(strings.Repeat("x", 17))))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.False(t, called)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/telemetry", strings.NewReader(strings.Repeat("x", 16))))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, called)
+
+	// Endpoints can have a larger limit
+	called = false
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/upload", strings.NewReader(strings.Repeat("x", 1000))))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, called)
+}
+
+func TestBodyLimit_UnknownLength(t *testing.T) {
+	var read int64
+	server := httptest.NewServer(BodyLimit(BodyLimitConfig{MaxBytes: 1024})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var err error
+			read, err = io.Copy(io.Discard, r.Body)
+			if err != nil {
+				// Handlers report their own error, but the status is still 413
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			}
+		})))
+	defer server.Close()
+
+	// A streamed body has no Content-Length, so it is cut off at the limit
+	body := io.NopCloser(strings.NewReader(strings.Repeat("x", 1<<20)))
+	req, err := http.NewRequest("POST", server.URL, body)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	assert.Equal(t, int64(1024), read)
+}
+
+func TestBodyLimit_ConnectHandler(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/fleetd.v1.TestService/Echo", connect.NewUnaryHandler(
+		"/fleetd.v1.TestService/Echo",
+		func(ctx context.Context, req *connect.Request[wrapperspb.BytesValue]) (*connect.Response[wrapperspb.BytesValue], error) {
+			return connect.NewResponse(req.Msg), nil
+		},
+	))
+	server := httptest.NewServer(BodyLimit(BodyLimitConfig{MaxBytes: 1024})(mux))
+	defer server.Close()
+
+	client := connect.NewClient[wrapperspb.BytesValue, wrapperspb.BytesValue](
+		http.DefaultClient, server.URL+"/fleetd.v1.TestService/Echo")
+
+	_, err := client.CallUnary(context.Background(), connect.NewRequest(wrapperspb.Bytes(make([]byte, 100))))
+	require.NoError(t, err)
+
+	_, err = client.CallUnary(context.Background(), connect.NewRequest(wrapperspb.Bytes(bytes.Repeat([]byte("x"), 4096))))
+	require.Error(t, err)
+	assert.Equal(t, connect.CodeResourceExhausted, connect.CodeOf(err))
+	assert.Contains(t, err.Error(), "request body too large")
+}
+
+func TestBodyLimitConfigFromEnv(t *testing.T) {
+	t.Setenv("MAX_BODY_SIZE", "1024")
+
+	cfg := BodyLimitConfigFromEnv()
+	assert.Equal(t, int64(1024), cfg.limit("/fleetd.v1.DeviceService/Heartbeat"))
+	assert.Equal(t, int64(1<<30), cfg.limit("/fleetd.v1.BinaryService/UploadBinary"))
+}
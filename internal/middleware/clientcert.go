@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+
+	"connectrpc.com/connect"
+)
+
+type deviceIDKey struct{}
+
+// DeviceIDFromContext returns the device identity established by a verified
+// client certificate
+func DeviceIDFromContext(ctx context.Context) (string, bool) {
+	deviceID, ok := ctx.Value(deviceIDKey{}).(string)
+	return deviceID, ok
+}
+
+// ClientCertTLSConfig returns a TLS config that verifies client certificates
+// against the fleet CA. Clients without a certificate are still accepted so
+// they can authenticate with an API key instead.
+func ClientCertTLSConfig(caPool *x509.CertPool) *tls.Config {
+	return &tls.Config{
+		ClientAuth: tls.VerifyClientCertIfGiven,
+		ClientCAs:  caPool,
+		MinVersion: tls.VersionTLS12,
+	}
+}
+
+// ClientCertAuth returns HTTP middleware that authenticates devices by their
+// verified client certificate. The device ID is the certificate's common
+// name, or its first DNS name if the common name is empty. The identity is
+// stored in the request context and replaces any X-Device-ID header sent by
+// the client.
+func ClientCertAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Only chains verified during the handshake are trusted
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		deviceID := certDeviceID(r.TLS.VerifiedChains[0][0])
+		if deviceID == "" {
+			WriteError(w, http.StatusUnauthorized, connect.CodeUnauthenticated, "client certificate has no device identity")
+			return
+		}
+
+		r = r.WithContext(context.WithValue(r.Context(), deviceIDKey{}, deviceID))
+		r.Header.Set(DeviceIDHeader, deviceID)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func certDeviceID(cert *x509.Certificate) string {
+	if cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName
+	}
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames[0]
+	}
+	return ""
+}
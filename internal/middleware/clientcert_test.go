@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) issue(t *testing.T, commonName string, notAfter time.Time) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-2 * time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestClientCertAuth(t *testing.T) {
+	fleetCA := newTestCA(t, "fleet-ca")
+	otherCA := newTestCA(t, "other-ca")
+
+	pool := x509.NewCertPool()
+	pool.AddCert(fleetCA.cert)
+
+	server := httptest.NewUnstartedServer(ClientCertAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deviceID, ok := DeviceIDFromContext(r.Context())
+		if !ok {
+			deviceID = "anonymous"
+		}
+		io.WriteString(w, deviceID+" "+r.Header.Get(DeviceIDHeader))
+	})))
+	server.TLS = ClientCertTLSConfig(pool)
+	server.StartTLS()
+	defer server.Close()
+
+	request := func(cert *tls.Certificate) (string, error) {
+		transport := server.Client().Transport.(*http.Transport).Clone()
+		if cert != nil {
+			// Always present the certificate, even if the server does not list its CA
+			transport.TLSClientConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+				return cert, nil
+			}
+		}
+		client := &http.Client{Transport: transport}
+
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		req.Header.Set(DeviceIDHeader, "spoofed")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body), nil
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		cert := fleetCA.issue(t, "device-1", time.Now().Add(time.Hour))
+		body, err := request(&cert)
+		require.NoError(t, err)
+		assert.Equal(t, "device-1 device-1", body)
+	})
+
+	t.Run("wrong CA", func(t *testing.T) {
+		cert := otherCA.issue(t, "device-1", time.Now().Add(time.Hour))
+		_, err := request(&cert)
+		assert.Error(t, err)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		cert := fleetCA.issue(t, "device-1", time.Now().Add(-time.Hour))
+		_, err := request(&cert)
+		assert.Error(t, err)
+	})
+
+	t.Run("no certificate", func(t *testing.T) {
+		// Devices without a certificate fall through to API key auth
+		body, err := request(nil)
+		require.NoError(t, err)
+		assert.Equal(t, "anonymous spoofed", body)
+	})
+}
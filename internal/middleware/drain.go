@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// ErrDraining is the readiness error of a server that is shutting down
+var ErrDraining = errors.New("server is draining")
+
+// Drainer counts in-flight HTTP requests so a server can be drained before
+// it shuts down: once Drain is called the server reports itself not ready,
+// so load balancers stop routing to it, while the requests it already
+// accepted run to completion.
+type Drainer struct {
+	mu       sync.Mutex
+	inFlight int
+	draining bool
+	idle     chan struct{} // Closed when the last request finishes while draining
+}
+
+// NewDrainer creates a new Drainer
+func NewDrainer() *Drainer {
+	return &Drainer{}
+}
+
+// InFlight returns the number of requests currently being handled
+func (d *Drainer) InFlight() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.inFlight
+}
+
+// Ready fails once the server is draining. It is meant to be a readiness
+// check.
+func (d *Drainer) Ready(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining {
+		return ErrDraining
+	}
+	return nil
+}
+
+// Drain marks the server not ready and waits until no requests are in
+// flight or ctx is done. Requests that still arrive while draining are
+// served and waited for too.
+func (d *Drainer) Drain(ctx context.Context) error {
+	d.mu.Lock()
+	d.draining = true
+	if d.inFlight == 0 {
+		d.mu.Unlock()
+		return nil
+	}
+	if d.idle == nil {
+		d.idle = make(chan struct{})
+	}
+	idle := d.idle
+	d.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *Drainer) start() {
+	d.mu.Lock()
+	d.inFlight++
+	d.mu.Unlock()
+}
+
+func (d *Drainer) done() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.inFlight--
+	if d.inFlight == 0 && d.idle != nil {
+		close(d.idle)
+		d.idle = nil
+	}
+}
+
+// DrainMiddleware counts the requests handled by next in d
+func DrainMiddleware(d *Drainer) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			d.start()
+			defer d.done()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"fleetd.sh/internal/health"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrain_SIGTERM(t *testing.T) {
+	drainer := NewDrainer()
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "done")
+	})
+	health.Register(mux, time.Second, health.Dependency{Name: "server", Check: drainer.Ready})
+	server := httptest.NewServer(DrainMiddleware(drainer)(mux))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + health.ReadinessPath)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	type result struct {
+		status int
+		body   string
+		err    error
+	}
+	slow := make(chan result, 1)
+	go func() {
+		resp, err := http.Get(server.URL + "/slow")
+		if err != nil {
+			slow <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		slow <- result{status: resp.StatusCode, body: string(body), err: err}
+	}()
+	<-started
+	assert.Equal(t, 1, drainer.InFlight())
+
+	// The shutdown sequence a server runs on SIGTERM
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	drained := make(chan error, 1)
+	go func() {
+		<-sigCh
+		drained <- drainer.Drain(context.Background())
+	}()
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+
+	require.Eventually(t, func() bool { return drainer.Ready(context.Background()) != nil }, time.Second, time.Millisecond)
+	resp, err = http.Get(server.URL + health.ReadinessPath)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	select {
+	case <-drained:
+		t.Fatal("drained while a request was in flight")
+	default:
+	}
+
+	close(release)
+	r := <-slow
+	require.NoError(t, r.err)
+	assert.Equal(t, http.StatusOK, r.status)
+	assert.Equal(t, "done", r.body)
+
+	select {
+	case err := <-drained:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("drain did not finish")
+	}
+	assert.Equal(t, 0, drainer.InFlight())
+}
+
+func TestDrain_Timeout(t *testing.T) {
+	drainer := NewDrainer()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(DrainMiddleware(drainer)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})))
+	defer server.Close()
+	defer close(release)
+
+	go http.Get(server.URL)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, drainer.Drain(ctx), context.DeadlineExceeded)
+	assert.ErrorIs(t, drainer.Ready(context.Background()), ErrDraining)
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"connectrpc.com/connect"
+)
+
+// errorResponse is the JSON body of a Connect protocol error
+type errorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// WriteError rejects an HTTP request with a Connect protocol error body, so
+// requests turned away by middleware fail with the same code and message a
+// handler would have returned. status is sent as is; Connect clients read the
+// code from the body, other clients fall back to the status.
+func WriteError(w http.ResponseWriter, status int, code connect.Code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorResponse{Code: code.String(), Message: message})
+}
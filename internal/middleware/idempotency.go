@@ -0,0 +1,249 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+	"fleetd.sh/internal/config"
+)
+
+const (
+	// IdempotencyKeyHeader carries a client-chosen key identifying a request.
+	// Repeating a request with the same key returns the first response
+	// instead of running the request again.
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// IdempotentReplayedHeader is set on responses replayed from the cache
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// IdempotencyConfig configures the idempotency cache
+type IdempotencyConfig struct {
+	TTL time.Duration // How long a response is replayed for its key
+}
+
+// IdempotencyConfigFromEnv reads the response TTL from IDEMPOTENCY_TTL,
+// defaulting to 24 hours
+func IdempotencyConfigFromEnv() IdempotencyConfig {
+	return IdempotencyConfig{
+		TTL: config.GetDurationFromEnv("IDEMPOTENCY_TTL", 24*time.Hour),
+	}
+}
+
+// IdempotencyCache remembers the responses to requests sent with an
+// Idempotency-Key header. Keys are scoped to the caller, so two devices
+// choosing the same key do not see each other's responses.
+type IdempotencyCache struct {
+	mu            sync.Mutex
+	entries       map[string]*idempotencyEntry
+	ttl           time.Duration
+	now           func() time.Time
+	cleanupTicker *time.Ticker
+	done          chan struct{}
+}
+
+type idempotencyEntry struct {
+	fingerprint [sha256.Size]byte // Identifies the request the key was first used with
+	done        chan struct{}     // Closed once the first request has finished
+	response    *recordedResponse // Nil until a successful response is stored
+	expires     time.Time
+}
+
+type recordedResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// NewIdempotencyCache creates a new IdempotencyCache
+func NewIdempotencyCache(cfg IdempotencyConfig) *IdempotencyCache {
+	if cfg.TTL <= 0 {
+		cfg.TTL = 24 * time.Hour
+	}
+	c := &IdempotencyCache{
+		entries:       make(map[string]*idempotencyEntry),
+		ttl:           cfg.TTL,
+		now:           time.Now,
+		cleanupTicker: time.NewTicker(min(cfg.TTL, time.Hour)),
+		done:          make(chan struct{}),
+	}
+
+	go c.cleanupLoop()
+	return c
+}
+
+func (c *IdempotencyCache) Stop() {
+	close(c.done)
+	c.cleanupTicker.Stop()
+}
+
+func (c *IdempotencyCache) cleanupLoop() {
+	for {
+		select {
+		case <-c.cleanupTicker.C:
+			c.cleanup()
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (c *IdempotencyCache) cleanup() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for key, entry := range c.entries {
+		if entry.response != nil && now.After(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// claim returns the entry for key. If there is none, a new one is created
+// and owned is true: the caller must run the request and call finish.
+func (c *IdempotencyCache) claim(key string, fingerprint [sha256.Size]byte) (entry *idempotencyEntry, owned bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.entries[key]; ok && (entry.response == nil || c.now().Before(entry.expires)) {
+		return entry, false
+	}
+	entry = &idempotencyEntry{fingerprint: fingerprint, done: make(chan struct{})}
+	c.entries[key] = entry
+	return entry, true
+}
+
+// finish stores the response to an owned entry's request. Failed requests
+// are forgotten so that the client can retry them with the same key.
+func (c *IdempotencyCache) finish(key string, entry *idempotencyEntry, resp *recordedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if resp != nil && resp.status >= 200 && resp.status < 300 {
+		entry.response = resp
+		entry.expires = c.now().Add(c.ttl)
+	} else {
+		delete(c.entries, key)
+	}
+	close(entry.done)
+}
+
+// IdempotencyMiddleware returns HTTP middleware that makes POST requests
+// carrying an Idempotency-Key header safe to retry. The first successful
+// response for a key is replayed to later requests with the same key, and
+// duplicates arriving while the first request is running wait for it. Reusing
+// a key for a different request is rejected. Streaming requests are passed
+// through unchanged.
+func IdempotencyMiddleware(c *IdempotencyCache) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || r.Method != http.MethodPost || isStreamingRequest(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				WriteError(w, http.StatusBadRequest, connect.CodeInvalidArgument, "failed to read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			hash := sha256.New()
+			hash.Write([]byte(r.URL.Path + "\n"))
+			hash.Write(body)
+			var fingerprint [sha256.Size]byte
+			copy(fingerprint[:], hash.Sum(nil))
+
+			scoped := deviceClientKey(r.Context(), r.Header, r.RemoteAddr) + " " + key
+			for {
+				entry, owned := c.claim(scoped, fingerprint)
+				if owned {
+					c.serve(scoped, entry, next, w, r)
+					return
+				}
+
+				if entry.fingerprint != fingerprint {
+					WriteError(w, http.StatusUnprocessableEntity, connect.CodeInvalidArgument,
+						"idempotency key was already used for a different request")
+					return
+				}
+
+				select {
+				case <-entry.done:
+				case <-r.Context().Done():
+					return
+				}
+				if entry.response != nil {
+					entry.response.replay(w)
+					return
+				}
+				// The first request failed; try to run this one instead
+			}
+		})
+	}
+}
+
+// serve runs the first request for an entry, recording its response
+func (c *IdempotencyCache) serve(key string, entry *idempotencyEntry, next http.Handler, w http.ResponseWriter, r *http.Request) {
+	rec := &responseRecorder{ResponseWriter: w}
+	defer func() {
+		if rec.wroteHeader {
+			c.finish(key, entry, &recordedResponse{status: rec.status, header: rec.header, body: rec.body.Bytes()})
+		} else {
+			c.finish(key, entry, nil)
+		}
+	}()
+	next.ServeHTTP(rec, r)
+}
+
+func (resp *recordedResponse) replay(w http.ResponseWriter) {
+	for name, values := range resp.header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(resp.status)
+	w.Write(resp.body)
+}
+
+// isStreamingRequest reports whether r is a Connect, gRPC or gRPC-Web stream
+func isStreamingRequest(r *http.Request) bool {
+	contentType := r.Header.Get("Content-Type")
+	return strings.HasPrefix(contentType, "application/connect+") ||
+		strings.HasPrefix(contentType, "application/grpc")
+}
+
+// responseRecorder passes a response through while keeping a copy of it
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	header      http.Header
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.wroteHeader {
+		return
+	}
+	r.wroteHeader = true
+	r.status = status
+	r.header = r.ResponseWriter.Header().Clone()
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// idempotencyTestServer serves a Create procedure that makes a new resource
+// named after its request on every call, failing names starting with "fail"
+type idempotencyTestServer struct {
+	*httptest.Server
+	cache   *IdempotencyCache
+	created atomic.Int32
+	client  *connect.Client[wrapperspb.StringValue, wrapperspb.StringValue]
+}
+
+func newIdempotencyTestServer(t *testing.T, handlerDelay time.Duration) *idempotencyTestServer {
+	s := &idempotencyTestServer{cache: NewIdempotencyCache(IdempotencyConfig{TTL: time.Hour})}
+	t.Cleanup(s.cache.Stop)
+
+	mux := http.NewServeMux()
+	mux.Handle("/fleetd.v1.TestService/Create", connect.NewUnaryHandler(
+		"/fleetd.v1.TestService/Create",
+		func(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+			time.Sleep(handlerDelay)
+			if strings.HasPrefix(req.Msg.Value, "fail") {
+				return nil, connect.NewError(connect.CodeUnavailable, errors.New("try again"))
+			}
+			n := s.created.Add(1)
+			return connect.NewResponse(wrapperspb.String(fmt.Sprintf("%s-%d", req.Msg.Value, n))), nil
+		},
+	))
+	s.Server = httptest.NewServer(IdempotencyMiddleware(s.cache)(mux))
+	t.Cleanup(s.Close)
+
+	s.client = connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](
+		http.DefaultClient, s.URL+"/fleetd.v1.TestService/Create")
+	return s
+}
+
+func (s *idempotencyTestServer) create(name, key, apiKey string) (*connect.Response[wrapperspb.StringValue], error) {
+	req := connect.NewRequest(wrapperspb.String(name))
+	if key != "" {
+		req.Header().Set(IdempotencyKeyHeader, key)
+	}
+	if apiKey != "" {
+		req.Header().Set("X-API-Key", apiKey)
+	}
+	return s.client.CallUnary(context.Background(), req)
+}
+
+func TestIdempotency_SameKey(t *testing.T) {
+	s := newIdempotencyTestServer(t, 0)
+
+	first, err := s.create("fleet", "key-1", "")
+	require.NoError(t, err)
+	second, err := s.create("fleet", "key-1", "")
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(1), s.created.Load())
+	assert.Equal(t, "fleet-1", first.Msg.Value)
+	assert.Equal(t, first.Msg.Value, second.Msg.Value)
+	assert.Empty(t, first.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, "true", second.Header().Get(IdempotentReplayedHeader))
+}
+
+func TestIdempotency_DifferentKeys(t *testing.T) {
+	s := newIdempotencyTestServer(t, 0)
+
+	first, err := s.create("fleet", "key-1", "")
+	require.NoError(t, err)
+	second, err := s.create("fleet", "key-2", "")
+	require.NoError(t, err)
+	third, err := s.create("fleet", "", "")
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(3), s.created.Load())
+	assert.NotEqual(t, first.Msg.Value, second.Msg.Value)
+	assert.NotEqual(t, second.Msg.Value, third.Msg.Value)
+}
+
+func TestIdempotency_ScopedToCaller(t *testing.T) {
+	s := newIdempotencyTestServer(t, 0)
+
+	first, err := s.create("fleet", "key-1", "api-key-a")
+	require.NoError(t, err)
+	second, err := s.create("fleet", "key-1", "api-key-b")
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(2), s.created.Load())
+	assert.NotEqual(t, first.Msg.Value, second.Msg.Value)
+}
+
+func TestIdempotency_KeyReusedForDifferentRequest(t *testing.T) {
+	s := newIdempotencyTestServer(t, 0)
+
+	_, err := s.create("fleet", "key-1", "")
+	require.NoError(t, err)
+	_, err = s.create("other-fleet", "key-1", "")
+	require.Error(t, err)
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	assert.Equal(t, int32(1), s.created.Load())
+}
+
+func TestIdempotency_FailuresAreNotReplayed(t *testing.T) {
+	s := newIdempotencyTestServer(t, 0)
+
+	for range 2 {
+		_, err := s.create("fail", "key-1", "")
+		assert.Equal(t, connect.CodeUnavailable, connect.CodeOf(err))
+	}
+	assert.Equal(t, int32(0), s.created.Load())
+	s.cache.mu.Lock()
+	assert.Empty(t, s.cache.entries)
+	s.cache.mu.Unlock()
+}
+
+func TestIdempotency_ConcurrentDuplicates(t *testing.T) {
+	s := newIdempotencyTestServer(t, 50*time.Millisecond)
+
+	var wg sync.WaitGroup
+	values := make([]string, 5)
+	for i := range values {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := s.create("fleet", "key-1", "")
+			if assert.NoError(t, err) {
+				values[i] = resp.Msg.Value
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), s.created.Load())
+	for _, v := range values {
+		assert.Equal(t, "fleet-1", v)
+	}
+}
+
+func TestIdempotency_Expiry(t *testing.T) {
+	s := newIdempotencyTestServer(t, 0)
+	now := time.Now()
+	s.cache.now = func() time.Time { return now }
+
+	_, err := s.create("fleet", "key-1", "")
+	require.NoError(t, err)
+
+	// Once the TTL has passed the key runs the request again
+	now = now.Add(2 * time.Hour)
+	resp, err := s.create("fleet", "key-1", "")
+	require.NoError(t, err)
+	assert.Equal(t, "fleet-2", resp.Msg.Value)
+
+	s.cache.cleanup()
+	s.cache.mu.Lock()
+	assert.Len(t, s.cache.entries, 1)
+	s.cache.mu.Unlock()
+}
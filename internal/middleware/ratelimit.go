@@ -3,11 +3,13 @@ package middleware
 import (
 	"context"
 	"errors"
+	"net"
 	"net/http"
 	"sync"
 	"time"
 
 	"connectrpc.com/connect"
+	"fleetd.sh/internal/config"
 	"golang.org/x/time/rate"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
@@ -19,21 +21,146 @@ type RateLimiter struct {
 	limiters      map[string]*limiterState
 	rate          rate.Limit
 	burst         int
+	sustained     []RateTier
+	endpoints     map[string]EndpointLimit
 	expiration    time.Duration
 	cleanupTicker *time.Ticker
 	done          chan struct{}
 }
 
 type limiterState struct {
-	limiter  *rate.Limiter
+	limiter  *tieredLimiter
 	lastUsed time.Time
 }
 
+// tieredLimiter enforces several token buckets at once. A request is allowed
+// only if every bucket has a token, and consumes one from each.
+type tieredLimiter struct {
+	mu      sync.Mutex
+	buckets []*rate.Limiter
+}
+
+func newTieredLimiter(r rate.Limit, burst int, sustained []RateTier) *tieredLimiter {
+	l := &tieredLimiter{buckets: []*rate.Limiter{rate.NewLimiter(r, burst)}}
+	for _, tier := range sustained {
+		l.buckets = append(l.buckets, rate.NewLimiter(tier.limit(), tier.Requests))
+	}
+	return l
+}
+
+// Allow reports whether a request may happen now
+func (l *tieredLimiter) Allow() bool {
+	ok, _ := l.allow(time.Now())
+	return ok
+}
+
+// allow takes a token from every bucket if all have one. Otherwise nothing
+// is consumed and it returns how long until every bucket would allow the
+// request, or zero if no wait is long enough.
+func (l *tieredLimiter) allow(now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	reservations := make([]*rate.Reservation, 0, len(l.buckets))
+	allowed := true
+	var wait time.Duration
+	for _, bucket := range l.buckets {
+		r := bucket.ReserveN(now, 1)
+		if !r.OK() {
+			// The bucket can never hold a token, e.g. a zero burst
+			allowed = false
+			continue
+		}
+		reservations = append(reservations, r)
+		if d := r.DelayFrom(now); d > 0 {
+			allowed = false
+			wait = max(wait, d)
+		}
+	}
+
+	if !allowed {
+		for _, r := range reservations {
+			r.CancelAt(now)
+		}
+		return false, wait
+	}
+	return true, 0
+}
+
 // RateLimiterConfig configures the rate limiter
 type RateLimiterConfig struct {
-	Rate       float64       // Rate limit in requests per second
-	Burst      int           // Maximum burst size
-	Expiration time.Duration // How long to keep limiters for inactive clients
+	Rate       float64                  // Rate limit in requests per second
+	Burst      int                      // Maximum burst size
+	Sustained  []RateTier               // Longer term limits enforced alongside Rate and Burst
+	Expiration time.Duration            // How long to keep limiters for inactive clients
+	Endpoints  map[string]EndpointLimit // Per-endpoint limits keyed by procedure, e.g. /fleetd.v1.DeviceService/Register
+}
+
+// EndpointLimit overrides the default limit for one endpoint. Each client
+// gets a separate budget for every endpoint with its own limit.
+type EndpointLimit struct {
+	Rate      float64    // Rate limit in requests per second
+	Burst     int        // Maximum burst size
+	Sustained []RateTier // Longer term limits enforced alongside Rate and Burst
+}
+
+// RateTier allows Requests per Period, refilling continuously. Combined with
+// a short burst limit it lets clients burst briefly while capping their
+// sustained rate, e.g. 200 per second but no more than 100 per minute
+// overall.
+type RateTier struct {
+	Requests int
+	Period   time.Duration
+}
+
+func (t RateTier) limit() rate.Limit {
+	if t.Period <= 0 {
+		return rate.Inf
+	}
+	return rate.Limit(float64(t.Requests) / t.Period.Seconds())
+}
+
+// Endpoints that receive device telemetry and registrations
+var (
+	telemetryEndpoints = []string{
+		"/fleetd.v1.DeviceService/Heartbeat",
+		"/fleetd.v1.DeviceService/ReportStatus",
+		"/fleetd.v1.UpdateService/ReportUpdateStatus",
+	}
+	registrationEndpoints = []string{
+		"/fleetd.v1.DeviceService/Register",
+		"/fleetd.v1.DeviceService/BatchRegisterDevices",
+	}
+)
+
+// DeviceRateLimiterConfigFromEnv builds a per-device RateLimiterConfig.
+// Telemetry endpoints get a higher limit than registration. Limits are read
+// from RATE_LIMIT_RATE and RATE_LIMIT_BURST for other endpoints,
+// RATE_LIMIT_TELEMETRY_RATE and RATE_LIMIT_TELEMETRY_BURST, and
+// RATE_LIMIT_REGISTER_RATE and RATE_LIMIT_REGISTER_BURST.
+func DeviceRateLimiterConfigFromEnv() RateLimiterConfig {
+	telemetry := EndpointLimit{
+		Rate:  config.GetFloatFromEnv("RATE_LIMIT_TELEMETRY_RATE", 10),
+		Burst: config.GetIntFromEnv("RATE_LIMIT_TELEMETRY_BURST", 50),
+	}
+	register := EndpointLimit{
+		Rate:  config.GetFloatFromEnv("RATE_LIMIT_REGISTER_RATE", 0.1),
+		Burst: config.GetIntFromEnv("RATE_LIMIT_REGISTER_BURST", 3),
+	}
+
+	cfg := RateLimiterConfig{
+		Rate:       config.GetFloatFromEnv("RATE_LIMIT_RATE", 5),
+		Burst:      config.GetIntFromEnv("RATE_LIMIT_BURST", 20),
+		Expiration: 10 * time.Minute,
+		Endpoints:  make(map[string]EndpointLimit),
+	}
+	for _, endpoint := range telemetryEndpoints {
+		cfg.Endpoints[endpoint] = telemetry
+	}
+	for _, endpoint := range registrationEndpoints {
+		cfg.Endpoints[endpoint] = register
+	}
+	return cfg
 }
 
 // NewRateLimiter creates a new RateLimiter
@@ -42,6 +169,8 @@ func NewRateLimiter(config RateLimiterConfig) *RateLimiter {
 		limiters:      make(map[string]*limiterState),
 		rate:          rate.Limit(config.Rate),
 		burst:         config.Burst,
+		sustained:     config.Sustained,
+		endpoints:     config.Endpoints,
 		expiration:    config.Expiration,
 		cleanupTicker: time.NewTicker(config.Expiration),
 		done:          make(chan struct{}),
@@ -52,16 +181,31 @@ func NewRateLimiter(config RateLimiterConfig) *RateLimiter {
 }
 
 // getLimiter gets or creates a rate limiter for a client
-func (rl *RateLimiter) getLimiter(clientID string) *rate.Limiter {
+func (rl *RateLimiter) getLimiter(clientID string) *tieredLimiter {
+	return rl.limiterFor(clientID, rl.rate, rl.burst, rl.sustained)
+}
+
+// getEndpointLimiter gets or creates a rate limiter for a client calling an
+// endpoint. Endpoints without their own limit share the client's default
+// limiter.
+func (rl *RateLimiter) getEndpointLimiter(clientID, endpoint string) *tieredLimiter {
+	limit, ok := rl.endpoints[endpoint]
+	if !ok {
+		return rl.getLimiter(clientID)
+	}
+	return rl.limiterFor(endpoint+" "+clientID, rate.Limit(limit.Rate), limit.Burst, limit.Sustained)
+}
+
+func (rl *RateLimiter) limiterFor(key string, r rate.Limit, burst int, sustained []RateTier) *tieredLimiter {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
-	state, exists := rl.limiters[clientID]
+	state, exists := rl.limiters[key]
 	if !exists {
-		limiter := rate.NewLimiter(rl.rate, rl.burst)
-		rl.limiters[clientID] = &limiterState{limiter: limiter, lastUsed: time.Now()}
-		return limiter
+		state = &limiterState{limiter: newTieredLimiter(r, burst, sustained)}
+		rl.limiters[key] = state
 	}
+	state.lastUsed = time.Now()
 
 	return state.limiter
 }
@@ -87,8 +231,8 @@ func (rl *RateLimiter) UnaryServerInterceptor() connect.UnaryInterceptorFunc {
 			}
 
 			limiter := rl.getLimiter(clientID)
-			if !limiter.Allow() {
-				return nil, connect.NewError(connect.CodeResourceExhausted, errors.New("rate limit exceeded"))
+			if ok, wait := limiter.allow(time.Now()); !ok {
+				return nil, rateLimitError(wait)
 			}
 
 			return next(ctx, req)
@@ -132,13 +276,13 @@ func (s *streamInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFu
 // rateLimitedServerStream wraps a connect.ServerStream with rate limiting
 type rateLimitedServerStream struct {
 	connect.StreamingHandlerConn
-	limiter *rate.Limiter
+	limiter *tieredLimiter
 }
 
 // Receive rate limits incoming messages
 func (s *rateLimitedServerStream) Receive(m interface{}) error {
-	if !s.limiter.Allow() {
-		return connect.NewError(connect.CodeResourceExhausted, errors.New("rate limit exceeded"))
+	if ok, wait := s.limiter.allow(time.Now()); !ok {
+		return rateLimitError(wait)
 	}
 	return s.StreamingHandlerConn.Receive(m)
 }
@@ -161,6 +305,57 @@ func getClientID(ctx context.Context) string {
 	return ""
 }
 
+// DeviceInterceptor returns a Connect interceptor that rate limits each
+// device separately, so devices sharing an IP address behind NAT do not
+// exhaust each other's budget. See deviceClientKey for how callers are told
+// apart.
+func (rl *RateLimiter) DeviceInterceptor() connect.UnaryInterceptorFunc {
+	return func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			if req.Spec().IsClient {
+				return next(ctx, req)
+			}
+
+			clientID := deviceClientKey(ctx, req.Header(), req.Peer().Addr)
+			if ok, wait := rl.getEndpointLimiter(clientID, req.Spec().Procedure).allow(time.Now()); !ok {
+				return nil, rateLimitError(wait)
+			}
+			return next(ctx, req)
+		}
+	}
+}
+
+// DeviceRateLimitMiddleware returns HTTP middleware that rate limits each
+// device separately, using the request path as the endpoint
+func DeviceRateLimitMiddleware(rl *RateLimiter) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			clientID := deviceClientKey(r.Context(), r.Header, r.RemoteAddr)
+			if ok, wait := rl.getEndpointLimiter(clientID, r.URL.Path).allow(time.Now()); !ok {
+				writeRateLimited(w, wait)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// deviceClientKey identifies the caller for rate limiting and idempotency
+// keys: the device authenticated by client certificate, then the API key,
+// then the remote IP address for unauthenticated callers
+func deviceClientKey(ctx context.Context, header http.Header, remoteAddr string) string {
+	if deviceID, ok := DeviceIDFromContext(ctx); ok {
+		return "device:" + deviceID
+	}
+	if apiKey := header.Get("X-API-Key"); apiKey != "" {
+		return "key:" + apiKey
+	}
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return "ip:" + host
+	}
+	return "ip:" + remoteAddr
+}
+
 // RateLimitMiddleware returns HTTP middleware that rate limits requests
 func RateLimitMiddleware(rl *RateLimiter) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -175,8 +370,8 @@ func RateLimitMiddleware(rl *RateLimiter) func(next http.Handler) http.Handler {
 			limiter := rl.getLimiter(clientID)
 
 			// Try to allow request
-			if !limiter.Allow() {
-				http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			if ok, wait := limiter.allow(time.Now()); !ok {
+				writeRateLimited(w, wait)
 				return
 			}
 
@@ -218,8 +413,8 @@ func (rl *RateLimiter) UnaryInterceptor() connect.UnaryInterceptorFunc {
 		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
 			// Implement rate limiting logic here
 			// For example, check if the request is allowed and return an error if not
-			if !rl.allowRequest(req.Header()) {
-				return nil, connect.NewError(connect.CodeResourceExhausted, errors.New("rate limit exceeded"))
+			if ok, wait := rl.allowRequest(req.Header()); !ok {
+				return nil, rateLimitError(wait)
 			}
 			// Call the next handler if the request is allowed
 			return next(ctx, req)
@@ -227,17 +422,33 @@ func (rl *RateLimiter) UnaryInterceptor() connect.UnaryInterceptorFunc {
 	}
 }
 
-// Helper method to check if a request is allowed
-func (rl *RateLimiter) allowRequest(header http.Header) bool {
+// Helper method to check if a request is allowed. When it is not, the wait
+// until it would be is also returned, or zero if waiting will not help.
+func (rl *RateLimiter) allowRequest(header http.Header) (bool, time.Duration) {
 	// Extract client ID or other necessary information from the header
 	clientID := header.Get("X-API-Key")
 	if clientID == "" {
-		return false // or handle as needed
+		return false, 0 // or handle as needed
 	}
 
 	// Implement your rate limiting logic here using clientID
 	limiter := rl.getLimiter(clientID)
-	return limiter.Allow()
+	return limiter.allow(time.Now())
+}
+
+// rateLimitError returns a ResourceExhausted error telling the client how
+// long to wait before retrying
+func rateLimitError(wait time.Duration) error {
+	err := connect.NewError(connect.CodeResourceExhausted, errors.New("rate limit exceeded"))
+	setSeconds(err.Meta(), RetryAfterHeader, wait)
+	return err
+}
+
+// writeRateLimited rejects an HTTP request, telling the client how long to
+// wait before retrying
+func writeRateLimited(w http.ResponseWriter, wait time.Duration) {
+	setSeconds(w.Header(), RetryAfterHeader, wait)
+	WriteError(w, http.StatusTooManyRequests, connect.CodeResourceExhausted, "rate limit exceeded")
 }
 
 // Define a custom type for streaming interceptors
@@ -247,8 +458,8 @@ func (rl *RateLimiter) StreamInterceptor() StreamingInterceptor {
 	return func(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
 		return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
 			// Implement rate limiting logic here
-			if !rl.allowRequest(conn.RequestHeader()) {
-				return connect.NewError(connect.CodeResourceExhausted, errors.New("rate limit exceeded"))
+			if ok, wait := rl.allowRequest(conn.RequestHeader()); !ok {
+				return rateLimitError(wait)
 			}
 			return next(ctx, conn)
 		}
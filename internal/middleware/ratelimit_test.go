@@ -215,3 +215,192 @@ func TestRateLimiter_MultipleClients(t *testing.T) {
 	assert.True(t, client2.Allow())
 	assert.False(t, client2.Allow())
 }
+
+func TestRateLimiter_PerDevice(t *testing.T) {
+	rl := NewRateLimiter(RateLimiterConfig{
+		Rate:       1,
+		Burst:      1,
+		Expiration: time.Hour,
+		Endpoints: map[string]EndpointLimit{
+			"/fleetd.v1.DeviceService/Heartbeat": {Rate: 1, Burst: 3},
+		},
+	})
+	defer rl.Stop()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	// Stands in for ClientCertAuth, which sets the device ID for verified certificates
+	authenticate := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if deviceID := r.Header.Get("X-Test-Device"); deviceID != "" {
+				r = r.WithContext(context.WithValue(r.Context(), deviceIDKey{}, deviceID))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	server := httptest.NewServer(authenticate(DeviceRateLimitMiddleware(rl)(handler)))
+	defer server.Close()
+
+	call := func(path, deviceID string) int {
+		req, err := http.NewRequest("POST", server.URL+path, nil)
+		require.NoError(t, err)
+		if deviceID != "" {
+			req.Header.Set("X-Test-Device", deviceID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// Telemetry has a higher limit than other endpoints
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, call("/fleetd.v1.DeviceService/Heartbeat", "device-1"))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, call("/fleetd.v1.DeviceService/Heartbeat", "device-1"))
+
+	// Another device on the same IP is not affected
+	assert.Equal(t, http.StatusOK, call("/fleetd.v1.DeviceService/Heartbeat", "device-2"))
+
+	// Endpoints without their own limit use the default
+	assert.Equal(t, http.StatusOK, call("/fleetd.v1.DeviceService/GetDevice", "device-1"))
+	assert.Equal(t, http.StatusTooManyRequests, call("/fleetd.v1.DeviceService/GetDevice", "device-1"))
+
+	// Unauthenticated callers share a limit per IP
+	assert.Equal(t, http.StatusOK, call("/fleetd.v1.DeviceService/GetDevice", ""))
+	assert.Equal(t, http.StatusTooManyRequests, call("/fleetd.v1.DeviceService/GetDevice", ""))
+	assert.Equal(t, http.StatusOK, call("/fleetd.v1.DeviceService/GetDevice", "device-2"))
+}
+
+func TestRateLimiter_DeviceInterceptor(t *testing.T) {
+	rl := NewRateLimiter(RateLimiterConfig{
+		Rate:       1,
+		Burst:      1,
+		Expiration: time.Hour,
+	})
+	defer rl.Stop()
+
+	handler := rl.DeviceInterceptor()(func(_ context.Context, _ connect.AnyRequest) (connect.AnyResponse, error) {
+		data := "ok"
+		return connect.NewResponse(&data), nil
+	})
+
+	device1 := context.WithValue(context.Background(), deviceIDKey{}, "device-1")
+	device2 := context.WithValue(context.Background(), deviceIDKey{}, "device-2")
+
+	_, err := handler(device1, connect.NewRequest(&struct{}{}))
+	require.NoError(t, err)
+	_, err = handler(device1, connect.NewRequest(&struct{}{}))
+	assert.Equal(t, connect.CodeResourceExhausted, connect.CodeOf(err))
+
+	_, err = handler(device2, connect.NewRequest(&struct{}{}))
+	require.NoError(t, err)
+}
+
+func TestDeviceRateLimiterConfigFromEnv(t *testing.T) {
+	t.Setenv("RATE_LIMIT_TELEMETRY_RATE", "20")
+	t.Setenv("RATE_LIMIT_REGISTER_BURST", "1")
+
+	cfg := DeviceRateLimiterConfigFromEnv()
+	assert.Equal(t, EndpointLimit{Rate: 20, Burst: 50}, cfg.Endpoints["/fleetd.v1.DeviceService/Heartbeat"])
+	assert.Equal(t, EndpointLimit{Rate: 0.1, Burst: 1}, cfg.Endpoints["/fleetd.v1.DeviceService/Register"])
+	assert.Greater(t, cfg.Endpoints["/fleetd.v1.DeviceService/ReportStatus"].Rate, cfg.Endpoints["/fleetd.v1.DeviceService/Register"].Rate)
+}
+
+func TestRateLimiter_BurstAndSustained(t *testing.T) {
+	rl := NewRateLimiter(RateLimiterConfig{
+		Rate:       10,
+		Burst:      10,
+		Sustained:  []RateTier{{Requests: 60, Period: time.Minute}},
+		Expiration: time.Hour,
+	})
+	defer rl.Stop()
+
+	limiter := rl.getLimiter("client")
+	now := time.Now()
+
+	// A full burst passes
+	for i := 0; i < 10; i++ {
+		ok, _ := limiter.allow(now)
+		require.True(t, ok, "request %d", i)
+	}
+
+	// The burst limit applies first, and a rejected request consumes nothing
+	ok, wait := limiter.allow(now)
+	assert.False(t, ok)
+	assert.Equal(t, 100*time.Millisecond, wait)
+	ok, _ = limiter.allow(now.Add(wait))
+	assert.True(t, ok)
+
+	// Keeping up the burst rate is eventually throttled by the sustained limit
+	allowed := 11
+	for second := 1; ; second++ {
+		now = now.Add(time.Second)
+		var ok bool
+		for i := 0; i < 10; i++ {
+			if ok, wait = limiter.allow(now); !ok {
+				break
+			}
+			allowed++
+		}
+		if !ok {
+			require.Less(t, second, 10)
+			break
+		}
+	}
+	assert.Less(t, allowed, 100)
+
+	// The wait reported is until the sustained bucket refills, which allows
+	// exactly one more request
+	assert.Greater(t, wait, time.Duration(0))
+	assert.LessOrEqual(t, wait, time.Second)
+	ok, _ = limiter.allow(now.Add(wait - time.Millisecond))
+	assert.False(t, ok)
+	ok, _ = limiter.allow(now.Add(wait))
+	assert.True(t, ok)
+	ok, _ = limiter.allow(now.Add(wait))
+	assert.False(t, ok)
+}
+
+func TestRateLimiter_RetryAfter(t *testing.T) {
+	rl := NewRateLimiter(RateLimiterConfig{
+		Rate:       100,
+		Burst:      100,
+		Sustained:  []RateTier{{Requests: 2, Period: time.Minute}},
+		Expiration: time.Hour,
+	})
+	defer rl.Stop()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	server := httptest.NewServer(WithRateLimit(handler, rl))
+	defer server.Close()
+
+	req, err := http.NewRequest("GET", server.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("X-API-Key", "test-client")
+
+	for i := 0; i < 2; i++ {
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get(RetryAfterHeader))
+	}
+
+	// The sustained bucket refills one request every 30 seconds
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "30", resp.Header.Get(RetryAfterHeader))
+
+	// Connect errors carry the same hint
+	ok, wait := rl.allowRequest(req.Header)
+	require.False(t, ok)
+	err = rateLimitError(wait)
+	assert.Equal(t, connect.CodeResourceExhausted, connect.CodeOf(err))
+	assert.Equal(t, "30", err.(*connect.Error).Meta().Get(RetryAfterHeader))
+}
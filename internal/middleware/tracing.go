@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"connectrpc.com/connect"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// AppIDHeader identifies the application a request is made for
+	AppIDHeader = "X-App-ID"
+
+	tracerName = "fleetd.sh/internal/middleware"
+)
+
+// TracingInterceptor is a connect.Interceptor that traces RPCs across the
+// agent and server. On clients it starts a span and injects it as a W3C
+// traceparent header; on handlers it continues the caller's trace. Spans are
+// tagged with the device and app IDs sent in request headers.
+type TracingInterceptor struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// NewTracingInterceptor creates a new TracingInterceptor. A nil provider uses
+// the global tracer provider.
+func NewTracingInterceptor(provider trace.TracerProvider) *TracingInterceptor {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return &TracingInterceptor{
+		tracer:     provider.Tracer(tracerName),
+		propagator: propagation.TraceContext{},
+	}
+}
+
+// WrapUnary implements connect.Interceptor
+func (i *TracingInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		ctx, span := i.start(ctx, req.Spec(), req.Header())
+		defer span.End()
+
+		resp, err := next(ctx, req)
+		recordError(span, err)
+		return resp, err
+	}
+}
+
+// WrapStreamingClient implements connect.Interceptor
+func (i *TracingInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		ctx, span := i.tracer.Start(ctx, spec.Procedure, trace.WithSpanKind(trace.SpanKindClient))
+		conn := next(ctx, spec)
+		i.propagator.Inject(ctx, propagation.HeaderCarrier(conn.RequestHeader()))
+		return &tracedClientConn{StreamingClientConn: conn, span: span}
+	}
+}
+
+// WrapStreamingHandler implements connect.Interceptor
+func (i *TracingInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		ctx, span := i.start(ctx, conn.Spec(), conn.RequestHeader())
+		defer span.End()
+
+		err := next(ctx, conn)
+		recordError(span, err)
+		return err
+	}
+}
+
+// start begins a client span and injects it into header, or continues the
+// trace carried by header on handlers
+func (i *TracingInterceptor) start(ctx context.Context, spec connect.Spec, header http.Header) (context.Context, trace.Span) {
+	kind := trace.SpanKindServer
+	if spec.IsClient {
+		kind = trace.SpanKindClient
+	} else {
+		ctx = i.propagator.Extract(ctx, propagation.HeaderCarrier(header))
+	}
+
+	ctx, span := i.tracer.Start(ctx, spec.Procedure, trace.WithSpanKind(kind))
+	if spec.IsClient {
+		i.propagator.Inject(ctx, propagation.HeaderCarrier(header))
+	}
+
+	span.SetAttributes(attribute.String("rpc.method", spec.Procedure))
+	if deviceID := header.Get(DeviceIDHeader); deviceID != "" {
+		span.SetAttributes(attribute.String("device_id", deviceID))
+	}
+	if appID := header.Get(AppIDHeader); appID != "" {
+		span.SetAttributes(attribute.String("app_id", appID))
+	}
+	return ctx, span
+}
+
+func recordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	span.SetAttributes(attribute.String("rpc.connect.code", connect.CodeOf(err).String()))
+}
+
+// tracedClientConn ends the client span once the response is closed
+type tracedClientConn struct {
+	connect.StreamingClientConn
+	span trace.Span
+}
+
+func (c *tracedClientConn) CloseResponse() error {
+	err := c.StreamingClientConn.CloseResponse()
+	c.span.End()
+	return err
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestTracing_PropagatesClientSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	interceptor := NewTracingInterceptor(provider)
+
+	fail := false
+	mux := http.NewServeMux()
+	mux.Handle("/fleetd.v1.TestService/Ping", connect.NewUnaryHandler(
+		"/fleetd.v1.TestService/Ping",
+		func(ctx context.Context, req *connect.Request[emptypb.Empty]) (*connect.Response[emptypb.Empty], error) {
+			assert.True(t, trace.SpanContextFromContext(ctx).IsValid())
+			if fail {
+				return nil, connect.NewError(connect.CodeUnavailable, errors.New("not now"))
+			}
+			return connect.NewResponse(&emptypb.Empty{}), nil
+		},
+		connect.WithInterceptors(interceptor),
+	))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := connect.NewClient[emptypb.Empty, emptypb.Empty](
+		http.DefaultClient,
+		server.URL+"/fleetd.v1.TestService/Ping",
+		connect.WithInterceptors(interceptor),
+	)
+
+	req := connect.NewRequest(&emptypb.Empty{})
+	req.Header().Set(DeviceIDHeader, "device-1")
+	req.Header().Set(AppIDHeader, "app-1")
+	_, err := client.CallUnary(context.Background(), req)
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	serverSpan, clientSpan := spans[0], spans[1]
+	assert.Equal(t, trace.SpanKindServer, serverSpan.SpanKind())
+	assert.Equal(t, trace.SpanKindClient, clientSpan.SpanKind())
+
+	// The server continues the trace started by the client
+	assert.Equal(t, clientSpan.SpanContext().TraceID(), serverSpan.SpanContext().TraceID())
+	assert.Equal(t, clientSpan.SpanContext().SpanID(), serverSpan.Parent().SpanID())
+	assert.True(t, serverSpan.Parent().IsRemote())
+
+	for _, span := range spans {
+		assert.Equal(t, "/fleetd.v1.TestService/Ping", span.Name())
+		assert.Contains(t, span.Attributes(), attribute.String("device_id", "device-1"))
+		assert.Contains(t, span.Attributes(), attribute.String("app_id", "app-1"))
+	}
+
+	// Failed calls are marked as errors on both sides
+	fail = true
+	_, err = client.CallUnary(context.Background(), connect.NewRequest(&emptypb.Empty{}))
+	require.Error(t, err)
+
+	spans = recorder.Ended()
+	require.Len(t, spans, 4)
+	for _, span := range spans[2:] {
+		assert.Equal(t, codes.Error, span.Status().Code)
+		assert.Contains(t, span.Attributes(), attribute.String("rpc.connect.code", "unavailable"))
+		assert.NotContains(t, span.Attributes(), attribute.String("device_id", "device-1"))
+	}
+}
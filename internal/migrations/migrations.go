@@ -3,9 +3,12 @@ package migrations
 import (
 	"database/sql"
 	"embed"
+	"errors"
 	"fmt"
+	"io/fs"
 
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
 	"github.com/golang-migrate/migrate/v4/database/sqlite3"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
@@ -14,19 +17,22 @@ import (
 //go:embed queries/*.sql
 var Migrations embed.FS
 
-func MigrateUp(d *sql.DB) (version int, dirty bool, err error) {
+// ErrDirty is returned when a previous migration failed part way. The schema
+// has to be repaired by hand and the version set with Force before migrating
+// again.
+var ErrDirty = errors.New("database is dirty")
+
+// newMigrator creates a migrator for d. Each migration runs in its own
+// transaction, so a failing step leaves the schema as it was before it.
+func newMigrator(d *sql.DB) (*migrate.Migrate, database.Driver, error) {
 	source, err := iofs.New(Migrations, "queries")
 	if err != nil {
-		return -1, false, fmt.Errorf("failed to create source driver: %w", err)
+		return nil, nil, fmt.Errorf("failed to create source driver: %w", err)
 	}
 
 	driver, err := sqlite3.WithInstance(d, &sqlite3.Config{})
 	if err != nil {
-		return -1, false, fmt.Errorf("failed to create sqlite driver: %w", err)
-	}
-
-	if _, err := d.Exec("PRAGMA foreign_keys = ON"); err != nil {
-		return -1, false, fmt.Errorf("failed to enable foreign keys: %w", err)
+		return nil, nil, fmt.Errorf("failed to create sqlite driver: %w", err)
 	}
 
 	m, err := migrate.NewWithInstance(
@@ -36,10 +42,23 @@ func MigrateUp(d *sql.DB) (version int, dirty bool, err error) {
 		driver,
 	)
 	if err != nil {
-		return -1, false, fmt.Errorf("failed to create migrator: %w", err)
+		return nil, nil, fmt.Errorf("failed to create migrator: %w", err)
+	}
+	return m, driver, nil
+}
+
+// run applies migrations to d with apply and returns the resulting version
+func run(d *sql.DB, apply func(m *migrate.Migrate) error) (version int, dirty bool, err error) {
+	m, driver, err := newMigrator(d)
+	if err != nil {
+		return -1, false, err
 	}
 
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+	if err := apply(m); err != nil {
+		var dirtyErr migrate.ErrDirty
+		if errors.As(err, &dirtyErr) {
+			return dirtyErr.Version, true, fmt.Errorf("%w at version %d", ErrDirty, dirtyErr.Version)
+		}
 		return -1, false, fmt.Errorf("failed to run migrations: %w", err)
 	}
 
@@ -52,36 +71,111 @@ func MigrateUp(d *sql.DB) (version int, dirty bool, err error) {
 	return version, dirty, nil
 }
 
-func MigrateDown(d *sql.DB) (version int, dirty bool, err error) {
-	source, err := iofs.New(Migrations, "queries")
+// MigrateUp applies every pending migration
+func MigrateUp(d *sql.DB) (version int, dirty bool, err error) {
+	if _, err := d.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		return -1, false, fmt.Errorf("failed to enable foreign keys: %w", err)
+	}
+
+	return run(d, func(m *migrate.Migrate) error {
+		if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+			return err
+		}
+		return nil
+	})
+}
+
+// MigrateDown rolls back the given number of migrations, or every migration
+// if steps is zero or less. The version is -1 once all are rolled back.
+func MigrateDown(d *sql.DB, steps int) (version int, dirty bool, err error) {
+	return run(d, func(m *migrate.Migrate) error {
+		var err error
+		if steps <= 0 {
+			err = m.Down()
+		} else {
+			err = m.Steps(-steps)
+		}
+		if err != nil && err != migrate.ErrNoChange {
+			return err
+		}
+		return nil
+	})
+}
+
+// MigrateTo migrates up or down to the given version
+func MigrateTo(d *sql.DB, target uint) (version int, dirty bool, err error) {
+	return run(d, func(m *migrate.Migrate) error {
+		if err := m.Migrate(target); err != nil && err != migrate.ErrNoChange {
+			return err
+		}
+		return nil
+	})
+}
+
+// Status returns the current schema version, or -1 if no migration has been
+// applied, and whether the last migration failed part way
+func Status(d *sql.DB) (version int, dirty bool, err error) {
+	_, driver, err := newMigrator(d)
 	if err != nil {
-		return -1, false, fmt.Errorf("failed to create source driver: %w", err)
+		return -1, false, err
 	}
 
-	driver, err := sqlite3.WithInstance(d, &sqlite3.Config{})
+	version, dirty, err = driver.Version()
 	if err != nil {
-		return -1, false, fmt.Errorf("failed to create sqlite driver: %w", err)
+		return -1, false, fmt.Errorf("failed to get version: %w", err)
 	}
+	return version, dirty, nil
+}
 
-	m, err := migrate.NewWithInstance(
-		"iofs",
-		source,
-		"sqlite",
-		driver,
-	)
+// Latest returns the version of the newest migration
+func Latest() (int, error) {
+	source, err := iofs.New(Migrations, "queries")
 	if err != nil {
-		return -1, false, fmt.Errorf("failed to create migrator: %w", err)
+		return -1, fmt.Errorf("failed to create source driver: %w", err)
 	}
+	defer source.Close()
 
-	if err := m.Down(); err != nil && err != migrate.ErrNoChange {
-		return -1, false, fmt.Errorf("failed to run migrations: %w", err)
+	version, err := source.First()
+	if err != nil {
+		return -1, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	for {
+		next, err := source.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return int(version), nil
+		}
+		if err != nil {
+			return -1, fmt.Errorf("failed to read migrations: %w", err)
+		}
+		version = next
 	}
+}
 
-	version, dirty, err = driver.Version()
+// Pending reports whether d is missing migrations or was left dirty by a
+// failed one
+func Pending(d *sql.DB) (bool, error) {
+	version, dirty, err := Status(d)
 	if err != nil {
-		// Return 0 since migrations have been rolled back
-		return 0, false, fmt.Errorf("failed to get version: %w", err)
+		return false, err
 	}
+	latest, err := Latest()
+	if err != nil {
+		return false, err
+	}
+	return dirty || version < latest, nil
+}
 
-	return version, dirty, nil
+// Force records version as the current schema version and clears the dirty
+// flag without running any migration. Use it after repairing a schema left
+// dirty by a failed migration; -1 marks no migration as applied.
+func Force(d *sql.DB, version int) error {
+	m, _, err := newMigrator(d)
+	if err != nil {
+		return err
+	}
+
+	if err := m.Force(version); err != nil {
+		return fmt.Errorf("failed to force version %d: %w", version, err)
+	}
+	return nil
 }
@@ -0,0 +1,127 @@
+package migrations
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func tableExists(t *testing.T, db *sql.DB, name string) bool {
+	var n int
+	require.NoError(t, db.QueryRow(
+		"SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&n))
+	return n > 0
+}
+
+func columnExists(t *testing.T, db *sql.DB, table, column string) bool {
+	var n int
+	require.NoError(t, db.QueryRow(
+		"SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", table, column).Scan(&n))
+	return n > 0
+}
+
+func TestMigrateUpDown(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	version, dirty, err := Status(db)
+	require.NoError(t, err)
+	assert.Equal(t, -1, version)
+	assert.False(t, dirty)
+
+	pending, err := Pending(db)
+	require.NoError(t, err)
+	assert.True(t, pending)
+
+	latest, dirty, err := MigrateUp(db)
+	require.NoError(t, err)
+	require.False(t, dirty)
+	assert.True(t, tableExists(t, db, "device_tag"))
+
+	newest, err := Latest()
+	require.NoError(t, err)
+	assert.Equal(t, newest, latest)
+	pending, err = Pending(db)
+	require.NoError(t, err)
+	assert.False(t, pending)
+
+	// Roll back the latest migration
+	version, dirty, err = MigrateDown(db, 1)
+	require.NoError(t, err)
+	assert.Equal(t, latest-1, version)
+	assert.False(t, dirty)
+	assert.False(t, columnExists(t, db, "update_campaign", "maintenance_window"))
+	assert.True(t, tableExists(t, db, "device_tag"))
+
+	version, dirty, err = Status(db)
+	require.NoError(t, err)
+	assert.Equal(t, latest-1, version)
+	assert.False(t, dirty)
+	pending, err = Pending(db)
+	require.NoError(t, err)
+	assert.True(t, pending)
+
+	// And apply it again
+	version, _, err = MigrateUp(db)
+	require.NoError(t, err)
+	assert.Equal(t, latest, version)
+	assert.True(t, columnExists(t, db, "update_campaign", "maintenance_window"))
+
+	// Migrate to a specific version in either direction
+	version, _, err = MigrateTo(db, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 3, version)
+	assert.False(t, tableExists(t, db, "device_version_history"))
+
+	version, _, err = MigrateTo(db, uint(latest))
+	require.NoError(t, err)
+	assert.Equal(t, latest, version)
+
+	// Roll back everything
+	version, _, err = MigrateDown(db, 0)
+	require.NoError(t, err)
+	assert.Equal(t, -1, version)
+	assert.False(t, tableExists(t, db, "device"))
+}
+
+func TestMigrateDirty(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	latest, _, err := MigrateUp(db)
+	require.NoError(t, err)
+	_, _, err = MigrateDown(db, 1)
+	require.NoError(t, err)
+
+	// Simulate a migration that failed part way
+	_, err = db.Exec("UPDATE schema_migrations SET version = ?, dirty = 1", latest)
+	require.NoError(t, err)
+
+	version, dirty, err := Status(db)
+	require.NoError(t, err)
+	assert.Equal(t, latest, version)
+	assert.True(t, dirty)
+
+	version, dirty, err = MigrateUp(db)
+	require.ErrorIs(t, err, ErrDirty)
+	assert.Equal(t, latest, version)
+	assert.True(t, dirty)
+
+	// After repairing the schema, forcing the version resolves it
+	require.NoError(t, Force(db, latest-1))
+	version, dirty, err = Status(db)
+	require.NoError(t, err)
+	assert.Equal(t, latest-1, version)
+	assert.False(t, dirty)
+
+	version, dirty, err = MigrateUp(db)
+	require.NoError(t, err)
+	assert.Equal(t, latest, version)
+	assert.False(t, dirty)
+}
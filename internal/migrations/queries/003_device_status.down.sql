@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_device_status_event_device;
+DROP TABLE IF EXISTS device_status_event;
+
+DROP INDEX IF EXISTS idx_device_status;
+ALTER TABLE device DROP COLUMN status;
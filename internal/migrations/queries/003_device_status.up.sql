@@ -0,0 +1,17 @@
+-- Track the current status of each device
+ALTER TABLE device ADD COLUMN status TEXT NOT NULL DEFAULT 'unknown';
+
+CREATE INDEX idx_device_status ON device(status);
+
+-- Audit trail of device status transitions
+CREATE TABLE device_status_event (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    device_id TEXT NOT NULL,
+    previous_status TEXT NOT NULL,
+    status TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+    FOREIGN KEY (device_id) REFERENCES device(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_device_status_event_device ON device_status_event(device_id, created_at);
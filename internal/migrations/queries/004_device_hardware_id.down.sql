@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_device_hardware_id;
+ALTER TABLE device DROP COLUMN hardware_id;
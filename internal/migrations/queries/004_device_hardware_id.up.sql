@@ -0,0 +1,4 @@
+-- Stable hardware identifier used to detect duplicate registrations
+ALTER TABLE device ADD COLUMN hardware_id TEXT;
+
+CREATE UNIQUE INDEX idx_device_hardware_id ON device(hardware_id) WHERE hardware_id IS NOT NULL;
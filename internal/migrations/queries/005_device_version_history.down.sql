@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_update_campaign_rollback_of;
+ALTER TABLE update_campaign DROP COLUMN rollback_of;
+
+DROP INDEX IF EXISTS idx_device_version_history_device;
+DROP TABLE IF EXISTS device_version_history;
@@ -0,0 +1,20 @@
+-- Versions successfully installed on each device, newest last
+CREATE TABLE device_version_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    device_id TEXT NOT NULL,
+    campaign_id TEXT NOT NULL,
+    binary_id TEXT NOT NULL,
+    version TEXT NOT NULL,
+    sha256 TEXT NOT NULL,
+    installed_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+    FOREIGN KEY (device_id) REFERENCES device(id) ON DELETE CASCADE,
+    FOREIGN KEY (campaign_id) REFERENCES update_campaign(id),
+    FOREIGN KEY (binary_id) REFERENCES binary(id)
+);
+
+CREATE INDEX idx_device_version_history_device ON device_version_history(device_id, id);
+
+-- Campaign this campaign rolls back, if any
+ALTER TABLE update_campaign ADD COLUMN rollback_of TEXT REFERENCES update_campaign(id);
+
+CREATE INDEX idx_update_campaign_rollback_of ON update_campaign(rollback_of);
@@ -0,0 +1,7 @@
+-- Return archived devices to the device table before dropping the archive
+INSERT OR IGNORE INTO device (id, name, type, version, api_key, metadata, last_seen, created_at, updated_at, status, hardware_id)
+SELECT id, name, type, version, api_key, metadata, last_seen, created_at, updated_at, status, hardware_id
+FROM device_archive;
+
+DROP INDEX IF EXISTS idx_device_archive_hardware_id;
+DROP TABLE IF EXISTS device_archive;
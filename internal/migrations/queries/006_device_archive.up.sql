@@ -0,0 +1,17 @@
+-- Devices idle for a long time are moved here to keep the device table small
+CREATE TABLE device_archive (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    type TEXT NOT NULL,
+    version TEXT NOT NULL,
+    api_key TEXT NOT NULL,
+    metadata TEXT NOT NULL DEFAULT '{}',
+    last_seen TEXT,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'unknown',
+    hardware_id TEXT,
+    archived_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+);
+
+CREATE INDEX idx_device_archive_hardware_id ON device_archive(hardware_id);
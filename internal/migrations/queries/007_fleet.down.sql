@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_device_fleet;
+ALTER TABLE device_archive DROP COLUMN fleet_id;
+ALTER TABLE device DROP COLUMN fleet_id;
+DROP TABLE IF EXISTS fleet;
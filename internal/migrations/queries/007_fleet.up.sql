@@ -0,0 +1,15 @@
+-- Fleets group devices; every device belongs to exactly one fleet
+CREATE TABLE fleet (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+);
+
+-- Devices not assigned to any other fleet, including all existing ones
+INSERT INTO fleet (id, name, description) VALUES ('default', 'default', 'Devices not assigned to another fleet');
+
+ALTER TABLE device ADD COLUMN fleet_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE device_archive ADD COLUMN fleet_id TEXT NOT NULL DEFAULT 'default';
+
+CREATE INDEX idx_device_fleet ON device(fleet_id, id);
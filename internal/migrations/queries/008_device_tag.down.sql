@@ -0,0 +1,3 @@
+ALTER TABLE update_campaign DROP COLUMN target_selector;
+DROP INDEX IF EXISTS idx_device_tag_key_value;
+DROP TABLE IF EXISTS device_tag;
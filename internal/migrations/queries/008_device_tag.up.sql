@@ -0,0 +1,13 @@
+-- Key/value tags used to select devices
+CREATE TABLE device_tag (
+    device_id TEXT NOT NULL,
+    key TEXT NOT NULL,
+    value TEXT NOT NULL,
+    PRIMARY KEY (device_id, key),
+    FOREIGN KEY (device_id) REFERENCES device(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_device_tag_key_value ON device_tag(key, value, device_id);
+
+-- Selector expression the campaign targeted, if any
+ALTER TABLE update_campaign ADD COLUMN target_selector TEXT NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE update_campaign DROP COLUMN maintenance_window;
@@ -0,0 +1,2 @@
+-- Window during which the campaign releases its update, as JSON; empty for none
+ALTER TABLE update_campaign ADD COLUMN maintenance_window TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,42 @@
+package openapi
+
+import (
+	"encoding/json"
+
+	pb "fleetd.sh/gen/fleetd/v1"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// DefaultPath is where the fleetd API document is kept, relative to the
+// repository root
+const DefaultPath = "docs/openapi.json"
+
+// FleetdServices are the services of the fleetd platform API
+func FleetdServices() []protoreflect.ServiceDescriptor {
+	return []protoreflect.ServiceDescriptor{
+		pb.File_fleetd_v1_device_proto.Services().ByName("DeviceService"),
+		pb.File_fleetd_v1_binary_proto.Services().ByName("BinaryService"),
+		pb.File_fleetd_v1_update_proto.Services().ByName("UpdateService"),
+		pb.File_fleetd_v1_analytics_proto.Services().ByName("AnalyticsService"),
+	}
+}
+
+// Fleetd describes the fleetd platform API
+func Fleetd() *Document {
+	return Generate(Info{
+		Title:       "fleetd API",
+		Description: "Connect RPCs of the fleetd platform API. Each operation is a POST of the request message as JSON to its procedure path.",
+		Version:     "v1",
+	}, FleetdServices()...)
+}
+
+// MarshalFleetd returns the fleetd API document as indented JSON, as it is
+// written to DefaultPath
+func MarshalFleetd() ([]byte, error) {
+	data, err := json.MarshalIndent(Fleetd(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
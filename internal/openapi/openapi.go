@@ -0,0 +1,310 @@
+// Package openapi describes Connect services as an OpenAPI 3 document, so
+// that the HTTP API reference is generated from the protobuf definitions
+// instead of maintained by hand.
+package openapi
+
+import (
+	"fmt"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Document is an OpenAPI 3.0 document
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Paths      map[string]*PathItem  `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []map[string][]string `json:"security,omitempty"`
+	Tags       []Tag                 `json:"tags,omitempty"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// PathItem holds the operations on a path. Connect procedures are always
+// called with POST.
+type PathItem struct {
+	Post *Operation `json:"post,omitempty"`
+}
+
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is the subset of the OpenAPI schema object needed to describe
+// protobuf messages in their JSON form
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	In          string `json:"in,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+const (
+	apiKeyScheme = "ApiKeyAuth"
+	errorSchema  = "connect.Error"
+)
+
+// Generate describes the given services. Every method becomes a POST
+// operation on its Connect procedure path, /package.Service/Method, with the
+// request and response messages in their protobuf JSON form. Streaming
+// methods are listed too, but need a Connect or gRPC client to call.
+func Generate(info Info, services ...protoreflect.ServiceDescriptor) *Document {
+	g := &generator{
+		doc: &Document{
+			OpenAPI: "3.0.3",
+			Info:    info,
+			Paths:   make(map[string]*PathItem),
+			Components: Components{
+				Schemas: map[string]*Schema{errorSchema: connectErrorSchema()},
+				SecuritySchemes: map[string]*SecurityScheme{
+					apiKeyScheme: {
+						Type:        "apiKey",
+						In:          "header",
+						Name:        "X-API-Key",
+						Description: "API key issued at registration. Devices may authenticate with a client certificate instead.",
+					},
+				},
+			},
+			Security: []map[string][]string{{apiKeyScheme: {}}},
+		},
+	}
+
+	for _, service := range services {
+		g.doc.Tags = append(g.doc.Tags, Tag{Name: string(service.Name())})
+		methods := service.Methods()
+		for i := 0; i < methods.Len(); i++ {
+			g.addMethod(service, methods.Get(i))
+		}
+	}
+	return g.doc
+}
+
+type generator struct {
+	doc *Document
+}
+
+func (g *generator) addMethod(service protoreflect.ServiceDescriptor, method protoreflect.MethodDescriptor) {
+	contentType := "application/json"
+	var description string
+	switch {
+	case method.IsStreamingClient() && method.IsStreamingServer():
+		description = "Bidirectional streaming RPC."
+	case method.IsStreamingClient():
+		description = "Client streaming RPC."
+	case method.IsStreamingServer():
+		description = "Server streaming RPC."
+	}
+	if description != "" {
+		contentType = "application/connect+json"
+		description += " Messages are framed in Connect envelopes; use a Connect or gRPC client."
+	}
+
+	path := fmt.Sprintf("/%s/%s", service.FullName(), method.Name())
+	g.doc.Paths[path] = &PathItem{
+		Post: &Operation{
+			OperationID: fmt.Sprintf("%s.%s", service.Name(), method.Name()),
+			Summary:     string(method.Name()),
+			Description: description,
+			Tags:        []string{string(service.Name())},
+			RequestBody: &RequestBody{
+				Required: true,
+				Content: map[string]*MediaType{
+					contentType: {Schema: g.messageRef(method.Input())},
+				},
+			},
+			Responses: map[string]*Response{
+				"200": {
+					Description: "Success",
+					Content: map[string]*MediaType{
+						contentType: {Schema: g.messageRef(method.Output())},
+					},
+				},
+				"default": {
+					Description: "Error",
+					Content: map[string]*MediaType{
+						"application/json": {Schema: &Schema{Ref: schemaRef(errorSchema)}},
+					},
+				},
+			},
+		},
+	}
+}
+
+// messageRef returns a reference to the schema of a message, adding it and
+// the messages and enums it uses to the components
+func (g *generator) messageRef(message protoreflect.MessageDescriptor) *Schema {
+	if schema, ok := wellKnownSchema(message); ok {
+		return schema
+	}
+
+	name := string(message.FullName())
+	if _, ok := g.doc.Components.Schemas[name]; !ok {
+		schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		// Register before visiting fields so recursive messages terminate
+		g.doc.Components.Schemas[name] = schema
+		fields := message.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			field := fields.Get(i)
+			schema.Properties[field.JSONName()] = g.fieldSchema(field)
+		}
+	}
+	return &Schema{Ref: schemaRef(name)}
+}
+
+func (g *generator) fieldSchema(field protoreflect.FieldDescriptor) *Schema {
+	if field.IsMap() {
+		return &Schema{Type: "object", AdditionalProperties: g.singularSchema(field.MapValue())}
+	}
+	if field.IsList() {
+		return &Schema{Type: "array", Items: g.singularSchema(field)}
+	}
+	return g.singularSchema(field)
+}
+
+// singularSchema describes one value of a field, following the protobuf JSON
+// mapping
+func (g *generator) singularSchema(field protoreflect.FieldDescriptor) *Schema {
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		return &Schema{Type: "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return &Schema{Type: "integer", Format: "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return &Schema{Type: "integer", Format: "int64"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		// 64-bit integers are strings in JSON so they survive JavaScript
+		return &Schema{Type: "string", Format: "int64"}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return &Schema{Type: "string", Format: "uint64"}
+	case protoreflect.FloatKind:
+		return &Schema{Type: "number", Format: "float"}
+	case protoreflect.DoubleKind:
+		return &Schema{Type: "number", Format: "double"}
+	case protoreflect.StringKind:
+		return &Schema{Type: "string"}
+	case protoreflect.BytesKind:
+		return &Schema{Type: "string", Format: "byte"}
+	case protoreflect.EnumKind:
+		return g.enumRef(field.Enum())
+	default: // Message and group
+		return g.messageRef(field.Message())
+	}
+}
+
+func (g *generator) enumRef(enum protoreflect.EnumDescriptor) *Schema {
+	name := string(enum.FullName())
+	if _, ok := g.doc.Components.Schemas[name]; !ok {
+		values := enum.Values()
+		schema := &Schema{Type: "string", Enum: make([]string, values.Len())}
+		for i := 0; i < values.Len(); i++ {
+			schema.Enum[i] = string(values.Get(i).Name())
+		}
+		g.doc.Components.Schemas[name] = schema
+	}
+	return &Schema{Ref: schemaRef(name)}
+}
+
+// wellKnownSchema describes the well-known types that have a special JSON
+// form
+func wellKnownSchema(message protoreflect.MessageDescriptor) (*Schema, bool) {
+	switch message.FullName() {
+	case "google.protobuf.Timestamp":
+		return &Schema{Type: "string", Format: "date-time"}, true
+	case "google.protobuf.Duration":
+		return &Schema{Type: "string", Description: "Duration in seconds with an s suffix, e.g. 1.5s"}, true
+	case "google.protobuf.Empty":
+		return &Schema{Type: "object"}, true
+	case "google.protobuf.Struct":
+		return &Schema{Type: "object", AdditionalProperties: &Schema{}}, true
+	case "google.protobuf.Value":
+		return &Schema{}, true
+	case "google.protobuf.ListValue":
+		return &Schema{Type: "array", Items: &Schema{}}, true
+	case "google.protobuf.StringValue":
+		return &Schema{Type: "string"}, true
+	case "google.protobuf.BoolValue":
+		return &Schema{Type: "boolean"}, true
+	case "google.protobuf.Int32Value":
+		return &Schema{Type: "integer", Format: "int32"}, true
+	case "google.protobuf.Int64Value":
+		return &Schema{Type: "string", Format: "int64"}, true
+	case "google.protobuf.DoubleValue":
+		return &Schema{Type: "number", Format: "double"}, true
+	}
+	return nil, false
+}
+
+// connectErrorSchema describes the JSON body of a Connect error
+func connectErrorSchema() *Schema {
+	var codes []string
+	for code := connect.CodeCanceled; code <= connect.CodeUnauthenticated; code++ {
+		codes = append(codes, code.String())
+	}
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"code":    {Type: "string", Enum: codes},
+			"message": {Type: "string"},
+			"details": {
+				Type: "array",
+				Items: &Schema{
+					Type: "object",
+					Properties: map[string]*Schema{
+						"type":  {Type: "string"},
+						"value": {Type: "string", Format: "byte"},
+						"debug": {Type: "object", AdditionalProperties: &Schema{}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func schemaRef(name string) string {
+	return "#/components/schemas/" + name
+}
@@ -0,0 +1,141 @@
+package openapi
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFleetd_Operations(t *testing.T) {
+	doc := Fleetd()
+
+	count := 0
+	for _, service := range FleetdServices() {
+		require.NotNil(t, service)
+		methods := service.Methods()
+		for i := 0; i < methods.Len(); i++ {
+			method := methods.Get(i)
+			path := "/" + string(service.FullName()) + "/" + string(method.Name())
+			item, ok := doc.Paths[path]
+			require.True(t, ok, "no operation for %s", path)
+			require.NotNil(t, item.Post)
+			assert.Equal(t, string(service.Name())+"."+string(method.Name()), item.Post.OperationID)
+			assert.NotEmpty(t, item.Post.RequestBody.Content)
+			assert.Contains(t, item.Post.Responses, "200")
+			count++
+		}
+	}
+	assert.Len(t, doc.Paths, count)
+}
+
+func TestFleetd_Schemas(t *testing.T) {
+	schemas := Fleetd().Components.Schemas
+
+	device := schemas["fleetd.v1.Device"]
+	require.NotNil(t, device)
+	assert.Equal(t, &Schema{Type: "string"}, device.Properties["fleetId"])
+	assert.Equal(t, &Schema{Type: "string", Format: "date-time"}, device.Properties["lastSeen"])
+	assert.Equal(t, &Schema{Type: "object", AdditionalProperties: &Schema{Type: "string"}}, device.Properties["tags"])
+
+	list := schemas["fleetd.v1.ListDevicesResponse"]
+	require.NotNil(t, list)
+	assert.Equal(t, &Schema{Type: "array", Items: &Schema{Ref: "#/components/schemas/fleetd.v1.Device"}}, list.Properties["devices"])
+
+	binary := schemas["fleetd.v1.Binary"]
+	require.NotNil(t, binary)
+	assert.Equal(t, &Schema{Type: "string", Format: "int64"}, binary.Properties["size"])
+
+	strategy := schemas["fleetd.v1.UpdateStrategy"]
+	require.NotNil(t, strategy)
+	assert.Equal(t, "string", strategy.Type)
+	assert.NotEmpty(t, strategy.Enum)
+}
+
+// TestFleetd_Valid checks the document against the rules of the OpenAPI 3.0
+// specification that apply to what the generator emits
+func TestFleetd_Valid(t *testing.T) {
+	data, err := MarshalFleetd()
+	require.NoError(t, err)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(data, &doc))
+
+	assert.Regexp(t, `^3\.0\.\d+$`, doc["openapi"])
+	info := doc["info"].(map[string]any)
+	assert.NotEmpty(t, info["title"])
+	assert.NotEmpty(t, info["version"])
+
+	components := doc["components"].(map[string]any)
+	schemas := components["schemas"].(map[string]any)
+	for name := range schemas {
+		assert.Regexp(t, `^[a-zA-Z0-9.\-_]+$`, name)
+	}
+	securitySchemes := components["securitySchemes"].(map[string]any)
+	for _, requirement := range doc["security"].([]any) {
+		for name := range requirement.(map[string]any) {
+			assert.Contains(t, securitySchemes, name)
+		}
+	}
+
+	operationIDs := make(map[string]bool)
+	for path, item := range doc["paths"].(map[string]any) {
+		assert.True(t, strings.HasPrefix(path, "/"), path)
+		for method, op := range item.(map[string]any) {
+			assert.Equal(t, "post", method)
+			op := op.(map[string]any)
+			id := op["operationId"].(string)
+			assert.False(t, operationIDs[id], "duplicate operationId %s", id)
+			operationIDs[id] = true
+			assert.NotEmpty(t, op["responses"], path)
+			for code, resp := range op["responses"].(map[string]any) {
+				assert.Regexp(t, `^([1-5]\d\d|default)$`, code)
+				assert.NotEmpty(t, resp.(map[string]any)["description"], path)
+			}
+		}
+	}
+
+	// Every reference resolves and every schema uses a known type
+	validTypes := map[string]bool{"": true, "object": true, "array": true, "string": true, "integer": true, "number": true, "boolean": true}
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			if ref, ok := v["$ref"].(string); ok {
+				name, found := strings.CutPrefix(ref, "#/components/schemas/")
+				assert.True(t, found, "unexpected reference %s", ref)
+				assert.Contains(t, schemas, name)
+				assert.Len(t, v, 1, "$ref must not have siblings")
+			}
+			if typ, ok := v["type"].(string); ok {
+				assert.True(t, validTypes[typ], "invalid type %s", typ)
+				if typ == "array" {
+					assert.Contains(t, v, "items")
+				}
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(doc["paths"])
+	walk(schemas)
+}
+
+func TestFleetd_UpToDate(t *testing.T) {
+	want, err := MarshalFleetd()
+	require.NoError(t, err)
+
+	got, err := os.ReadFile(filepath.Join("..", "..", DefaultPath))
+	require.NoError(t, err)
+	assert.True(t, string(want) == string(got),
+		"%s is out of date, regenerate it with: go run ./cmd/openapi", DefaultPath)
+}
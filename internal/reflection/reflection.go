@@ -0,0 +1,147 @@
+// Package reflection serves the gRPC server reflection protocol from the
+// Connect servers, so that tools like grpcurl can list their services and
+// fetch their descriptors.
+package reflection
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"connectrpc.com/connect"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	grpcreflection "google.golang.org/grpc/reflection"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionv1alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// ServiceV1 and ServiceV1Alpha are the reflection services themselves.
+	// Older clients only know v1alpha.
+	ServiceV1      = "grpc.reflection.v1.ServerReflection"
+	ServiceV1Alpha = "grpc.reflection.v1alpha.ServerReflection"
+
+	procedureV1      = "/" + ServiceV1 + "/ServerReflectionInfo"
+	procedureV1Alpha = "/" + ServiceV1Alpha + "/ServerReflectionInfo"
+)
+
+// services lists the services reflection advertises
+type services []string
+
+func (s services) GetServiceInfo() map[string]grpc.ServiceInfo {
+	info := make(map[string]grpc.ServiceInfo, len(s))
+	for _, name := range s {
+		info[name] = grpc.ServiceInfo{}
+	}
+	return info
+}
+
+// Reflector advertises a fixed set of services. Their descriptors are
+// looked up in the global protobuf registry, which every generated package
+// registers with.
+type Reflector struct {
+	v1      reflectionv1.ServerReflectionServer
+	v1alpha reflectionv1alpha.ServerReflectionServer
+}
+
+// NewReflector creates a Reflector for the given fully qualified service
+// names, e.g. fleetd.v1.DeviceService. The reflection services are
+// advertised too.
+func NewReflector(serviceNames ...string) *Reflector {
+	opts := grpcreflection.ServerOptions{
+		Services: append(services{ServiceV1, ServiceV1Alpha}, serviceNames...),
+	}
+	return &Reflector{
+		v1:      grpcreflection.NewServerV1(opts),
+		v1alpha: grpcreflection.NewServer(opts),
+	}
+}
+
+// NewHandlerV1 returns the path and handler of the v1 reflection service
+func NewHandlerV1(r *Reflector, opts ...connect.HandlerOption) (string, http.Handler) {
+	return procedureV1, connect.NewBidiStreamHandler(procedureV1,
+		func(ctx context.Context, stream *connect.BidiStream[reflectionv1.ServerReflectionRequest, reflectionv1.ServerReflectionResponse]) error {
+			return r.v1.ServerReflectionInfo(&bidiStream[reflectionv1.ServerReflectionRequest, reflectionv1.ServerReflectionResponse]{ctx: ctx, stream: stream})
+		}, opts...)
+}
+
+// NewHandlerV1Alpha returns the path and handler of the v1alpha reflection
+// service
+func NewHandlerV1Alpha(r *Reflector, opts ...connect.HandlerOption) (string, http.Handler) {
+	return procedureV1Alpha, connect.NewBidiStreamHandler(procedureV1Alpha,
+		func(ctx context.Context, stream *connect.BidiStream[reflectionv1alpha.ServerReflectionRequest, reflectionv1alpha.ServerReflectionResponse]) error {
+			return r.v1alpha.ServerReflectionInfo(&bidiStream[reflectionv1alpha.ServerReflectionRequest, reflectionv1alpha.ServerReflectionResponse]{ctx: ctx, stream: stream})
+		}, opts...)
+}
+
+// bidiStream adapts a Connect stream to the gRPC stream the reflection
+// server is written against
+type bidiStream[Req, Res any] struct {
+	ctx    context.Context
+	stream *connect.BidiStream[Req, Res]
+}
+
+var _ grpc.BidiStreamingServer[reflectionv1.ServerReflectionRequest, reflectionv1.ServerReflectionResponse] = (*bidiStream[reflectionv1.ServerReflectionRequest, reflectionv1.ServerReflectionResponse])(nil)
+
+func (s *bidiStream[Req, Res]) Recv() (*Req, error) {
+	req, err := s.stream.Receive()
+	if errors.Is(err, io.EOF) {
+		// The reflection server compares against io.EOF itself
+		return nil, io.EOF
+	}
+	return req, err
+}
+
+func (s *bidiStream[Req, Res]) Send(res *Res) error {
+	return s.stream.Send(res)
+}
+
+func (s *bidiStream[Req, Res]) Context() context.Context {
+	return s.ctx
+}
+
+func (s *bidiStream[Req, Res]) SetHeader(md metadata.MD) error {
+	for key, values := range md {
+		for _, value := range values {
+			s.stream.ResponseHeader().Add(key, value)
+		}
+	}
+	return nil
+}
+
+func (s *bidiStream[Req, Res]) SendHeader(md metadata.MD) error {
+	return s.SetHeader(md)
+}
+
+func (s *bidiStream[Req, Res]) SetTrailer(md metadata.MD) {
+	for key, values := range md {
+		for _, value := range values {
+			s.stream.ResponseTrailer().Add(key, value)
+		}
+	}
+}
+
+func (s *bidiStream[Req, Res]) SendMsg(m any) error {
+	res, ok := m.(*Res)
+	if !ok {
+		return connect.NewError(connect.CodeInternal, errors.New("unexpected message type"))
+	}
+	return s.Send(res)
+}
+
+func (s *bidiStream[Req, Res]) RecvMsg(m any) error {
+	req, err := s.Recv()
+	if err != nil {
+		return err
+	}
+	dst, ok := m.(proto.Message)
+	if !ok {
+		return connect.NewError(connect.CodeInternal, errors.New("unexpected message type"))
+	}
+	proto.Reset(dst)
+	proto.Merge(dst, any(req).(proto.Message))
+	return nil
+}
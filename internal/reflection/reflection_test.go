@@ -0,0 +1,81 @@
+package reflection
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"fleetd.sh/gen/fleetd/v1/fleetpbconnect"
+
+	"connectrpc.com/connect"
+	"connectrpc.com/grpchealth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionv1alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+)
+
+var testServices = []string{fleetpbconnect.DeviceServiceName, grpchealth.HealthV1ServiceName}
+
+// newTestServer serves reflection over HTTP/2, as gRPC clients require
+func newTestServer(t *testing.T) *httptest.Server {
+	r := NewReflector(testServices...)
+	mux := http.NewServeMux()
+	mux.Handle(NewHandlerV1(r))
+	mux.Handle(NewHandlerV1Alpha(r))
+	server := httptest.NewUnstartedServer(mux)
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestReflection_ListServices(t *testing.T) {
+	server := newTestServer(t)
+	client := connect.NewClient[reflectionv1.ServerReflectionRequest, reflectionv1.ServerReflectionResponse](
+		server.Client(), server.URL+procedureV1, connect.WithGRPC())
+
+	stream := client.CallBidiStream(context.Background())
+	require.NoError(t, stream.Send(&reflectionv1.ServerReflectionRequest{
+		MessageRequest: &reflectionv1.ServerReflectionRequest_ListServices{},
+	}))
+	resp, err := stream.Receive()
+	require.NoError(t, err)
+
+	var names []string
+	for _, service := range resp.GetListServicesResponse().GetService() {
+		names = append(names, service.Name)
+	}
+	assert.ElementsMatch(t, append([]string{ServiceV1, ServiceV1Alpha}, testServices...), names)
+
+	// Descriptors of the listed services can be fetched
+	require.NoError(t, stream.Send(&reflectionv1.ServerReflectionRequest{
+		MessageRequest: &reflectionv1.ServerReflectionRequest_FileContainingSymbol{
+			FileContainingSymbol: fleetpbconnect.DeviceServiceName,
+		},
+	}))
+	resp, err = stream.Receive()
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.GetFileDescriptorResponse().GetFileDescriptorProto())
+
+	require.NoError(t, stream.CloseRequest())
+	require.NoError(t, stream.CloseResponse())
+}
+
+func TestReflection_V1Alpha(t *testing.T) {
+	server := newTestServer(t)
+	client := connect.NewClient[reflectionv1alpha.ServerReflectionRequest, reflectionv1alpha.ServerReflectionResponse](
+		server.Client(), server.URL+procedureV1Alpha, connect.WithGRPC())
+
+	stream := client.CallBidiStream(context.Background())
+	require.NoError(t, stream.Send(&reflectionv1alpha.ServerReflectionRequest{
+		MessageRequest: &reflectionv1alpha.ServerReflectionRequest_ListServices{},
+	}))
+	resp, err := stream.Receive()
+	require.NoError(t, err)
+	assert.Len(t, resp.GetListServicesResponse().GetService(), len(testServices)+2)
+
+	require.NoError(t, stream.CloseRequest())
+	require.NoError(t, stream.CloseResponse())
+}
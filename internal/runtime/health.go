@@ -48,26 +48,28 @@ func (r *Runtime) monitorHealth(ctx context.Context, name string, proc *managedP
 			err := proc.health.checker.Check(checkCtx)
 			cancel()
 
+			proc.mu.Lock()
 			if err != nil {
 				proc.health.failures++
 				proc.health.status = fmt.Sprintf("unhealthy: %v", err)
-
-				// Check if we need to restart
-				if proc.health.failures >= proc.health.maxFailures {
-					r.logger.Error("Health check failed, restarting process",
-						"name", name,
-						"failures", proc.health.failures)
-
-					// Restart the process
-					proc.cancel()
-					// Start will be handled by the monitor goroutine
-				}
 			} else {
 				proc.health.failures = 0
 				proc.health.status = "healthy"
 			}
-
 			proc.health.lastCheck = time.Now()
+			failures := proc.health.failures
+			proc.mu.Unlock()
+
+			// Check if we need to restart
+			if err != nil && failures >= proc.health.maxFailures {
+				r.logger.Error("Health check failed, restarting process",
+					"name", name,
+					"failures", failures)
+
+				// Restart the process
+				proc.cancel()
+				// Start will be handled by the monitor goroutine
+			}
 
 		case <-ctx.Done():
 			return
@@ -0,0 +1,104 @@
+package runtime
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ProcessState is the state of a managed process
+type ProcessState string
+
+const (
+	ProcessRunning ProcessState = "running"
+	// ProcessStopping is a process that was stopped but hasn't exited yet
+	ProcessStopping ProcessState = "stopping"
+)
+
+// ProcessInfo is a snapshot of a managed process
+type ProcessInfo struct {
+	Name      string
+	PID       int
+	State     ProcessState
+	StartedAt time.Time
+	Uptime    time.Duration
+
+	// Health is the status of the last health check, empty before the first
+	Health          string
+	HealthFailures  int
+	LastHealthCheck time.Time
+}
+
+// ProcessDetail is a ProcessInfo with the process's recent resource usage
+// and output
+type ProcessDetail struct {
+	ProcessInfo
+
+	CPU    float64 // Percent, as of the last sample
+	Memory uint64  // Resident bytes, as of the last sample
+
+	Stdout []string
+	Stderr []string
+}
+
+// ListProcesses returns a snapshot of every managed process, ordered by
+// name. Processes are dropped once they exit.
+func (r *Runtime) ListProcesses() []ProcessInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	infos := make([]ProcessInfo, 0, len(r.processes))
+	for name, proc := range r.processes {
+		infos = append(infos, proc.info(name, now))
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// DescribeProcess returns a snapshot of a managed process with its last
+// resource sample and up to logLines of its most recent output
+func (r *Runtime) DescribeProcess(name string, logLines int) (*ProcessDetail, error) {
+	r.mu.RLock()
+	proc, ok := r.processes[name]
+	if !ok {
+		r.mu.RUnlock()
+		return nil, fmt.Errorf("process not found: %s", name)
+	}
+	detail := &ProcessDetail{ProcessInfo: proc.info(name, time.Now())}
+	proc.mu.Lock()
+	detail.CPU = proc.stats.cpu
+	detail.Memory = proc.stats.memory
+	proc.mu.Unlock()
+	r.mu.RUnlock()
+
+	// Logs are read from disk, outside the lock
+	stdout, stderr, err := r.GetLogs(name, logLines)
+	if err != nil {
+		return nil, err
+	}
+	detail.Stdout = stdout
+	detail.Stderr = stderr
+	return detail, nil
+}
+
+// info snapshots proc. The caller must hold Runtime.mu.
+func (proc *managedProcess) info(name string, now time.Time) ProcessInfo {
+	info := ProcessInfo{
+		Name:      name,
+		PID:       proc.process.Pid,
+		State:     ProcessRunning,
+		StartedAt: proc.startedAt,
+		Uptime:    now.Sub(proc.startedAt),
+	}
+	if proc.stopping {
+		info.State = ProcessStopping
+	}
+
+	proc.mu.Lock()
+	defer proc.mu.Unlock()
+	info.Health = proc.health.status
+	info.HealthFailures = proc.health.failures
+	info.LastHealthCheck = proc.health.lastCheck
+	return info
+}
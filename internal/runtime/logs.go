@@ -1,18 +1,97 @@
 package runtime
 
 import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 )
 
+// logFollowInterval is how often a followed log file is polled for new output
+const logFollowInterval = 250 * time.Millisecond
+
+// logIndexInterval is the resolution of a log index. Output is marked at most
+// this often, so a since filter may let through output up to this much older.
+const logIndexInterval = time.Second
+
+// logIndex records, next to a log file, the time output was written at
+// offsets into it. Each line holds an offset and a Unix time in nanoseconds,
+// and output between two marks was written within logIndexInterval of the
+// first. The index is best-effort: failing to write it never fails logging.
+type logIndex struct {
+	file   *os.File
+	size   int64     // Bytes in the log file
+	marked time.Time // When the last mark was written
+}
+
+// openLogIndex opens the index for the log file at logPath for appending
+func openLogIndex(logPath string) (*logIndex, error) {
+	var size int64
+	if stat, err := os.Stat(logPath); err == nil {
+		size = stat.Size()
+	}
+	file, err := os.OpenFile(logPath+".idx", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &logIndex{file: file, size: size}, nil
+}
+
+// record marks n bytes as written now
+func (idx *logIndex) record(n int) {
+	now := time.Now()
+	if now.Sub(idx.marked) >= logIndexInterval {
+		fmt.Fprintf(idx.file, "%d %d\n", idx.size, now.UnixNano())
+		idx.marked = now
+	}
+	idx.size += int64(n)
+}
+
+// logOffsetSince returns the offset into the log file at logPath from which
+// output may have been written at or after since. Without an index, the
+// whole file is included.
+func logOffsetSince(logPath string, since time.Time) int64 {
+	f, err := os.Open(logPath + ".idx")
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	cutoff := since.Add(-logIndexInterval).UnixNano()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		offset, nanos, ok := strings.Cut(scanner.Text(), " ")
+		if !ok {
+			continue
+		}
+		off, err1 := strconv.ParseInt(offset, 10, 64)
+		ts, err2 := strconv.ParseInt(nanos, 10, 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		if ts > cutoff {
+			return off
+		}
+	}
+	// Nothing was written since
+	return math.MaxInt64
+}
+
 func newLogManager(name string, baseDir string, maxSize int64, keepFiles int) (*logManager, error) {
 	logDir := filepath.Join(baseDir, "logs", name)
 	if err := os.MkdirAll(logDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create log directory: %w", err)
 	}
 
+	// Logs are appended so a restarted process keeps its earlier output
 	stdout, err := os.OpenFile(
 		filepath.Join(logDir, "stdout.log"),
 		os.O_CREATE|os.O_APPEND|os.O_WRONLY,
@@ -32,9 +111,21 @@ func newLogManager(name string, baseDir string, maxSize int64, keepFiles int) (*
 		return nil, fmt.Errorf("failed to create stderr log: %w", err)
 	}
 
+	// Missing indexes only disable since filtering
+	stdoutIdx, err := openLogIndex(filepath.Join(logDir, "stdout.log"))
+	if err != nil {
+		stdoutIdx = nil
+	}
+	stderrIdx, err := openLogIndex(filepath.Join(logDir, "stderr.log"))
+	if err != nil {
+		stderrIdx = nil
+	}
+
 	return &logManager{
 		stdout:     stdout,
 		stderr:     stderr,
+		stdoutIdx:  stdoutIdx,
+		stderrIdx:  stderrIdx,
 		maxSize:    maxSize,
 		keepFiles:  keepFiles,
 		logDir:     logDir,
@@ -42,6 +133,78 @@ func newLogManager(name string, baseDir string, maxSize int64, keepFiles int) (*
 	}, nil
 }
 
+// logWriter feeds process output into a logManager so files can be rotated
+// underneath the running process
+type logWriter struct {
+	lm       *logManager
+	isStdout bool
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	return w.lm.write(w.isStdout, p)
+}
+
+func (lm *logManager) writer(isStdout bool) io.Writer {
+	return &logWriter{lm: lm, isStdout: isStdout}
+}
+
+func (lm *logManager) write(isStdout bool, p []byte) (int, error) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	file, idx := lm.stderr, lm.stderrIdx
+	if isStdout {
+		file, idx = lm.stdout, lm.stdoutIdx
+	}
+	if file == nil {
+		return 0, os.ErrClosed
+	}
+
+	n, err := file.Write(p)
+	if idx != nil {
+		idx.record(n)
+	}
+	if err != nil {
+		return n, err
+	}
+
+	if lm.maxSize > 0 {
+		if stat, err := file.Stat(); err == nil && stat.Size() >= lm.maxSize {
+			if err := lm.rotate(isStdout); err != nil {
+				return n, err
+			}
+		}
+	}
+
+	return n, nil
+}
+
+// Close closes the underlying log files
+func (lm *logManager) Close() error {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	var firstErr error
+	for _, file := range []**os.File{&lm.stdout, &lm.stderr} {
+		if *file == nil {
+			continue
+		}
+		if err := (*file).Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		*file = nil
+	}
+	for _, idx := range []**logIndex{&lm.stdoutIdx, &lm.stderrIdx} {
+		if *idx != nil {
+			(*idx).file.Close()
+			*idx = nil
+		}
+	}
+	return firstErr
+}
+
+// rotate moves the current log to a gzipped segment and starts a new file.
+// The caller must hold the lock.
 func (lm *logManager) rotate(isStdout bool) error {
 	var file *os.File
 	var baseName string
@@ -55,29 +218,33 @@ func (lm *logManager) rotate(isStdout bool) error {
 
 	// Close current file
 	file.Close()
+	lm.setFile(isStdout, nil)
 
-	// Rotate existing files
-	for i := lm.keepFiles - 1; i >= 0; i-- {
-		oldPath := filepath.Join(lm.logDir, baseName+"."+strconv.Itoa(i))
-		newPath := filepath.Join(lm.logDir, baseName+"."+strconv.Itoa(i+1))
-
-		// Remove oldest file if it exists
-		if i == lm.keepFiles-1 {
-			os.Remove(newPath)
-			continue
-		}
-
-		// Rename existing files
-		if _, err := os.Stat(oldPath); err == nil {
-			os.Rename(oldPath, newPath)
+	// Shift existing segments, dropping the oldest beyond keepFiles
+	if lm.keepFiles > 0 {
+		os.Remove(lm.segmentPath(baseName, lm.keepFiles-1))
+		for i := lm.keepFiles - 2; i >= 0; i-- {
+			oldPath := lm.segmentPath(baseName, i)
+			if _, err := os.Stat(oldPath); err == nil {
+				os.Rename(oldPath, lm.segmentPath(baseName, i+1))
+			}
 		}
 	}
 
-	// Rename current log file
+	// On failure the current file is reopened, so output keeps going to it
+	// rather than to a closed file
 	currentPath := filepath.Join(lm.logDir, baseName)
-	newPath := filepath.Join(lm.logDir, baseName+".0")
-	if err := os.Rename(currentPath, newPath); err != nil {
-		return fmt.Errorf("failed to rotate log file: %w", err)
+	if lm.keepFiles > 0 {
+		if err := compressFile(currentPath, lm.segmentPath(baseName, 0)); err != nil {
+			return lm.reopen(isStdout, currentPath, fmt.Errorf("failed to rotate log file: %w", err))
+		}
+	}
+	if err := os.Remove(currentPath); err != nil {
+		// Don't keep a segment duplicating the file that's still current
+		if lm.keepFiles > 0 {
+			os.Remove(lm.segmentPath(baseName, 0))
+		}
+		return lm.reopen(isStdout, currentPath, fmt.Errorf("failed to rotate log file: %w", err))
 	}
 
 	// Create new log file
@@ -86,30 +253,242 @@ func (lm *logManager) rotate(isStdout bool) error {
 		return fmt.Errorf("failed to create new log file: %w", err)
 	}
 
-	// Update file pointer
+	lm.setFile(isStdout, newFile)
+	lm.currentGen++
+
+	// Start a fresh index for the new file
+	idx := &lm.stderrIdx
 	if isStdout {
-		lm.stdout = newFile
-	} else {
-		lm.stderr = newFile
+		idx = &lm.stdoutIdx
+	}
+	if *idx != nil {
+		(*idx).file.Close()
+	}
+	os.Remove(currentPath + ".idx")
+	*idx, err = openLogIndex(currentPath)
+	if err != nil {
+		*idx = nil
 	}
 
 	return nil
 }
 
-func (lm *logManager) checkRotate() error {
-	// Check stdout size
-	if stat, err := lm.stdout.Stat(); err == nil && stat.Size() > lm.maxSize {
-		if err := lm.rotate(true); err != nil {
-			return err
+// reopen reopens the current log file for appending after a failed rotation
+// and returns err. The caller must hold the lock.
+func (lm *logManager) reopen(isStdout bool, path string, err error) error {
+	file, openErr := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if openErr != nil {
+		return errors.Join(err, fmt.Errorf("failed to reopen log file: %w", openErr))
+	}
+	lm.setFile(isStdout, file)
+	return err
+}
+
+// setFile replaces the stdout or stderr file. The caller must hold the lock.
+func (lm *logManager) setFile(isStdout bool, file *os.File) {
+	if isStdout {
+		lm.stdout = file
+	} else {
+		lm.stderr = file
+	}
+}
+
+func (lm *logManager) segmentPath(baseName string, i int) string {
+	return filepath.Join(lm.logDir, baseName+"."+strconv.Itoa(i)+".gz")
+}
+
+func compressFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmpPath := dst + ".tmp"
+	out, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(out)
+	if _, err := io.Copy(zw, in); err != nil {
+		out.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		out.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, dst)
+}
+
+// tailLines returns up to n of the last lines in the file at path
+func tailLines(path string, n int) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if n > 0 && len(lines) > n {
+			lines = lines[1:]
 		}
 	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// GetLogs returns up to lines of the most recent stdout and stderr output of
+// a binary. It reads from disk, so it works whether or not the binary is
+// running. A non-positive lines returns the whole current log file.
+func (r *Runtime) GetLogs(name string, lines int) (stdout []string, stderr []string, err error) {
+	logDir := filepath.Join(r.baseDir, "logs", name)
+	if _, err := os.Stat(logDir); err != nil {
+		return nil, nil, fmt.Errorf("no logs for %s: %w", name, err)
+	}
+
+	stdout, err = tailLines(filepath.Join(logDir, "stdout.log"), lines)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("failed to read stdout log: %w", err)
+	}
 
-	// Check stderr size
-	if stat, err := lm.stderr.Stat(); err == nil && stat.Size() > lm.maxSize {
-		if err := lm.rotate(false); err != nil {
+	stderr, err = tailLines(filepath.Join(logDir, "stderr.log"), lines)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("failed to read stderr log: %w", err)
+	}
+
+	return stdout, stderr, nil
+}
+
+// StreamLogs sends up to tail of the most recent lines of a binary's stdout
+// (or stderr) log to send, then, if follow is set, keeps sending new lines
+// until ctx is done. Lines are read from disk only as fast as send accepts
+// them, so a slow consumer does not cause output to pile up in memory.
+// Rotation is detected and the new log file is followed from its start.
+// A non-zero since skips existing lines written before it, to within
+// logIndexInterval, before tail is applied.
+func (r *Runtime) StreamLogs(ctx context.Context, name string, tail int, since time.Time, follow, stderr bool, send func(line string) error) error {
+	baseName := "stdout.log"
+	if stderr {
+		baseName = "stderr.log"
+	}
+	path := filepath.Join(r.baseDir, "logs", name, baseName)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("no logs for %s: %w", name, err)
+	}
+	defer func() { f.Close() }()
+
+	opened, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat log: %w", err)
+	}
+
+	var start int64
+	if !since.IsZero() {
+		start = logOffsetSince(path, since)
+	}
+
+	// Send the existing tail first
+	reader := bufio.NewReader(f)
+	var (
+		backlog []string
+		pending string
+		offset  int64
+	)
+	for {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF {
+			pending = line
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read log: %w", err)
+		}
+		offset += int64(len(line))
+		if offset <= start {
+			continue
+		}
+		backlog = append(backlog, strings.TrimSuffix(line, "\n"))
+		if tail > 0 && len(backlog) > tail {
+			backlog = backlog[1:]
+		}
+	}
+	for _, line := range backlog {
+		if err := send(line); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	if !follow {
+		return nil
+	}
+
+	ticker := time.NewTicker(logFollowInterval)
+	defer ticker.Stop()
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err == nil {
+			if err := send(strings.TrimSuffix(pending+line, "\n")); err != nil {
+				return err
+			}
+			pending = ""
+			continue
+		}
+		if err != io.EOF {
+			return fmt.Errorf("failed to read log: %w", err)
+		}
+		pending += line
+
+		// Switch over if the file was rotated, after draining anything
+		// written to it between the last read and the rotation
+		if current, err := os.Stat(path); err == nil && !os.SameFile(opened, current) {
+			next, err := os.Open(path)
+			if err == nil {
+				rest, err := io.ReadAll(reader)
+				if err != nil {
+					next.Close()
+					return fmt.Errorf("failed to read log: %w", err)
+				}
+				pending += string(rest)
+				for {
+					i := strings.IndexByte(pending, '\n')
+					if i < 0 {
+						break
+					}
+					if err := send(pending[:i]); err != nil {
+						next.Close()
+						return err
+					}
+					pending = pending[i+1:]
+				}
+				f.Close()
+				f, opened, reader = next, current, bufio.NewReader(next)
+				continue
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
 }
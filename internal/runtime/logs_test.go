@@ -0,0 +1,278 @@
+package runtime
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogRotation(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	lm, err := newLogManager("app", tmpDir, 100, 2)
+	if err != nil {
+		t.Fatalf("Failed to create log manager: %v", err)
+	}
+	defer lm.Close()
+
+	w := lm.writer(true)
+	line := strings.Repeat("x", 59) + "\n"
+	for i := 0; i < 10; i++ {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatalf("Failed to write log line: %v", err)
+		}
+	}
+
+	logDir := filepath.Join(tmpDir, "logs", "app")
+	for _, name := range []string{"stdout.log.0.gz", "stdout.log.1.gz"} {
+		f, err := os.Open(filepath.Join(logDir, name))
+		if err != nil {
+			t.Fatalf("Expected rotated segment %s: %v", name, err)
+		}
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatalf("Segment %s is not gzipped: %v", name, err)
+		}
+		data, err := io.ReadAll(zr)
+		f.Close()
+		if err != nil {
+			t.Fatalf("Failed to read segment %s: %v", name, err)
+		}
+		if string(data) != line+line {
+			t.Errorf("Unexpected contents in %s: %q", name, data)
+		}
+	}
+
+	// Retention keeps only LogRotateKeep segments
+	if _, err := os.Stat(filepath.Join(logDir, "stdout.log.2.gz")); !os.IsNotExist(err) {
+		t.Errorf("Expected only 2 rotated segments to be kept")
+	}
+
+	stat, err := os.Stat(filepath.Join(logDir, "stdout.log"))
+	if err != nil {
+		t.Fatalf("Failed to stat current log: %v", err)
+	}
+	if stat.Size() >= 100 {
+		t.Errorf("Expected current log below rotation threshold, got %d bytes", stat.Size())
+	}
+}
+
+func TestLogRotationFailure(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	lm, err := newLogManager("app", tmpDir, 100, 2)
+	if err != nil {
+		t.Fatalf("Failed to create log manager: %v", err)
+	}
+	defer lm.Close()
+
+	// Make the log directory unwritable. Root ignores the mode, so the
+	// segment's temporary path is also taken by a directory.
+	logDir := filepath.Join(tmpDir, "logs", "app")
+	if err := os.Mkdir(filepath.Join(logDir, "stdout.log.0.gz.tmp"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(logDir, 0555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(logDir, 0755) })
+
+	w := lm.writer(true)
+	line := strings.Repeat("x", 59) + "\n"
+	w.Write([]byte(line))
+	if _, err := w.Write([]byte(line)); err == nil {
+		t.Fatal("Expected rotation to fail")
+	}
+
+	// Output keeps going to the current file
+	w.Write([]byte(line))
+	data, err := os.ReadFile(filepath.Join(logDir, "stdout.log"))
+	if err != nil {
+		t.Fatalf("Failed to read current log: %v", err)
+	}
+	if string(data) != line+line+line {
+		t.Errorf("Expected all output in the current log, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(logDir, "stdout.log.0.gz")); !os.IsNotExist(err) {
+		t.Errorf("Expected no segment after a failed rotation")
+	}
+
+	// Rotation succeeds once the directory is writable again
+	os.Chmod(logDir, 0755)
+	os.Remove(filepath.Join(logDir, "stdout.log.0.gz.tmp"))
+	if _, err := w.Write([]byte(line)); err != nil {
+		t.Fatalf("Expected rotation to succeed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(logDir, "stdout.log.0.gz")); err != nil {
+		t.Errorf("Expected rotated segment: %v", err)
+	}
+}
+
+func TestLogsAppendAcrossRestarts(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	r, err := New(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create runtime: %v", err)
+	}
+
+	for _, msg := range []string{"first run\n", "second run\n"} {
+		lm, err := newLogManager("app", tmpDir, 0, 0)
+		if err != nil {
+			t.Fatalf("Failed to create log manager: %v", err)
+		}
+		lm.writer(true).Write([]byte(msg))
+		lm.writer(false).Write([]byte("err: " + msg))
+		lm.Close()
+	}
+
+	stdout, stderr, err := r.GetLogs("app", 1)
+	if err != nil {
+		t.Fatalf("Failed to get logs: %v", err)
+	}
+	if len(stdout) != 1 || stdout[0] != "second run" {
+		t.Errorf("Expected last stdout line, got %v", stdout)
+	}
+	if len(stderr) != 1 || stderr[0] != "err: second run" {
+		t.Errorf("Expected last stderr line, got %v", stderr)
+	}
+
+	stdout, _, err = r.GetLogs("app", 0)
+	if err != nil {
+		t.Fatalf("Failed to get logs: %v", err)
+	}
+	if !bytes.Equal([]byte(strings.Join(stdout, "\n")), []byte("first run\nsecond run")) {
+		t.Errorf("Expected both runs in stdout, got %v", stdout)
+	}
+
+	if _, _, err := r.GetLogs("missing", 10); err == nil {
+		t.Error("Expected error for binary without logs")
+	}
+}
+
+func TestStreamLogsFollow(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	r, err := New(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create runtime: %v", err)
+	}
+
+	// Rotate every few lines so following has to cross file boundaries
+	lm, err := newLogManager("app", tmpDir, 30, 1)
+	if err != nil {
+		t.Fatalf("Failed to create log manager: %v", err)
+	}
+	defer lm.Close()
+	w := lm.writer(true)
+
+	w.Write([]byte("line 0\nline 1\n"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := make(chan string, 100)
+	done := make(chan error, 1)
+	go func() {
+		done <- r.StreamLogs(ctx, "app", 1, time.Time{}, true, false, func(line string) error {
+			received <- line
+			return nil
+		})
+	}()
+
+	expectLine := func(want string) {
+		t.Helper()
+		select {
+		case got := <-received:
+			if got != want {
+				t.Fatalf("Expected %q, got %q", want, got)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out waiting for %q", want)
+		}
+	}
+
+	// Only the requested tail is replayed
+	expectLine("line 1")
+
+	for i := 2; i < 12; i++ {
+		w.Write([]byte(fmt.Sprintf("line %d\n", i)))
+		expectLine(fmt.Sprintf("line %d", i))
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("StreamLogs returned error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("StreamLogs did not return after cancel")
+	}
+}
+
+func TestStreamLogsSince(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	r, err := New(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create runtime: %v", err)
+	}
+
+	lm, err := newLogManager("app", tmpDir, 0, 0)
+	if err != nil {
+		t.Fatalf("Failed to create log manager: %v", err)
+	}
+	defer lm.Close()
+	w := lm.writer(true)
+
+	w.Write([]byte("old 1\nold 2\n"))
+	time.Sleep(logIndexInterval + 100*time.Millisecond)
+	since := time.Now()
+	w.Write([]byte("new 1\nnew 2\n"))
+
+	var lines []string
+	err = r.StreamLogs(context.Background(), "app", 0, since, false, false, func(line string) error {
+		lines = append(lines, line)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamLogs failed: %v", err)
+	}
+	if strings.Join(lines, ",") != "new 1,new 2" {
+		t.Errorf("Expected only lines since %v, got %v", since, lines)
+	}
+
+	// Nothing was written after a later since
+	lines = nil
+	err = r.StreamLogs(context.Background(), "app", 0, time.Now().Add(time.Hour), false, false, func(line string) error {
+		lines = append(lines, line)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamLogs failed: %v", err)
+	}
+	if len(lines) != 0 {
+		t.Errorf("Expected no lines, got %v", lines)
+	}
+
+	// Tail applies to what since lets through
+	lines = nil
+	err = r.StreamLogs(context.Background(), "app", 1, since, false, false, func(line string) error {
+		lines = append(lines, line)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamLogs failed: %v", err)
+	}
+	if strings.Join(lines, ",") != "new 2" {
+		t.Errorf("Expected the last line since %v, got %v", since, lines)
+	}
+}
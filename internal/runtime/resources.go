@@ -31,15 +31,19 @@ func (r *Runtime) monitorResources(ctx context.Context, name string, proc *manag
 				continue
 			}
 
-			// Update process stats
-			proc.stats = stats
+			// Update process stats, keeping its limits
+			proc.mu.Lock()
+			proc.stats.cpu = stats.cpu
+			proc.stats.memory = stats.memory
+			err = enforceResourceLimits(proc)
+			proc.mu.Unlock()
 
 			// Check limits
-			if err := enforceResourceLimits(proc); err != nil {
+			if err != nil {
 				r.logger.Error("Resource limit exceeded",
 					"process", name,
-					"cpu", proc.stats.cpu,
-					"memory", proc.stats.memory,
+					"cpu", stats.cpu,
+					"memory", stats.memory,
 					"error", err)
 				proc.cancel() // Stop the process
 				return
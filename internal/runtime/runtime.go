@@ -2,6 +2,8 @@ package runtime
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"log/slog"
@@ -11,6 +13,8 @@ import (
 	"sync"
 	"syscall"
 	"time"
+
+	"fleetd.sh/internal/update"
 )
 
 // Enhanced Runtime implementation
@@ -19,22 +23,32 @@ type Runtime struct {
 	processes map[string]*managedProcess
 	baseDir   string
 	logger    *slog.Logger
+	secrets   SecretStore
+	publicKey ed25519.PublicKey
 }
 
 type managedProcess struct {
-	process *os.Process
-	cmd     *exec.Cmd
-	cancel  context.CancelFunc
-	health  *health
-	logs    *logManager
-	stats   *resourceStats
+	process   *os.Process
+	cmd       *exec.Cmd
+	cancel    context.CancelFunc
+	logs      *logManager
+	startedAt time.Time
+	stopping  bool // Guarded by Runtime.mu
+
+	// mu guards health and stats, which the monitor goroutines update
+	mu     sync.Mutex
+	health *health
+	stats  *resourceStats
 }
 
 type Config struct {
-	MaxLogSize    int64           // Maximum size of log files in bytes
-	LogRotateKeep int             // Number of rotated log files to keep
+	MaxLogSize    int64           // Maximum size of log files in bytes, 0 disables rotation
+	LogRotateKeep int             // Number of rotated (gzipped) log files to keep
 	HealthCheck   *HealthConfig   // Health check configuration
 	Resources     *ResourceConfig // Resource limits
+	// Environment is added to the agent's environment. Values may reference
+	// secrets as ${secret:name}, which are resolved when the process starts.
+	Environment map[string]string
 }
 
 type HealthConfig struct {
@@ -63,8 +77,11 @@ type HealthChecker interface {
 }
 
 type logManager struct {
+	mu         sync.Mutex
 	stdout     *os.File
 	stderr     *os.File
+	stdoutIdx  *logIndex
+	stderrIdx  *logIndex
 	maxSize    int64
 	keepFiles  int
 	logDir     string
@@ -93,8 +110,17 @@ func New(baseDir string) (*Runtime, error) {
 	}, nil
 }
 
-// Deploy installs a new binary
-func (r *Runtime) Deploy(name string, binary io.Reader) error {
+// SetPublicKey sets the key used to verify binary signatures. Once a key is
+// set, Deploy refuses binaries without a valid signature.
+func (r *Runtime) SetPublicKey(key ed25519.PublicKey) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.publicKey = key
+}
+
+// Deploy installs a new binary. signature is the base64 Ed25519 signature of
+// the binary's SHA256 digest, which is required once a public key is set.
+func (r *Runtime) Deploy(name string, binary io.Reader, signature string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -113,9 +139,10 @@ func (r *Runtime) Deploy(name string, binary io.Reader) error {
 	}
 	defer f.Close()
 
-	// Copy binary data
+	// Copy binary data, hashing it for the signature check
 	r.logger.Debug("Copying binary data")
-	written, err := io.Copy(f, binary)
+	hash := sha256.New()
+	written, err := io.Copy(io.MultiWriter(f, hash), binary)
 	if err != nil {
 		os.Remove(tmpPath)
 		return fmt.Errorf("failed to write binary: %w", err)
@@ -134,6 +161,12 @@ func (r *Runtime) Deploy(name string, binary io.Reader) error {
 		return fmt.Errorf("failed to close temporary file: %w", err)
 	}
 
+	// Nothing unverified replaces the installed binary
+	if err := update.VerifySignature(r.publicKey, hash.Sum(nil), signature); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to verify binary: %w", err)
+	}
+
 	// Rename temporary file to final location
 	r.logger.Debug("Renaming temporary file to final location")
 	if err := os.Rename(tmpPath, binPath); err != nil {
@@ -164,6 +197,11 @@ func (r *Runtime) Start(name string, args []string, config *Config) error {
 		return fmt.Errorf("binary not found: %w", err)
 	}
 
+	env, err := expandEnvironment(config.Environment, r.secrets)
+	if err != nil {
+		return err
+	}
+
 	// Setup logging
 	logManager, err := newLogManager(name, r.baseDir, config.MaxLogSize, config.LogRotateKeep)
 	if err != nil {
@@ -172,11 +210,15 @@ func (r *Runtime) Start(name string, args []string, config *Config) error {
 
 	ctx, cancel := context.WithCancel(context.Background())
 	cmd := exec.CommandContext(ctx, binPath, args...)
-	cmd.Stdout = logManager.stdout
-	cmd.Stderr = logManager.stderr
+	cmd.Stdout = logManager.writer(true)
+	cmd.Stderr = logManager.writer(false)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 
 	if err := cmd.Start(); err != nil {
 		cancel()
+		logManager.Close()
 		return fmt.Errorf("failed to start process: %w", err)
 	}
 
@@ -191,8 +233,9 @@ func (r *Runtime) Start(name string, args []string, config *Config) error {
 			},
 			maxFailures: config.HealthCheck.MaxFailures,
 		},
-		logs:  logManager,
-		stats: &resourceStats{limits: config.Resources},
+		logs:      logManager,
+		stats:     &resourceStats{limits: config.Resources},
+		startedAt: time.Now(),
 	}
 
 	r.processes[name] = proc
@@ -204,6 +247,7 @@ func (r *Runtime) Start(name string, args []string, config *Config) error {
 	// Monitor process
 	go func() {
 		cmd.Wait()
+		logManager.Close()
 		r.mu.Lock()
 		delete(r.processes, name)
 		r.mu.Unlock()
@@ -226,6 +270,7 @@ func (r *Runtime) Stop(name string) error {
 		return fmt.Errorf("process not found: %s", name)
 	}
 
+	proc.stopping = true
 	if proc.cancel != nil {
 		proc.cancel()
 	}
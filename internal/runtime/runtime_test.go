@@ -2,6 +2,10 @@ package runtime
 
 import (
 	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -18,7 +22,7 @@ func TestRuntime(t *testing.T) {
 
 	// Test binary deployment
 	testBinary := []byte("#!/bin/sh\necho 'test'")
-	err = rt.Deploy("test.sh", bytes.NewReader(testBinary))
+	err = rt.Deploy("test.sh", bytes.NewReader(testBinary), "")
 	if err != nil {
 		t.Fatalf("Failed to deploy binary: %v", err)
 	}
@@ -77,7 +81,7 @@ while true; do
   sleep 0.1
 done
 `)
-	if err := r.Deploy("test-script", bytes.NewReader(testScript)); err != nil {
+	if err := r.Deploy("test-script", bytes.NewReader(testScript), ""); err != nil {
 		t.Fatalf("Failed to deploy test script: %v", err)
 	}
 
@@ -120,3 +124,175 @@ done
 		t.Error("Expected script to not be running after stop")
 	}
 }
+
+func TestListProcesses(t *testing.T) {
+	r, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create runtime: %v", err)
+	}
+
+	testScript := []byte("#!/bin/sh\necho started\nwhile true; do\n  sleep 0.1\ndone\n")
+	for _, name := range []string{"app-b", "app-a"} {
+		if err := r.Deploy(name, bytes.NewReader(testScript), ""); err != nil {
+			t.Fatalf("Failed to deploy %s: %v", name, err)
+		}
+		if err := r.Start(name, []string{}, &Config{}); err != nil {
+			t.Fatalf("Failed to start %s: %v", name, err)
+		}
+	}
+	defer r.Stop("app-b")
+
+	time.Sleep(50 * time.Millisecond) // Give processes time to start
+
+	procs := r.ListProcesses()
+	if len(procs) != 2 || procs[0].Name != "app-a" || procs[1].Name != "app-b" {
+		t.Fatalf("Expected app-a and app-b, got %+v", procs)
+	}
+	for _, p := range procs {
+		if p.State != ProcessRunning {
+			t.Errorf("Expected %s to be running, got %s", p.Name, p.State)
+		}
+		if p.PID <= 0 {
+			t.Errorf("Expected %s to have a PID, got %d", p.Name, p.PID)
+		}
+		if p.Uptime <= 0 {
+			t.Errorf("Expected %s to have an uptime, got %v", p.Name, p.Uptime)
+		}
+	}
+
+	detail, err := r.DescribeProcess("app-a", 10)
+	if err != nil {
+		t.Fatalf("Failed to describe app-a: %v", err)
+	}
+	if detail.PID != procs[0].PID {
+		t.Errorf("Expected PID %d, got %d", procs[0].PID, detail.PID)
+	}
+	if len(detail.Stdout) != 1 || detail.Stdout[0] != "started" {
+		t.Errorf("Expected stdout [started], got %v", detail.Stdout)
+	}
+
+	// A stopped process disappears once it has exited
+	if err := r.Stop("app-a"); err != nil {
+		t.Fatalf("Failed to stop app-a: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		procs = r.ListProcesses()
+		if len(procs) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected app-a to disappear, got %+v", procs)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if procs[0].Name != "app-b" {
+		t.Errorf("Expected app-b to remain, got %s", procs[0].Name)
+	}
+	if _, err := r.DescribeProcess("app-a", 10); err == nil {
+		t.Error("Expected describing a stopped process to fail")
+	}
+}
+
+func TestSecretEnvironment(t *testing.T) {
+	r, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create runtime: %v", err)
+	}
+	secretsDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(secretsDir, "db_password"), []byte("s3cret\n"), 0600); err != nil {
+		t.Fatalf("Failed to write secret: %v", err)
+	}
+	r.SetSecretStore(NewDirSecretStore(secretsDir))
+
+	testScript := []byte("#!/bin/sh\necho \"$DB_URL\"\nwhile true; do\n  sleep 0.1\ndone\n")
+	if err := r.Deploy("app", bytes.NewReader(testScript), ""); err != nil {
+		t.Fatalf("Failed to deploy app: %v", err)
+	}
+
+	// An unresolved reference aborts startup
+	err = r.Start("app", []string{}, &Config{
+		Environment: map[string]string{"DB_URL": "postgres://app:${secret:missing}@db"},
+	})
+	if !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound, got %v", err)
+	}
+	if procs := r.ListProcesses(); len(procs) != 0 {
+		t.Fatalf("Expected app not to start, got %+v", procs)
+	}
+
+	if err := r.Start("app", []string{}, &Config{
+		Environment: map[string]string{"DB_URL": "postgres://app:${secret:db_password}@db"},
+	}); err != nil {
+		t.Fatalf("Failed to start app: %v", err)
+	}
+	defer r.Stop("app")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		stdout, _, err := r.GetLogs("app", 1)
+		if err == nil && len(stdout) == 1 {
+			if stdout[0] != "postgres://app:s3cret@db" {
+				t.Errorf("Expected the secret in the environment, got %q", stdout[0])
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for app output")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDeploySignature(t *testing.T) {
+	dir := t.TempDir()
+	r, err := New(dir)
+	if err != nil {
+		t.Fatalf("Failed to create runtime: %v", err)
+	}
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	r.SetPublicKey(publicKey)
+
+	binary := []byte("#!/bin/sh\necho 'signed'\n")
+	digest := sha256.Sum256(binary)
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, digest[:]))
+	binPath := filepath.Join(dir, "app")
+
+	tests := []struct {
+		name      string
+		binary    []byte
+		signature string
+		wantErr   bool
+	}{
+		{name: "unsigned", binary: binary, wantErr: true},
+		{name: "tampered", binary: []byte("#!/bin/sh\necho 'tampered'\n"), signature: signature, wantErr: true},
+		{name: "signed", binary: binary, signature: signature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := r.Deploy("app", bytes.NewReader(tt.binary), tt.signature)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Expected deploy to be refused")
+				}
+				if _, err := os.Stat(binPath); !os.IsNotExist(err) {
+					t.Error("Refused binary was installed")
+				}
+				if _, err := os.Stat(binPath + ".tmp"); !os.IsNotExist(err) {
+					t.Error("Refused binary was left behind")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to deploy signed binary: %v", err)
+			}
+			installed, err := os.ReadFile(binPath)
+			if err != nil || !bytes.Equal(installed, binary) {
+				t.Errorf("Expected the signed binary to be installed, got %q (%v)", installed, err)
+			}
+		})
+	}
+}
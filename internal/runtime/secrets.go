@@ -0,0 +1,94 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ErrSecretNotFound is returned by a SecretStore for an unknown secret
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretStore resolves the secrets that process environments reference, so
+// they don't have to be shipped in plaintext with the binary's config
+type SecretStore interface {
+	Secret(name string) (string, error)
+}
+
+// dirSecretStore reads each secret from a file named after it
+type dirSecretStore struct {
+	dir string
+}
+
+// NewDirSecretStore creates a SecretStore over a directory provisioned with
+// one file per secret. A trailing newline in a file is not part of the
+// secret.
+func NewDirSecretStore(dir string) SecretStore {
+	return dirSecretStore{dir: dir}
+}
+
+func (s dirSecretStore) Secret(name string) (string, error) {
+	// Names must not escape the directory
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid secret name %q", name)
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrSecretNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r"), nil
+}
+
+// SetSecretStore sets the store ${secret:name} references are resolved
+// from. Without one, processes that reference secrets fail to start.
+func (r *Runtime) SetSecretStore(s SecretStore) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.secrets = s
+}
+
+var secretRef = regexp.MustCompile(`\$\{secret:([^}]*)\}`)
+
+// expandEnvironment resolves the secret references in env and returns it as
+// KEY=value pairs, ordered by key. It fails if any reference can't be
+// resolved, so a process never starts with a partial environment.
+func expandEnvironment(env map[string]string, secrets SecretStore) ([]string, error) {
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(env))
+	for _, key := range keys {
+		var resolveErr error
+		value := secretRef.ReplaceAllStringFunc(env[key], func(ref string) string {
+			if resolveErr != nil {
+				return ""
+			}
+			name := secretRef.FindStringSubmatch(ref)[1]
+			if secrets == nil {
+				resolveErr = fmt.Errorf("failed to resolve secret %q for %s: no secret store configured", name, key)
+				return ""
+			}
+			secret, err := secrets.Secret(name)
+			if err != nil {
+				resolveErr = fmt.Errorf("failed to resolve secret %q for %s: %w", name, key, err)
+				return ""
+			}
+			return secret
+		})
+		if resolveErr != nil {
+			return nil, resolveErr
+		}
+		pairs = append(pairs, key+"="+value)
+	}
+	return pairs, nil
+}
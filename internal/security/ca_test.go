@@ -0,0 +1,80 @@
+package security
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseCert(t *testing.T, certPEM []byte) *x509.Certificate {
+	block, _ := pem.Decode(certPEM)
+	require.NotNil(t, block)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	return cert
+}
+
+func TestIssueDeviceCert(t *testing.T) {
+	ca, err := NewCA()
+	require.NoError(t, err)
+
+	issued, err := ca.IssueDeviceCert("device-123")
+	require.NoError(t, err)
+
+	cert := parseCert(t, issued.CertPEM)
+	_, err = cert.Verify(x509.VerifyOptions{
+		Roots:     ca.Pool(),
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	require.NoError(t, err)
+
+	deviceID, err := DeviceIDFromCert(cert)
+	require.NoError(t, err)
+	assert.Equal(t, "device-123", deviceID)
+	assert.Equal(t, "device-123", cert.Subject.CommonName)
+	assert.WithinDuration(t, time.Now().Add(DeviceCertLifetime), cert.NotAfter, time.Minute)
+
+	// The bundle can be loaded directly as a TLS client certificate
+	_, err = tls.X509KeyPair(issued.CertPEM, issued.KeyPEM)
+	require.NoError(t, err)
+	assert.Equal(t, ca.CertPEM(), issued.CAPEM)
+
+	_, err = ca.IssueDeviceCert("")
+	assert.Error(t, err)
+}
+
+func TestLoadCA(t *testing.T) {
+	ca, err := NewCA()
+	require.NoError(t, err)
+	keyPEM, err := ca.KeyPEM()
+	require.NoError(t, err)
+
+	loaded, err := LoadCA(ca.CertPEM(), keyPEM)
+	require.NoError(t, err)
+
+	// Certificates issued by the reloaded CA verify against the original
+	issued, err := loaded.IssueDeviceCert("device-456")
+	require.NoError(t, err)
+	_, err = parseCert(t, issued.CertPEM).Verify(x509.VerifyOptions{
+		Roots:     ca.Pool(),
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	require.NoError(t, err)
+
+	// Device certificates from another CA do not verify
+	other, err := NewCA()
+	require.NoError(t, err)
+	_, err = parseCert(t, issued.CertPEM).Verify(x509.VerifyOptions{
+		Roots:     other.Pool(),
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	assert.Error(t, err)
+
+	_, err = LoadCA(issued.CertPEM, issued.KeyPEM)
+	assert.Error(t, err, "device certificates are not CAs")
+}
@@ -3,6 +3,7 @@ package state
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"sync"
@@ -20,6 +21,9 @@ type State struct {
 	// DeviceInfo contains static device information
 	DeviceInfo DeviceInfo `json:"deviceInfo"`
 
+	// Configuration is the setup applied through device discovery, if any
+	Configuration *Configuration `json:"configuration,omitempty"`
+
 	// RuntimeState contains dynamic state information
 	RuntimeState RuntimeState `json:"runtime_state"`
 
@@ -37,10 +41,32 @@ type DeviceInfo struct {
 	FirstSeenTime time.Time         `json:"firstSeenTime"`
 }
 
+type Configuration struct {
+	DeviceName   string    `json:"deviceName"`
+	APIEndpoint  string    `json:"apiEndpoint"`
+	FleetID      string    `json:"fleetId,omitempty"`
+	WiFiSSID     string    `json:"wifiSsid,omitempty"`
+	ConfiguredAt time.Time `json:"configuredAt"`
+}
+
+// Secrets holds credentials, kept out of State in a file only the agent's
+// user can read
+type Secrets struct {
+	WiFiPassword string `json:"wifiPassword,omitempty"`
+}
+
+// LogValue implements slog.LogValuer so secrets never reach log output
+func (Secrets) LogValue() slog.Value {
+	return slog.StringValue("[REDACTED]")
+}
+
 type RuntimeState struct {
 	DeployedBinaries map[string]BinaryInfo `json:"deployed_binaries"`
 	LastHealthCheck  time.Time             `json:"lastHealthCheck"`
 	Status           string                `json:"status"`
+	// TelemetryBacklog is the number of undelivered metrics. It's filled in
+	// by the agent when read, not persisted.
+	TelemetryBacklog int `json:"-"`
 }
 
 type BinaryInfo struct {
@@ -60,10 +86,12 @@ type UpdateRecord struct {
 
 // Manager handles persistent state storage
 type Manager struct {
-	path       string
-	backupPath string
-	state      *State
-	mu         sync.RWMutex
+	path        string
+	backupPath  string
+	secretsPath string
+	state       *State
+	secrets     Secrets
+	mu          sync.RWMutex
 }
 
 // New creates a new state manager
@@ -75,8 +103,9 @@ func New(path string) (*Manager, error) {
 	}
 
 	m := &Manager{
-		path:       path,
-		backupPath: path + ".bak",
+		path:        path,
+		backupPath:  path + ".bak",
+		secretsPath: filepath.Join(dir, "secrets.json"),
 		state: &State{
 			RuntimeState: RuntimeState{
 				DeployedBinaries: make(map[string]BinaryInfo),
@@ -84,6 +113,10 @@ func New(path string) (*Manager, error) {
 		},
 	}
 
+	if err := m.loadSecrets(); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to load secrets: %w", err)
+	}
+
 	// Try to load existing state
 	if err := m.load(); err != nil && !os.IsNotExist(err) {
 		return nil, fmt.Errorf("failed to load state: %w", err)
@@ -102,6 +135,25 @@ func (m *Manager) Get() State {
 	return stateCopy
 }
 
+// Secrets returns a copy of the stored secrets
+func (m *Manager) Secrets() Secrets {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.secrets
+}
+
+// UpdateSecrets atomically updates the secrets
+func (m *Manager) UpdateSecrets(fn func(*Secrets) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := fn(&m.secrets); err != nil {
+		return err
+	}
+
+	return m.saveSecrets()
+}
+
 // Update atomically updates the state
 func (m *Manager) Update(fn func(*State) error) error {
 	m.mu.Lock()
@@ -124,8 +176,62 @@ func (m *Manager) load() error {
 	if err := json.Unmarshal(data, &state); err != nil {
 		return fmt.Errorf("failed to parse state: %w", err)
 	}
-
 	m.state = &state
+
+	// Earlier versions stored the WiFi password in the state file
+	var legacy struct {
+		Configuration *struct {
+			WiFiPassword string `json:"wifiPassword"`
+		} `json:"configuration"`
+	}
+	if err := json.Unmarshal(data, &legacy); err == nil && legacy.Configuration != nil && legacy.Configuration.WiFiPassword != "" {
+		if m.secrets.WiFiPassword == "" {
+			m.secrets.WiFiPassword = legacy.Configuration.WiFiPassword
+			if err := m.saveSecrets(); err != nil {
+				return err
+			}
+		}
+		if err := m.save(); err != nil {
+			return err
+		}
+		// The backup is the file that had the password
+		if err := os.Remove(m.backupPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove backup: %w", err)
+		}
+	}
+	return nil
+}
+
+func (m *Manager) loadSecrets() error {
+	data, err := os.ReadFile(m.secretsPath)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &m.secrets); err != nil {
+		return fmt.Errorf("failed to parse secrets: %w", err)
+	}
+	return nil
+}
+
+// saveSecrets writes the secrets readable only by the owner. The caller must
+// hold the lock.
+func (m *Manager) saveSecrets() error {
+	data, err := json.MarshalIndent(m.secrets, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal secrets: %w", err)
+	}
+
+	// Create the temporary file with the final mode, so the secrets are never
+	// readable by others
+	tmpPath := m.secretsPath + ".tmp"
+	os.Remove(tmpPath)
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write secrets: %w", err)
+	}
+	if err := os.Rename(tmpPath, m.secretsPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to save secrets: %w", err)
+	}
 	return nil
 }
 
@@ -1,9 +1,12 @@
 package state
 
 import (
+	"bytes"
 	"encoding/json"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -69,3 +72,91 @@ func TestStateManager(t *testing.T) {
 		t.Errorf("Expected current version to be 2, got %d", currentState.Version)
 	}
 }
+
+func TestSecrets(t *testing.T) {
+	tmpDir := t.TempDir()
+	statePath := filepath.Join(tmpDir, "state.json")
+
+	manager, err := New(statePath)
+	if err != nil {
+		t.Fatalf("Failed to create state manager: %v", err)
+	}
+	if err := manager.Update(func(s *State) error {
+		s.Configuration = &Configuration{WiFiSSID: "office"}
+		return nil
+	}); err != nil {
+		t.Fatalf("Failed to update state: %v", err)
+	}
+	if err := manager.UpdateSecrets(func(s *Secrets) error {
+		s.WiFiPassword = "correct horse"
+		return nil
+	}); err != nil {
+		t.Fatalf("Failed to update secrets: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(tmpDir, "secrets.json"))
+	if err != nil {
+		t.Fatalf("Secrets file was not created: %v", err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("Expected secrets file mode 0600, got %o", mode)
+	}
+
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		t.Fatalf("Failed to read state file: %v", err)
+	}
+	if strings.Contains(string(data), "correct horse") {
+		t.Error("State file contains the WiFi password")
+	}
+
+	// Secrets survive a restart
+	manager, err = New(statePath)
+	if err != nil {
+		t.Fatalf("Failed to reload state manager: %v", err)
+	}
+	if got := manager.Secrets().WiFiPassword; got != "correct horse" {
+		t.Errorf("Expected WiFi password to be reloaded, got %q", got)
+	}
+
+	var buf bytes.Buffer
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("secrets", "secrets", manager.Secrets())
+	if strings.Contains(buf.String(), "correct horse") {
+		t.Errorf("Secrets were logged: %s", buf.String())
+	}
+}
+
+func TestSecretsMigration(t *testing.T) {
+	tmpDir := t.TempDir()
+	statePath := filepath.Join(tmpDir, "state.json")
+
+	// A state file written before secrets were split out
+	legacy := `{"version":1,"configuration":{"wifiSsid":"office","wifiPassword":"correct horse"}}`
+	if err := os.WriteFile(statePath, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(statePath+".bak", []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manager, err := New(statePath)
+	if err != nil {
+		t.Fatalf("Failed to create state manager: %v", err)
+	}
+	if got := manager.Secrets().WiFiPassword; got != "correct horse" {
+		t.Errorf("Expected WiFi password to be migrated, got %q", got)
+	}
+	if got := manager.Get().Configuration.WiFiSSID; got != "office" {
+		t.Errorf("Expected configuration to be kept, got SSID %q", got)
+	}
+
+	for _, path := range []string{statePath, statePath + ".bak"} {
+		data, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatalf("Failed to read %s: %v", path, err)
+		}
+		if strings.Contains(string(data), "correct horse") {
+			t.Errorf("%s still contains the WiFi password", path)
+		}
+	}
+}
@@ -2,7 +2,10 @@ package storage
 
 import (
 	"context"
+	"fmt"
 	"time"
+
+	"fleetd.sh/internal/config"
 )
 
 // MetricValue represents a single metric value
@@ -99,3 +102,31 @@ func GetMetricsStorageFactory(name string) (MetricsStorageFactory, bool) {
 }
 
 var metricsStorageFactories = make(map[string]MetricsStorageFactory)
+
+// MetricsStorageConfigFromEnv builds a MetricsStorageConfig from the
+// environment. METRICS_BACKEND selects the backend (sqlite or prometheus);
+// METRICS_SQLITE_PATH and METRICS_REMOTE_WRITE_URL configure them.
+func MetricsStorageConfigFromEnv() MetricsStorageConfig {
+	backend := config.GetStringFromEnv("METRICS_BACKEND", "sqlite")
+
+	options := make(map[string]interface{})
+	switch backend {
+	case "sqlite":
+		options["path"] = config.GetStringFromEnv("METRICS_SQLITE_PATH", "fleetd.db")
+	case "prometheus":
+		options["url"] = config.GetStringFromEnv("METRICS_REMOTE_WRITE_URL", "")
+		options["timeout"] = config.GetDurationFromEnv("METRICS_REMOTE_WRITE_TIMEOUT", 30*time.Second)
+	}
+
+	return MetricsStorageConfig{Type: backend, Options: options}
+}
+
+// NewMetricsStorage creates a metrics storage backend using the factory
+// registered for config.Type
+func NewMetricsStorage(cfg MetricsStorageConfig) (MetricsStorage, error) {
+	factory, ok := GetMetricsStorageFactory(cfg.Type)
+	if !ok {
+		return nil, fmt.Errorf("unknown metrics storage backend: %s", cfg.Type)
+	}
+	return factory.Create(cfg)
+}
@@ -0,0 +1,278 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// ErrNotSupported is returned for operations a storage backend cannot perform
+var ErrNotSupported = errors.New("operation not supported by storage backend")
+
+// PrometheusMetricsStorage implements MetricsStorage by forwarding metrics to
+// a Prometheus remote-write endpoint. Metrics are write-only; querying is left
+// to Prometheus itself.
+type PrometheusMetricsStorage struct {
+	url    string
+	client *http.Client
+}
+
+// PrometheusMetricsFactory creates Prometheus remote-write metrics storage backends
+type PrometheusMetricsFactory struct{}
+
+func init() {
+	RegisterMetricsStorageFactory("prometheus", &PrometheusMetricsFactory{})
+}
+
+// Create implements MetricsStorageFactory
+func (f *PrometheusMetricsFactory) Create(config MetricsStorageConfig) (MetricsStorage, error) {
+	url, ok := config.Options["url"].(string)
+	if !ok || url == "" {
+		return nil, fmt.Errorf("prometheus storage requires 'url' option")
+	}
+
+	timeout := 30 * time.Second
+	if t, ok := config.Options["timeout"].(time.Duration); ok {
+		timeout = t
+	}
+
+	return &PrometheusMetricsStorage{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Store implements MetricsStorage
+func (s *PrometheusMetricsStorage) Store(ctx context.Context, name string, value MetricValue) error {
+	return s.StoreBatch(ctx, map[string][]MetricValue{name: {value}})
+}
+
+// StoreBatch implements MetricsStorage
+func (s *PrometheusMetricsStorage) StoreBatch(ctx context.Context, metrics map[string][]MetricValue) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	series, err := toTimeSeries(metrics)
+	if err != nil {
+		return err
+	}
+
+	body := snappy.Encode(nil, encodeWriteRequest(series))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send metrics: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("remote write failed: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// Query implements MetricsStorage
+func (s *PrometheusMetricsStorage) Query(ctx context.Context, query MetricQuery) ([]MetricSeries, error) {
+	return nil, ErrNotSupported
+}
+
+// Delete implements MetricsStorage
+func (s *PrometheusMetricsStorage) Delete(ctx context.Context, name string) error {
+	return ErrNotSupported
+}
+
+// ListMetrics implements MetricsStorage
+func (s *PrometheusMetricsStorage) ListMetrics(ctx context.Context) ([]string, error) {
+	return nil, ErrNotSupported
+}
+
+// GetMetricInfo implements MetricsStorage
+func (s *PrometheusMetricsStorage) GetMetricInfo(ctx context.Context, name string) (*MetricInfo, error) {
+	return nil, ErrNotSupported
+}
+
+type promLabel struct {
+	name, value string
+}
+
+type promSample struct {
+	value     float64
+	timestamp int64 // Milliseconds since epoch
+}
+
+type promSeries struct {
+	labels  []promLabel
+	samples []promSample
+}
+
+// toTimeSeries translates fleetd metrics into Prometheus series. Each value
+// becomes a sample on the series identified by the metric name, device ID and
+// labels.
+func toTimeSeries(metrics map[string][]MetricValue) ([]promSeries, error) {
+	byKey := make(map[string]*promSeries)
+	var keys []string
+
+	for name, values := range metrics {
+		for _, v := range values {
+			sample, err := toFloat(v.Value)
+			if err != nil {
+				return nil, fmt.Errorf("metric %s: %w", name, err)
+			}
+
+			labels := []promLabel{{"__name__", sanitizeMetricName(name)}}
+			if v.DeviceID != "" {
+				labels = append(labels, promLabel{"device_id", v.DeviceID})
+			}
+			for k, val := range v.Labels {
+				k = sanitizeLabelName(k)
+				// The metric name and device ID take precedence over labels
+				if k == "__name__" || (k == "device_id" && v.DeviceID != "") {
+					continue
+				}
+				labels = append(labels, promLabel{k, val})
+			}
+			sort.Slice(labels, func(i, j int) bool { return labels[i].name < labels[j].name })
+
+			var key strings.Builder
+			for _, l := range labels {
+				key.WriteString(l.name + "\xff" + l.value + "\xff")
+			}
+
+			series, ok := byKey[key.String()]
+			if !ok {
+				series = &promSeries{labels: labels}
+				byKey[key.String()] = series
+				keys = append(keys, key.String())
+			}
+			series.samples = append(series.samples, promSample{
+				value:     sample,
+				timestamp: v.Timestamp.UnixMilli(),
+			})
+		}
+	}
+
+	// Prometheus requires samples within a series to be in time order
+	sort.Strings(keys)
+	result := make([]promSeries, 0, len(keys))
+	for _, key := range keys {
+		series := byKey[key]
+		sort.Slice(series.samples, func(i, j int) bool {
+			return series.samples[i].timestamp < series.samples[j].timestamp
+		})
+		result = append(result, *series)
+	}
+	return result, nil
+}
+
+func toFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint:
+		return float64(v), nil
+	case uint32:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("non-numeric value %q", v)
+		}
+		return f, nil
+	default:
+		return 0, fmt.Errorf("unsupported value type %T", value)
+	}
+}
+
+// sanitizeMetricName maps a name onto [a-zA-Z_:][a-zA-Z0-9_:]*
+func sanitizeMetricName(name string) string {
+	return sanitizeName(name, true)
+}
+
+// sanitizeLabelName maps a name onto [a-zA-Z_][a-zA-Z0-9_]*
+func sanitizeLabelName(name string) string {
+	return sanitizeName(name, false)
+}
+
+func sanitizeName(name string, allowColon bool) string {
+	var b strings.Builder
+	for i, r := range name {
+		valid := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') ||
+			(allowColon && r == ':') || (i > 0 && r >= '0' && r <= '9')
+		if valid {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
+
+// encodeWriteRequest encodes series as a prometheus.WriteRequest protobuf
+func encodeWriteRequest(series []promSeries) []byte {
+	var buf []byte
+	for _, s := range series {
+		var ts []byte
+		for _, l := range s.labels {
+			var label []byte
+			label = protowire.AppendTag(label, 1, protowire.BytesType)
+			label = protowire.AppendString(label, l.name)
+			label = protowire.AppendTag(label, 2, protowire.BytesType)
+			label = protowire.AppendString(label, l.value)
+
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, label)
+		}
+		for _, smp := range s.samples {
+			var sample []byte
+			sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+			sample = protowire.AppendFixed64(sample, math.Float64bits(smp.value))
+			sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+			sample = protowire.AppendVarint(sample, uint64(smp.timestamp))
+
+			ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, sample)
+		}
+
+		buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, ts)
+	}
+	return buf
+}
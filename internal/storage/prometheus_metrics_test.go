@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// remoteWriteReceiver records the series sent to a mock remote-write endpoint
+type remoteWriteReceiver struct {
+	mu     sync.Mutex
+	series []promSeries
+	status int
+}
+
+func (r *remoteWriteReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if r.status != 0 {
+		http.Error(w, "rejected", r.status)
+		return
+	}
+	if req.Header.Get("Content-Encoding") != "snappy" || req.Header.Get("Content-Type") != "application/x-protobuf" {
+		http.Error(w, "bad headers", http.StatusBadRequest)
+		return
+	}
+
+	compressed, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	body, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	forEachField(body, func(_ protowire.Number, ts []byte) {
+		var s promSeries
+		forEachField(ts, func(num protowire.Number, field []byte) {
+			switch num {
+			case 1:
+				var l promLabel
+				forEachField(field, func(num protowire.Number, v []byte) {
+					if num == 1 {
+						l.name = string(v)
+					} else {
+						l.value = string(v)
+					}
+				})
+				s.labels = append(s.labels, l)
+			case 2:
+				s.samples = append(s.samples, decodeSample(field))
+			}
+		})
+		r.series = append(r.series, s)
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// forEachField calls fn for every length-delimited field in b
+func forEachField(b []byte, fn func(protowire.Number, []byte)) {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		b = b[n:]
+		if typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			b = b[n:]
+			continue
+		}
+		v, n := protowire.ConsumeBytes(b)
+		fn(num, v)
+		b = b[n:]
+	}
+}
+
+func decodeSample(b []byte) promSample {
+	var s promSample
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		b = b[n:]
+		switch {
+		case num == 1 && typ == protowire.Fixed64Type:
+			v, n := protowire.ConsumeFixed64(b)
+			s.value = math.Float64frombits(v)
+			b = b[n:]
+		case num == 2 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			s.timestamp = int64(v)
+			b = b[n:]
+		default:
+			b = b[protowire.ConsumeFieldValue(num, typ, b):]
+		}
+	}
+	return s
+}
+
+func setupPrometheusMetrics(t *testing.T) (MetricsStorage, *remoteWriteReceiver) {
+	receiver := &remoteWriteReceiver{}
+	server := httptest.NewServer(receiver)
+	t.Cleanup(server.Close)
+
+	storage, err := NewMetricsStorage(MetricsStorageConfig{
+		Type:    "prometheus",
+		Options: map[string]interface{}{"url": server.URL},
+	})
+	require.NoError(t, err)
+	return storage, receiver
+}
+
+func TestPrometheusMetrics_StoreBatch(t *testing.T) {
+	storage, receiver := setupPrometheusMetrics(t)
+	ctx := context.Background()
+
+	now := time.Now().Truncate(time.Millisecond)
+	err := storage.StoreBatch(ctx, map[string][]MetricValue{
+		"cpu.usage": {
+			{DeviceID: "device-1", Value: 42.5, Timestamp: now, Labels: map[string]string{"core": "0"}},
+			// Out of order samples are sorted within the series
+			{DeviceID: "device-1", Value: 40, Timestamp: now.Add(-time.Minute), Labels: map[string]string{"core": "0"}},
+			{DeviceID: "device-2", Value: "12", Timestamp: now},
+		},
+		"online": {
+			{DeviceID: "device-1", Value: true, Timestamp: now, Labels: map[string]string{"device_id": "ignored", "site-name": "hq"}},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, receiver.series, 3)
+
+	assert.Equal(t, []promLabel{
+		{"__name__", "cpu_usage"},
+		{"core", "0"},
+		{"device_id", "device-1"},
+	}, receiver.series[0].labels)
+	assert.Equal(t, []promSample{
+		{value: 40, timestamp: now.Add(-time.Minute).UnixMilli()},
+		{value: 42.5, timestamp: now.UnixMilli()},
+	}, receiver.series[0].samples)
+
+	assert.Equal(t, []promLabel{
+		{"__name__", "cpu_usage"},
+		{"device_id", "device-2"},
+	}, receiver.series[1].labels)
+	assert.Equal(t, []promSample{{value: 12, timestamp: now.UnixMilli()}}, receiver.series[1].samples)
+
+	assert.Equal(t, []promLabel{
+		{"__name__", "online"},
+		{"device_id", "device-1"},
+		{"site_name", "hq"},
+	}, receiver.series[2].labels)
+	assert.Equal(t, []promSample{{value: 1, timestamp: now.UnixMilli()}}, receiver.series[2].samples)
+}
+
+func TestPrometheusMetrics_Errors(t *testing.T) {
+	storage, receiver := setupPrometheusMetrics(t)
+	ctx := context.Background()
+
+	err := storage.Store(ctx, "status", MetricValue{DeviceID: "device-1", Value: "healthy", Timestamp: time.Now()})
+	assert.Error(t, err, "non-numeric values cannot be written")
+
+	receiver.status = http.StatusBadRequest
+	err = storage.Store(ctx, "cpu", MetricValue{DeviceID: "device-1", Value: 1.0, Timestamp: time.Now()})
+	assert.ErrorContains(t, err, "rejected")
+
+	_, err = storage.Query(ctx, MetricQuery{})
+	assert.ErrorIs(t, err, ErrNotSupported)
+
+	_, err = NewMetricsStorage(MetricsStorageConfig{Type: "prometheus", Options: map[string]interface{}{}})
+	assert.Error(t, err)
+	_, err = NewMetricsStorage(MetricsStorageConfig{Type: "unknown"})
+	assert.Error(t, err)
+}
+
+func TestMetricsStorageConfigFromEnv(t *testing.T) {
+	assert.Equal(t, "sqlite", MetricsStorageConfigFromEnv().Type)
+
+	t.Setenv("METRICS_BACKEND", "prometheus")
+	t.Setenv("METRICS_REMOTE_WRITE_URL", "http://prometheus:9090/api/v1/write")
+	cfg := MetricsStorageConfigFromEnv()
+	assert.Equal(t, "prometheus", cfg.Type)
+	assert.Equal(t, "http://prometheus:9090/api/v1/write", cfg.Options["url"])
+}
@@ -2,6 +2,7 @@ package telemetry
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"go.opentelemetry.io/otel"
@@ -96,3 +97,45 @@ func TrackDiskOperation(ctx context.Context, op string) func(error) {
 		}
 	}
 }
+
+// RegisterDBPoolMetrics reports the connection pool statistics of db, labelled
+// with name, each time provider collects metrics. A nil provider uses the
+// global one. Unregister the returned registration when db is closed.
+func RegisterDBPoolMetrics(provider metric.MeterProvider, db *sql.DB, name string) (metric.Registration, error) {
+	if provider == nil {
+		provider = otel.GetMeterProvider()
+	}
+	dbPoolMeter := provider.Meter("fleetd/db")
+
+	open, err := dbPoolMeter.Int64ObservableGauge("db_pool_open_connections")
+	if err != nil {
+		return nil, err
+	}
+	inUse, err := dbPoolMeter.Int64ObservableGauge("db_pool_in_use_connections")
+	if err != nil {
+		return nil, err
+	}
+	idle, err := dbPoolMeter.Int64ObservableGauge("db_pool_idle_connections")
+	if err != nil {
+		return nil, err
+	}
+	waitCount, err := dbPoolMeter.Int64ObservableCounter("db_pool_wait_total")
+	if err != nil {
+		return nil, err
+	}
+	waitDuration, err := dbPoolMeter.Float64ObservableCounter("db_pool_wait_duration_seconds_total")
+	if err != nil {
+		return nil, err
+	}
+
+	dbAttr := metric.WithAttributes(attribute.String("db", name))
+	return dbPoolMeter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		stats := db.Stats()
+		o.ObserveInt64(open, int64(stats.OpenConnections), dbAttr)
+		o.ObserveInt64(inUse, int64(stats.InUse), dbAttr)
+		o.ObserveInt64(idle, int64(stats.Idle), dbAttr)
+		o.ObserveInt64(waitCount, stats.WaitCount, dbAttr)
+		o.ObserveFloat64(waitDuration, stats.WaitDuration.Seconds(), dbAttr)
+		return nil
+	}, open, inUse, idle, waitCount, waitDuration)
+}
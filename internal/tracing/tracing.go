@@ -0,0 +1,87 @@
+package tracing
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"fleetd.sh/internal/config"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Config configures trace sampling and the resource spans are reported under
+type Config struct {
+	ServiceName        string
+	SampleRatio        float64           // Fraction of new traces to sample, from 0 to 1
+	ResourceAttributes map[string]string // Static attributes attached to every span
+}
+
+// LoadFromEnvironment builds a Config for service, reading the sample ratio
+// from TRACING_SAMPLE_RATIO and resource attributes from
+// TRACING_RESOURCE_ATTRS as comma separated key=value pairs
+func LoadFromEnvironment(service string) Config {
+	cfg := Config{
+		ServiceName: service,
+		SampleRatio: config.GetFloatFromEnv("TRACING_SAMPLE_RATIO", 1),
+	}
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		slog.With("key", "TRACING_SAMPLE_RATIO").With("value", cfg.SampleRatio).Error("sample ratio out of range, using default value")
+		cfg.SampleRatio = 1
+	}
+
+	attrs, err := ParseResourceAttributes(config.GetStringFromEnv("TRACING_RESOURCE_ATTRS", ""))
+	if err != nil {
+		slog.With("key", "TRACING_RESOURCE_ATTRS").With("error", err).Error("error parsing resource attributes, ignoring them")
+	}
+	cfg.ResourceAttributes = attrs
+	return cfg
+}
+
+// ParseResourceAttributes parses attributes in the form env=prod,region=us-east
+func ParseResourceAttributes(value string) (map[string]string, error) {
+	attrs := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid resource attribute %q", pair)
+		}
+		attrs[key] = strings.TrimSpace(val)
+	}
+	return attrs, nil
+}
+
+// Sampler returns a parent-based sampler: spans continue their parent's
+// sampling decision, and new traces are sampled at SampleRatio
+func (c Config) Sampler() sdktrace.Sampler {
+	return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(c.SampleRatio))
+}
+
+// Resource returns the resource describing the traced service
+func (c Config) Resource() *resource.Resource {
+	attrs := []attribute.KeyValue{semconv.ServiceName(c.ServiceName)}
+	for k, v := range c.ResourceAttributes {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+	return resource.NewWithAttributes(semconv.SchemaURL, attrs...)
+}
+
+// NewTracerProvider creates a tracer provider that samples according to cfg
+// and batches spans to exporter. Additional options, such as a custom ID
+// generator, are applied last.
+func NewTracerProvider(cfg Config, exporter sdktrace.SpanExporter, opts ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	opts = append([]sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(cfg.Sampler()),
+		sdktrace.WithResource(cfg.Resource()),
+		sdktrace.WithBatcher(exporter),
+	}, opts...)
+	return sdktrace.NewTracerProvider(opts...)
+}
@@ -0,0 +1,125 @@
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// countingExporter counts exported spans
+type countingExporter struct {
+	mu    sync.Mutex
+	spans []sdktrace.ReadOnlySpan
+}
+
+func (e *countingExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *countingExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// seededIDGenerator generates reproducible trace and span IDs
+type seededIDGenerator struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+func (g *seededIDGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var tid trace.TraceID
+	binary.BigEndian.PutUint64(tid[:8], g.rng.Uint64())
+	binary.BigEndian.PutUint64(tid[8:], g.rng.Uint64())
+	return tid, g.newSpanID()
+}
+
+func (g *seededIDGenerator) NewSpanID(ctx context.Context, traceID trace.TraceID) trace.SpanID {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.newSpanID()
+}
+
+func (g *seededIDGenerator) newSpanID() trace.SpanID {
+	var sid trace.SpanID
+	binary.BigEndian.PutUint64(sid[:], g.rng.Uint64())
+	return sid
+}
+
+func TestSampleRatio(t *testing.T) {
+	exporter := &countingExporter{}
+	provider := NewTracerProvider(Config{ServiceName: "device-api", SampleRatio: 0.1}, exporter,
+		sdktrace.WithIDGenerator(&seededIDGenerator{rng: rand.New(rand.NewSource(42))}))
+	tracer := provider.Tracer("test")
+
+	const traces = 10000
+	ctx := context.Background()
+	for i := 0; i < traces; i++ {
+		ctx, root := tracer.Start(ctx, "heartbeat")
+		// Children follow their parent's decision
+		_, child := tracer.Start(ctx, "store")
+		assert.Equal(t, root.SpanContext().IsSampled(), child.SpanContext().IsSampled())
+		child.End()
+		root.End()
+	}
+	require.NoError(t, provider.Shutdown(ctx))
+
+	sampled := len(exporter.spans) / 2
+	assert.InDelta(t, traces/10, sampled, traces*0.01, "sampled %d of %d traces", sampled, traces)
+}
+
+func TestParentBasedSampling(t *testing.T) {
+	exporter := &countingExporter{}
+	provider := NewTracerProvider(Config{ServiceName: "device-api", SampleRatio: 0}, exporter)
+	tracer := provider.Tracer("test")
+
+	// A trace sampled by the caller is kept even at ratio 0
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	ctx := trace.ContextWithRemoteSpanContext(context.Background(), parent)
+	_, span := tracer.Start(ctx, "register")
+	span.End()
+
+	_, unsampled := tracer.Start(context.Background(), "register")
+	unsampled.End()
+
+	require.NoError(t, provider.Shutdown(context.Background()))
+	require.Len(t, exporter.spans, 1)
+	assert.Equal(t, parent.TraceID(), exporter.spans[0].SpanContext().TraceID())
+}
+
+func TestLoadFromEnvironment(t *testing.T) {
+	t.Setenv("TRACING_SAMPLE_RATIO", "0.25")
+	t.Setenv("TRACING_RESOURCE_ATTRS", "env=prod, region=us-east")
+
+	cfg := LoadFromEnvironment("device-api")
+	assert.Equal(t, 0.25, cfg.SampleRatio)
+	assert.Equal(t, map[string]string{"env": "prod", "region": "us-east"}, cfg.ResourceAttributes)
+
+	attrs := cfg.Resource().Attributes()
+	assert.Contains(t, attrs, attribute.String("service.name", "device-api"))
+	assert.Contains(t, attrs, attribute.String("env", "prod"))
+	assert.Contains(t, attrs, attribute.String("region", "us-east"))
+
+	t.Setenv("TRACING_SAMPLE_RATIO", "2")
+	t.Setenv("TRACING_RESOURCE_ATTRS", "env")
+	cfg = LoadFromEnvironment("device-api")
+	assert.Equal(t, 1.0, cfg.SampleRatio)
+	assert.Empty(t, cfg.ResourceAttributes)
+}
@@ -0,0 +1,288 @@
+package update
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Slot is one of the two root partitions of an A/B device. The device runs
+// from one slot while updates are written to the other, so a failed or
+// interrupted update never touches the running system.
+type Slot string
+
+const (
+	SlotA Slot = "a"
+	SlotB Slot = "b"
+)
+
+// Other returns the other slot
+func (s Slot) Other() Slot {
+	if s == SlotA {
+		return SlotB
+	}
+	return SlotA
+}
+
+// ParseSlot parses a slot name
+func ParseSlot(name string) (Slot, error) {
+	switch Slot(strings.ToLower(strings.TrimSpace(name))) {
+	case SlotA:
+		return SlotA, nil
+	case SlotB:
+		return SlotB, nil
+	}
+	return "", fmt.Errorf("invalid slot %q", name)
+}
+
+// ParseSlotDevices parses the partitions of the two slots in the form
+// a=/dev/mmcblk0p2,b=/dev/mmcblk0p3
+func ParseSlotDevices(spec string) (map[Slot]string, error) {
+	devices := make(map[Slot]string)
+	for _, pair := range strings.Split(spec, ",") {
+		name, device, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(device) == "" {
+			return nil, fmt.Errorf("invalid slot device %q", pair)
+		}
+		slot, err := ParseSlot(name)
+		if err != nil {
+			return nil, err
+		}
+		devices[slot] = strings.TrimSpace(device)
+	}
+	return devices, nil
+}
+
+// Bootloader controls which slot an A/B device boots. A try boot boots the
+// other slot once; unless that slot is committed, the bootloader goes back
+// to the committed slot on the next boot, so an image that doesn't come up
+// reverts by itself.
+type Bootloader interface {
+	// Booted returns the slot the running system booted from
+	Booted() (Slot, error)
+	// Committed returns the slot the device boots by default
+	Committed() (Slot, error)
+	// TryBoot makes the next boot use slot, once
+	TryBoot(slot Slot) error
+	// Commit makes slot the default
+	Commit(slot Slot) error
+}
+
+// ErrNotOnTrial is returned by ABUpdater.Commit when the running slot is
+// already the committed one
+var ErrNotOnTrial = errors.New("running slot is already committed")
+
+// ABUpdater installs images on A/B devices
+type ABUpdater struct {
+	boot      Bootloader
+	devices   map[Slot]string
+	publicKey ed25519.PublicKey
+}
+
+// NewABUpdater creates an ABUpdater that writes the image of each slot to
+// the partition in devices, e.g. /dev/mmcblk0p2
+func NewABUpdater(boot Bootloader, devices map[Slot]string) (*ABUpdater, error) {
+	for _, slot := range []Slot{SlotA, SlotB} {
+		if devices[slot] == "" {
+			return nil, fmt.Errorf("no device for slot %s", slot)
+		}
+	}
+	return &ABUpdater{boot: boot, devices: devices}, nil
+}
+
+// SetPublicKey sets the key used to verify image signatures. Once a key is
+// set, images without a valid signature are refused.
+func (u *ABUpdater) SetPublicKey(key ed25519.PublicKey) {
+	u.publicKey = key
+}
+
+// Install writes image to the slot that isn't running and sets it to be
+// tried on the next boot, returning that slot. The device must then be
+// rebooted; the new slot is only kept if Commit is called after it boots.
+func (u *ABUpdater) Install(ctx context.Context, image io.Reader, info UpdateInfo) (Slot, error) {
+	booted, err := u.boot.Booted()
+	if err != nil {
+		return "", fmt.Errorf("failed to get booted slot: %w", err)
+	}
+	committed, err := u.boot.Committed()
+	if err != nil {
+		return "", fmt.Errorf("failed to get committed slot: %w", err)
+	}
+	if booted != committed {
+		// The inactive slot is the fallback of the running trial
+		return "", fmt.Errorf("slot %s is on trial, commit it before installing", booted)
+	}
+
+	target := booted.Other()
+	if err := u.write(ctx, target, image, info); err != nil {
+		return "", err
+	}
+	if err := u.boot.TryBoot(target); err != nil {
+		return "", fmt.Errorf("failed to set try boot: %w", err)
+	}
+	return target, nil
+}
+
+func (u *ABUpdater) write(ctx context.Context, slot Slot, image io.Reader, info UpdateInfo) error {
+	device, err := os.OpenFile(u.devices[slot], os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open slot %s: %w", slot, err)
+	}
+	defer device.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(device, hash), contextReader{ctx, image}); err != nil {
+		return fmt.Errorf("failed to write slot %s: %w", slot, err)
+	}
+	if err := device.Sync(); err != nil {
+		return fmt.Errorf("failed to write slot %s: %w", slot, err)
+	}
+
+	// The slot is inactive, so a bad image written to it is harmless as
+	// long as it is never booted
+	digest := hash.Sum(nil)
+	if sum := hex.EncodeToString(digest); sum != info.SHA256 {
+		return fmt.Errorf("checksum mismatch: expected %s, got %s", info.SHA256, sum)
+	}
+	return VerifySignature(u.publicKey, digest, info.Signature)
+}
+
+// Commit keeps the running slot, which is on trial after an Install and a
+// reboot. It should be called once the new system is known to work, e.g.
+// after it has heartbeated.
+func (u *ABUpdater) Commit() (Slot, error) {
+	booted, err := u.boot.Booted()
+	if err != nil {
+		return "", fmt.Errorf("failed to get booted slot: %w", err)
+	}
+	committed, err := u.boot.Committed()
+	if err != nil {
+		return "", fmt.Errorf("failed to get committed slot: %w", err)
+	}
+	if booted == committed {
+		return booted, ErrNotOnTrial
+	}
+	if err := u.boot.Commit(booted); err != nil {
+		return "", fmt.Errorf("failed to commit slot %s: %w", booted, err)
+	}
+	return booted, nil
+}
+
+// contextReader stops a copy when ctx is done
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// UBoot is the Bootloader of devices booted by U-Boot, driven through its
+// environment with fw_printenv and fw_setenv. The boot script must boot
+// fleetd_slot, pass fleetd.slot=<slot> on the kernel command line, and
+// while fleetd_upgrade_available is 1 use bootcount and altbootcmd to switch
+// fleetd_slot back and clear fleetd_upgrade_available when the new slot
+// fails to boot.
+type UBoot struct {
+	// run runs a command with stdin and returns its output
+	run func(stdin []byte, name string, args ...string) ([]byte, error)
+	// cmdline is the path of the kernel command line
+	cmdline string
+}
+
+// NewUBoot creates a UBoot bootloader
+func NewUBoot() *UBoot {
+	return &UBoot{
+		run: func(stdin []byte, name string, args ...string) ([]byte, error) {
+			cmd := exec.Command(name, args...)
+			cmd.Stdin = bytes.NewReader(stdin)
+			out, err := cmd.Output()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			return out, nil
+		},
+		cmdline: "/proc/cmdline",
+	}
+}
+
+func (b *UBoot) Booted() (Slot, error) {
+	data, err := os.ReadFile(b.cmdline)
+	if err != nil {
+		return "", fmt.Errorf("failed to read kernel command line: %w", err)
+	}
+	for _, field := range strings.Fields(string(data)) {
+		if value, ok := strings.CutPrefix(field, "fleetd.slot="); ok {
+			return ParseSlot(value)
+		}
+	}
+	return "", errors.New("fleetd.slot is not set on the kernel command line")
+}
+
+func (b *UBoot) Committed() (Slot, error) {
+	slot, err := b.getenv("fleetd_slot")
+	if err != nil {
+		return "", err
+	}
+	current, err := ParseSlot(slot)
+	if err != nil {
+		return "", err
+	}
+	// While trying, fleetd_slot is the slot on trial
+	trying, err := b.getenv("fleetd_upgrade_available")
+	if err != nil {
+		return "", err
+	}
+	if trying == "1" {
+		return current.Other(), nil
+	}
+	return current, nil
+}
+
+func (b *UBoot) TryBoot(slot Slot) error {
+	return b.setenv(map[string]string{
+		"fleetd_slot":              string(slot),
+		"fleetd_upgrade_available": "1",
+		"bootcount":                "0",
+	})
+}
+
+func (b *UBoot) Commit(slot Slot) error {
+	return b.setenv(map[string]string{
+		"fleetd_slot":              string(slot),
+		"fleetd_upgrade_available": "0",
+	})
+}
+
+func (b *UBoot) getenv(name string) (string, error) {
+	out, err := b.run(nil, "fw_printenv", "-n", name)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// setenv sets all vars in one write, so the environment is never left half
+// updated
+func (b *UBoot) setenv(vars map[string]string) error {
+	var script bytes.Buffer
+	for name, value := range vars {
+		fmt.Fprintf(&script, "%s %s\n", name, value)
+	}
+	if _, err := b.run(script.Bytes(), "fw_setenv", "-s", "-"); err != nil {
+		return fmt.Errorf("failed to update boot environment: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package update
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// simBootloader simulates a bootloader with one-shot try boots
+type simBootloader struct {
+	booted    Slot
+	committed Slot
+	try       Slot
+}
+
+func (b *simBootloader) Booted() (Slot, error)    { return b.booted, nil }
+func (b *simBootloader) Committed() (Slot, error) { return b.committed, nil }
+func (b *simBootloader) TryBoot(slot Slot) error  { b.try = slot; return nil }
+func (b *simBootloader) Commit(slot Slot) error   { b.committed = slot; return nil }
+
+// reboot boots the try slot once, and the committed slot otherwise
+func (b *simBootloader) reboot() {
+	if b.try != "" {
+		b.booted, b.try = b.try, ""
+		return
+	}
+	b.booted = b.committed
+}
+
+func newTestABUpdater(t *testing.T) (*ABUpdater, *simBootloader, map[Slot]string) {
+	dir := t.TempDir()
+	devices := map[Slot]string{
+		SlotA: filepath.Join(dir, "a.img"),
+		SlotB: filepath.Join(dir, "b.img"),
+	}
+	for slot, path := range devices {
+		if err := os.WriteFile(path, []byte("image "+string(slot)), 0644); err != nil {
+			t.Fatalf("Failed to write slot: %v", err)
+		}
+	}
+	boot := &simBootloader{booted: SlotA, committed: SlotA}
+	updater, err := NewABUpdater(boot, devices)
+	if err != nil {
+		t.Fatalf("Failed to create updater: %v", err)
+	}
+	return updater, boot, devices
+}
+
+func TestABUpdate_Confirm(t *testing.T) {
+	updater, boot, devices := newTestABUpdater(t)
+	image := []byte("image v2")
+
+	slot, err := updater.Install(context.Background(), bytes.NewReader(image), updateInfo(image))
+	if err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if slot != SlotB {
+		t.Errorf("Installed to slot %s, want b", slot)
+	}
+	if got := readFile(t, devices[SlotB]); got != string(image) {
+		t.Errorf("Slot b = %q, want the image", got)
+	}
+	if got := readFile(t, devices[SlotA]); got != "image a" {
+		t.Errorf("Running slot a was modified: %q", got)
+	}
+
+	// The new slot boots and, once it has heartbeated, is committed
+	boot.reboot()
+	if boot.booted != SlotB {
+		t.Fatalf("Booted slot %s after install, want b", boot.booted)
+	}
+	committed, err := updater.Commit()
+	if err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if committed != SlotB {
+		t.Errorf("Committed slot %s, want b", committed)
+	}
+
+	boot.reboot()
+	if boot.booted != SlotB {
+		t.Errorf("Booted slot %s after commit, want b", boot.booted)
+	}
+	if _, err := updater.Commit(); !errors.Is(err, ErrNotOnTrial) {
+		t.Errorf("Second commit = %v, want ErrNotOnTrial", err)
+	}
+}
+
+func TestABUpdate_FailedBootReverts(t *testing.T) {
+	updater, boot, _ := newTestABUpdater(t)
+	image := []byte("image v2")
+
+	if _, err := updater.Install(context.Background(), bytes.NewReader(image), updateInfo(image)); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+
+	// The new slot boots but never commits, e.g. because it can't reach
+	// the server, and the watchdog reboots the device
+	boot.reboot()
+	if boot.booted != SlotB {
+		t.Fatalf("Booted slot %s after install, want b", boot.booted)
+	}
+	if _, err := updater.Install(context.Background(), bytes.NewReader(image), updateInfo(image)); err == nil {
+		t.Error("Expected install during a trial to fail")
+	}
+	boot.reboot()
+
+	if boot.booted != SlotA || boot.committed != SlotA {
+		t.Errorf("Booted %s, committed %s after failed boot; want a, a", boot.booted, boot.committed)
+	}
+	if _, err := updater.Commit(); !errors.Is(err, ErrNotOnTrial) {
+		t.Errorf("Commit after revert = %v, want ErrNotOnTrial", err)
+	}
+}
+
+func TestABUpdate_RejectedImage(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	image := []byte("image v2")
+	digest := sha256.Sum256(image)
+	signed := updateInfo(image)
+	signed.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, digest[:]))
+
+	badChecksum := updateInfo(image)
+	badChecksum.SHA256 = "not the checksum"
+	tests := []struct {
+		name string
+		info UpdateInfo
+	}{
+		{"checksum", badChecksum},
+		{"unsigned", updateInfo(image)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updater, boot, _ := newTestABUpdater(t)
+			updater.SetPublicKey(publicKey)
+
+			if _, err := updater.Install(context.Background(), bytes.NewReader(image), tt.info); err == nil {
+				t.Fatal("Expected install to fail")
+			}
+			// A rejected image is never booted
+			if boot.try != "" {
+				t.Errorf("Try boot set to %s for a rejected image", boot.try)
+			}
+		})
+	}
+
+	updater, boot, _ := newTestABUpdater(t)
+	updater.SetPublicKey(publicKey)
+	if _, err := updater.Install(context.Background(), bytes.NewReader(image), signed); err != nil {
+		t.Fatalf("Install of signed image failed: %v", err)
+	}
+	if boot.try != SlotB {
+		t.Errorf("Try boot = %q, want b", boot.try)
+	}
+}
+
+func TestUBoot(t *testing.T) {
+	cmdline := filepath.Join(t.TempDir(), "cmdline")
+	if err := os.WriteFile(cmdline, []byte("console=ttyS0 fleetd.slot=a rootwait\n"), 0644); err != nil {
+		t.Fatalf("Failed to write cmdline: %v", err)
+	}
+	env := map[string]string{"fleetd_slot": "a", "fleetd_upgrade_available": "0"}
+	boot := &UBoot{
+		cmdline: cmdline,
+		run: func(stdin []byte, name string, args ...string) ([]byte, error) {
+			switch name {
+			case "fw_printenv":
+				return []byte(env[args[len(args)-1]] + "\n"), nil
+			case "fw_setenv":
+				for _, line := range strings.Split(strings.TrimSpace(string(stdin)), "\n") {
+					name, value, _ := strings.Cut(line, " ")
+					env[name] = value
+				}
+				return nil, nil
+			}
+			return nil, errors.New("unexpected command")
+		},
+	}
+
+	if slot, err := boot.Booted(); err != nil || slot != SlotA {
+		t.Errorf("Booted = %s, %v; want a", slot, err)
+	}
+	if err := boot.TryBoot(SlotB); err != nil {
+		t.Fatalf("TryBoot failed: %v", err)
+	}
+	if env["fleetd_slot"] != "b" || env["fleetd_upgrade_available"] != "1" || env["bootcount"] != "0" {
+		t.Errorf("Unexpected environment after try boot: %v", env)
+	}
+	// Until committed, the fallback is still a
+	if slot, err := boot.Committed(); err != nil || slot != SlotA {
+		t.Errorf("Committed = %s, %v; want a", slot, err)
+	}
+	if err := boot.Commit(SlotB); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if slot, err := boot.Committed(); err != nil || slot != SlotB {
+		t.Errorf("Committed = %s, %v; want b", slot, err)
+	}
+}
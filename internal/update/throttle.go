@@ -0,0 +1,51 @@
+package update
+
+import (
+	"context"
+	"io"
+
+	"golang.org/x/time/rate"
+)
+
+// throttledReader limits the rate bytes are read from r with a token bucket
+// holding one token per byte
+type throttledReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rate.Limiter
+}
+
+// ThrottleReader limits reads from r to kbps KiB per second, or returns r
+// if kbps isn't positive. Reads fail once ctx is done.
+func ThrottleReader(ctx context.Context, r io.Reader, kbps int) io.Reader {
+	if kbps <= 0 {
+		return r
+	}
+	return newThrottledReader(ctx, r, kbps*1024)
+}
+
+// newThrottledReader limits reads from r to bytesPerSecond. The bucket holds
+// a tenth of a second of tokens, so reads go out in small, evenly spaced
+// bursts rather than saturating the link and then pausing.
+func newThrottledReader(ctx context.Context, r io.Reader, bytesPerSecond int) io.Reader {
+	burst := max(bytesPerSecond/10, 1)
+	return &throttledReader{
+		ctx:     ctx,
+		r:       r,
+		limiter: rate.NewLimiter(rate.Limit(bytesPerSecond), burst),
+	}
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	// WaitN fails for more than a burst of tokens
+	if len(p) > r.limiter.Burst() {
+		p = p[:r.limiter.Burst()]
+	}
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if werr := r.limiter.WaitN(r.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,63 @@
+package update
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestUpdaterDownloadLimit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("measures a throttled download")
+	}
+
+	// A binary that still starts, padded to a size that takes a couple of
+	// seconds at the limit
+	const (
+		limitKBps = 128
+		size      = 256 * 1024
+	)
+	binary := append([]byte("#!/bin/sh\nexit 0\n#"), bytes.Repeat([]byte("x"), size)...)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(binary)
+	}))
+	defer server.Close()
+
+	updater := newTestUpdater(t)
+	updater.SetDownloadLimit(limitKBps)
+
+	start := time.Now()
+	if err := updater.Download(context.Background(), server.Client(), server.URL, updateInfo(binary)); err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	if got := readFile(t, updater.execPath); got != string(binary) {
+		t.Error("Executable is not the download")
+	}
+	// Only the cap is asserted: a loaded machine may download slower
+	kbps := float64(len(binary)) / 1024 / elapsed.Seconds()
+	if kbps > limitKBps*1.15 {
+		t.Errorf("Download took %v, %.1f KiB/s, want at most %d KiB/s", elapsed, kbps, limitKBps)
+	}
+}
+
+func TestThrottledReaderCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := newThrottledReader(ctx, bytes.NewReader(make([]byte, 1024*1024)), 1024)
+
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	if _, err := io.Copy(io.Discard, r); !errors.Is(err, context.Canceled) {
+		t.Errorf("Copy = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Copy took %v after cancel", elapsed)
+	}
+}
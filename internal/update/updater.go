@@ -1,14 +1,22 @@
 package update
 
 import (
+	"bytes"
 	"context"
+	"crypto/ed25519"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
+	"syscall"
 	"time"
 )
 
@@ -16,44 +24,154 @@ import (
 type UpdateInfo struct {
 	Version     string            `json:"version"`
 	SHA256      string            `json:"sha256"`
+	Signature   string            `json:"signature,omitempty"` // Base64 Ed25519 signature of the SHA256 digest
 	ReleaseDate time.Time         `json:"releaseDate"`
 	Metadata    map[string]string `json:"metadata,omitempty"`
 }
 
-// Updater handles the self-update process
+// Updater handles the self-update process. An installed update is on trial
+// until Commit is called: if the new binary keeps failing to start,
+// BeginTrial restores the previous one.
 type Updater struct {
 	execPath    string
 	backupPath  string
 	stagingPath string
+	trialPath   string
+	publicKey   ed25519.PublicKey
+	checkArgs   []string
+	// downloadLimit caps Download in KiB per second, if set
+	downloadLimit int
 }
 
-// New creates a new Updater instance
+const (
+	// trialBoots is how many times an updated binary may start without
+	// committing before it is rolled back
+	trialBoots = 2
+	// checkTimeout bounds the preflight run of a new binary
+	checkTimeout = 10 * time.Second
+)
+
+var (
+	// ErrSignatureRequired is returned when an update without a signature is
+	// applied by an updater that has a public key
+	ErrSignatureRequired = errors.New("update is not signed")
+	// ErrRolledBack is returned by BeginTrial when an update that never
+	// started successfully has been rolled back
+	ErrRolledBack = errors.New("update rolled back")
+)
+
+// trial records an installed update that hasn't been committed yet
+type trial struct {
+	Info  UpdateInfo `json:"info"`
+	Boots int        `json:"boots"`
+}
+
+// ParsePublicKey decodes a base64 encoded Ed25519 public key
+func ParsePublicKey(encoded string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: %w", err)
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key size: %d", len(key))
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// New creates a new Updater instance for the running executable
 func New(basePath string) (*Updater, error) {
 	execPath, err := os.Executable()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get executable path: %w", err)
 	}
+	return NewForExecutable(basePath, execPath)
+}
 
+// NewForExecutable creates an Updater that updates the executable at
+// execPath instead of the running one
+func NewForExecutable(basePath, execPath string) (*Updater, error) {
 	// Create update directories
 	if err := os.MkdirAll(basePath, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create update directory: %w", err)
 	}
 
 	return &Updater{
-		execPath:    execPath,
-		backupPath:  filepath.Join(basePath, "backup"),
-		stagingPath: filepath.Join(basePath, "staging"),
+		execPath:   execPath,
+		backupPath: filepath.Join(basePath, "backup"),
+		// Staged next to the executable so the swap is an atomic rename
+		stagingPath: filepath.Join(filepath.Dir(execPath), "."+filepath.Base(execPath)+".new"),
+		trialPath:   filepath.Join(basePath, "trial.json"),
+		checkArgs:   []string{"-version"},
 	}, nil
 }
 
-// Update performs the self-update process
+// SetPublicKey sets the key used to verify update signatures. Once a key is
+// set, updates without a valid signature are refused.
+func (u *Updater) SetPublicKey(key ed25519.PublicKey) {
+	u.publicKey = key
+}
+
+// SetDownloadLimit caps the speed of Download, in KiB per second, for
+// devices on metered or slow links. Zero removes the cap.
+func (u *Updater) SetDownloadLimit(kbps int) {
+	u.downloadLimit = kbps
+}
+
+// Download fetches the binary at url and updates to it
+func (u *Updater) Download(ctx context.Context, client *http.Client, url string, info UpdateInfo) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create download request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download update: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download update: %s", resp.Status)
+	}
+	return u.Update(ctx, ThrottleReader(ctx, resp.Body, u.downloadLimit), info)
+}
+
+// Update verifies binary against info, checks that it runs, and swaps it in
+// for the current executable. The previous executable is kept until the
+// update is committed.
 func (u *Updater) Update(ctx context.Context, binary io.Reader, info UpdateInfo) error {
 	// Verify we can write to all necessary paths
 	if err := u.verifyWriteAccess(); err != nil {
 		return fmt.Errorf("update path verification failed: %w", err)
 	}
 
-	// Create staging file
+	// Nothing reaches the executable path until the staged binary is verified
+	if err := u.stage(ctx, binary, info); err != nil {
+		os.Remove(u.stagingPath)
+		return err
+	}
+
+	// Backup current executable
+	if err := u.backup(); err != nil {
+		os.Remove(u.stagingPath)
+		return fmt.Errorf("backup failed: %w", err)
+	}
+
+	// Replace executable with update
+	if err := u.replace(); err != nil {
+		// Attempt rollback on failure
+		if rbErr := u.restore(); rbErr != nil {
+			return fmt.Errorf("update failed and rollback failed: %v (rollback: %v)", err, rbErr)
+		}
+		return fmt.Errorf("update failed (rolled back): %w", err)
+	}
+
+	if err := writeFileAtomic(u.trialPath, trial{Info: info}); err != nil {
+		return fmt.Errorf("failed to record update trial: %w", err)
+	}
+	return nil
+}
+
+// stage writes binary to the staging path and verifies it
+func (u *Updater) stage(ctx context.Context, binary io.Reader, info UpdateInfo) error {
 	staging, err := os.OpenFile(u.stagingPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755)
 	if err != nil {
 		return fmt.Errorf("failed to create staging file: %w", err)
@@ -67,27 +185,60 @@ func (u *Updater) Update(ctx context.Context, binary io.Reader, info UpdateInfo)
 	if _, err := io.Copy(writer, binary); err != nil {
 		return fmt.Errorf("failed to write update: %w", err)
 	}
+	if err := staging.Sync(); err != nil {
+		return fmt.Errorf("failed to write update: %w", err)
+	}
+	if err := staging.Close(); err != nil {
+		return fmt.Errorf("failed to write update: %w", err)
+	}
 
 	// Verify checksum
-	sum := hex.EncodeToString(hash.Sum(nil))
+	digest := hash.Sum(nil)
+	sum := hex.EncodeToString(digest)
 	if sum != info.SHA256 {
 		return fmt.Errorf("checksum mismatch: expected %s, got %s", info.SHA256, sum)
 	}
 
-	// Backup current executable
-	if err := u.backup(); err != nil {
-		return fmt.Errorf("backup failed: %w", err)
+	if err := u.verifySignature(digest, info.Signature); err != nil {
+		return err
 	}
 
-	// Replace executable with update
-	if err := u.replace(); err != nil {
-		// Attempt rollback on failure
-		if rbErr := u.rollback(); rbErr != nil {
-			return fmt.Errorf("update failed and rollback failed: %v (rollback: %v)", err, rbErr)
-		}
-		return fmt.Errorf("update failed (rolled back): %w", err)
+	return u.check(ctx)
+}
+
+// check runs the staged binary with checkArgs, so a binary that can't start
+// on this device is refused before it replaces a working one
+func (u *Updater) check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, u.stagingPath, u.checkArgs...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("new binary failed to run: %w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+func (u *Updater) verifySignature(digest []byte, signature string) error {
+	return VerifySignature(u.publicKey, digest, signature)
+}
+
+// VerifySignature checks signature, the base64 Ed25519 signature of a
+// SHA256 digest, against digest when a public key is set
+func VerifySignature(publicKey ed25519.PublicKey, digest []byte, signature string) error {
+	if publicKey == nil {
+		return nil
+	}
+	if signature == "" {
+		return ErrSignatureRequired
 	}
 
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("failed to decode signature: %w", err)
+	}
+	if !ed25519.Verify(publicKey, digest, sig) {
+		return errors.New("signature verification failed")
+	}
 	return nil
 }
 
@@ -105,7 +256,8 @@ func (u *Updater) verifyWriteAccess() error {
 }
 
 func (u *Updater) backup() error {
-	if err := os.Rename(u.execPath, u.backupPath); err != nil {
+	// Copy rather than move, so the executable path is never empty
+	if err := copyFile(u.execPath, u.backupPath); err != nil {
 		return fmt.Errorf("failed to create backup: %w", err)
 	}
 	return nil
@@ -113,28 +265,89 @@ func (u *Updater) backup() error {
 
 func (u *Updater) replace() error {
 	if runtime.GOOS == "windows" {
-		// Windows requires special handling due to file locking
-		if err := os.Rename(u.stagingPath, u.execPath+".new"); err != nil {
-			return fmt.Errorf("failed to stage update: %w", err)
-		}
-		if err := os.Rename(u.execPath+".new", u.execPath); err != nil {
-			return fmt.Errorf("failed to replace executable: %w", err)
-		}
-	} else {
-		if err := os.Rename(u.stagingPath, u.execPath); err != nil {
-			return fmt.Errorf("failed to replace executable: %w", err)
+		// A running executable can't be replaced on Windows, only moved
+		os.Remove(u.execPath + ".old")
+		if err := os.Rename(u.execPath, u.execPath+".old"); err != nil {
+			return fmt.Errorf("failed to move executable: %w", err)
 		}
 	}
+	if err := os.Rename(u.stagingPath, u.execPath); err != nil {
+		return fmt.Errorf("failed to replace executable: %w", err)
+	}
 	return nil
 }
 
-func (u *Updater) rollback() error {
-	if err := os.Rename(u.backupPath, u.execPath); err != nil {
+// restore swaps the backup back in for the executable
+func (u *Updater) restore() error {
+	if err := copyFile(u.backupPath, u.stagingPath); err != nil {
+		return fmt.Errorf("failed to restore backup: %w", err)
+	}
+	if err := u.replace(); err != nil {
 		return fmt.Errorf("failed to restore backup: %w", err)
 	}
 	return nil
 }
 
+// BeginTrial is called when the agent starts. It returns the update on
+// trial, if any. When the update has already had its chances to start,
+// the previous binary is restored and ErrRolledBack returned; the caller
+// should then restart.
+func (u *Updater) BeginTrial() (*UpdateInfo, error) {
+	data, err := os.ReadFile(u.trialPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read update trial: %w", err)
+	}
+	var t trial
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("failed to parse update trial: %w", err)
+	}
+
+	if t.Boots >= trialBoots {
+		if err := u.Rollback(); err != nil {
+			return &t.Info, err
+		}
+		return &t.Info, ErrRolledBack
+	}
+
+	t.Boots++
+	if err := writeFileAtomic(u.trialPath, t); err != nil {
+		return nil, fmt.Errorf("failed to record update trial: %w", err)
+	}
+	return &t.Info, nil
+}
+
+// Commit keeps the update on trial for good
+func (u *Updater) Commit() error {
+	if err := os.Remove(u.trialPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to commit update: %w", err)
+	}
+	return u.Cleanup()
+}
+
+// Rollback restores the binary the update on trial replaced
+func (u *Updater) Rollback() error {
+	if err := u.restore(); err != nil {
+		return err
+	}
+	if err := os.Remove(u.trialPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to clear update trial: %w", err)
+	}
+	return u.Cleanup()
+}
+
+// Restart replaces the running process with the current executable, so an
+// update or rollback takes effect. On Windows, which can't exec, the
+// process exits for the service manager to start it again.
+func (u *Updater) Restart() error {
+	if runtime.GOOS == "windows" {
+		os.Exit(1)
+	}
+	return syscall.Exec(u.execPath, os.Args, os.Environ())
+}
+
 // Cleanup removes temporary update files
 func (u *Updater) Cleanup() error {
 	for _, path := range []string{u.stagingPath, u.backupPath} {
@@ -144,3 +357,45 @@ func (u *Updater) Cleanup() error {
 	}
 	return nil
 }
+
+// copyFile copies src to dst with the permissions of src, syncing it to
+// disk
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	stat, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, stat.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// writeFileAtomic writes v as JSON to path through a temporary file, so a
+// crash never leaves it half written
+func writeFileAtomic(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
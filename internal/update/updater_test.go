@@ -3,19 +3,61 @@ package update
 import (
 	"bytes"
 	"context"
+	"crypto/ed25519"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 	"time"
 )
 
-func TestUpdater(t *testing.T) {
-	// Create temporary test directory
-	tmpDir := t.TempDir()
+// Binaries that start, and that fail to start, when run with the check args
+var (
+	goodBinary = []byte("#!/bin/sh\nexit 0\n")
+	badBinary  = []byte("#!/bin/sh\nexit 1\n")
+)
+
+// newTestUpdater creates an updater pointed at a scratch executable, so tests
+// never touch the test binary
+func newTestUpdater(t *testing.T) *Updater {
+	if runtime.GOOS == "windows" {
+		t.Skip("test binaries are shell scripts")
+	}
+	updater, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create updater: %v", err)
+	}
+	dir := t.TempDir()
+	updater.execPath = filepath.Join(dir, "fleetd")
+	updater.stagingPath = filepath.Join(dir, ".fleetd.new")
+	if err := os.WriteFile(updater.execPath, []byte("#!/bin/sh\n# current\n"), 0755); err != nil {
+		t.Fatalf("Failed to write executable: %v", err)
+	}
+	return updater
+}
+
+func updateInfo(data []byte) UpdateInfo {
+	digest := sha256.Sum256(data)
+	return UpdateInfo{Version: "1.0.0", SHA256: hex.EncodeToString(digest[:]), ReleaseDate: time.Now()}
+}
+
+func readFile(t *testing.T, path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	return string(data)
+}
 
+func TestUpdater(t *testing.T) {
 	// Create test binary data
-	testData := []byte("test binary data")
+	testData := goodBinary
 	hash := sha256.Sum256(testData)
 	hashStr := hex.EncodeToString(hash[:])
 
@@ -27,13 +69,10 @@ func TestUpdater(t *testing.T) {
 	}
 
 	// Initialize updater
-	updater, err := New(tmpDir)
-	if err != nil {
-		t.Fatalf("Failed to create updater: %v", err)
-	}
+	updater := newTestUpdater(t)
 
 	// Test update process
-	err = updater.Update(context.Background(), bytes.NewReader(testData), info)
+	err := updater.Update(context.Background(), bytes.NewReader(testData), info)
 	if err != nil {
 		t.Fatalf("Update failed: %v", err)
 	}
@@ -78,3 +117,187 @@ func TestUpdaterChecksumMismatch(t *testing.T) {
 		t.Error("Expected checksum mismatch error")
 	}
 }
+
+func TestUpdaterSignature(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	key, err := ParsePublicKey(base64.StdEncoding.EncodeToString(publicKey))
+	if err != nil {
+		t.Fatalf("Failed to parse public key: %v", err)
+	}
+
+	testData := goodBinary
+	digest := sha256.Sum256(testData)
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, digest[:]))
+
+	tampered := []byte("tampered binary data")
+	tamperedDigest := sha256.Sum256(tampered)
+
+	tests := []struct {
+		name      string
+		data      []byte
+		sha256    string
+		signature string
+		wantErr   bool
+	}{
+		{"signed", testData, hex.EncodeToString(digest[:]), signature, false},
+		// A tampered binary with a matching checksum still fails verification
+		{"tampered", tampered, hex.EncodeToString(tamperedDigest[:]), signature, true},
+		{"unsigned", testData, hex.EncodeToString(digest[:]), "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updater := newTestUpdater(t)
+			updater.SetPublicKey(key)
+			current := readFile(t, updater.execPath)
+
+			err := updater.Update(context.Background(), bytes.NewReader(tt.data), UpdateInfo{
+				Version:   "1.0.0",
+				SHA256:    tt.sha256,
+				Signature: tt.signature,
+			})
+			if tt.wantErr && err == nil {
+				t.Error("Expected signature verification error")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Update failed: %v", err)
+			}
+			// A rejected binary never replaces the executable
+			if tt.wantErr && readFile(t, updater.execPath) != current {
+				t.Error("Executable was replaced by a rejected update")
+			}
+		})
+	}
+
+	if _, err := ParsePublicKey("not a key"); err == nil {
+		t.Error("Expected error parsing invalid public key")
+	}
+}
+
+func TestUpdaterAtomicSwap(t *testing.T) {
+	updater := newTestUpdater(t)
+	current := readFile(t, updater.execPath)
+
+	if err := updater.Update(context.Background(), bytes.NewReader(goodBinary), updateInfo(goodBinary)); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	// The new binary is in place, executable, and nothing is left staged
+	if got := readFile(t, updater.execPath); got != string(goodBinary) {
+		t.Errorf("Executable = %q, want the update", got)
+	}
+	stat, err := os.Stat(updater.execPath)
+	if err != nil {
+		t.Fatalf("Failed to stat executable: %v", err)
+	}
+	if stat.Mode().Perm()&0100 == 0 {
+		t.Errorf("Executable mode = %v, want it executable", stat.Mode())
+	}
+	if _, err := os.Stat(updater.stagingPath); !os.IsNotExist(err) {
+		t.Error("Staging file was left behind")
+	}
+	if got := readFile(t, updater.backupPath); got != current {
+		t.Errorf("Backup = %q, want the previous executable", got)
+	}
+	// The staging file is next to the executable, so the swap is a rename
+	// within one directory
+	if filepath.Dir(updater.stagingPath) != filepath.Dir(updater.execPath) {
+		t.Error("Staging file is not next to the executable")
+	}
+
+	// Committing keeps the update and drops the backup
+	info, err := updater.BeginTrial()
+	if err != nil || info == nil || info.Version != "1.0.0" {
+		t.Fatalf("BeginTrial = %v, %v; want the update on trial", info, err)
+	}
+	if err := updater.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if info, err := updater.BeginTrial(); info != nil || err != nil {
+		t.Errorf("BeginTrial after commit = %v, %v; want no trial", info, err)
+	}
+	if _, err := os.Stat(updater.backupPath); !os.IsNotExist(err) {
+		t.Error("Backup was not removed on commit")
+	}
+	if got := readFile(t, updater.execPath); got != string(goodBinary) {
+		t.Errorf("Executable = %q after commit, want the update", got)
+	}
+}
+
+func TestUpdaterRejectsNonStartingBinary(t *testing.T) {
+	updater := newTestUpdater(t)
+	current := readFile(t, updater.execPath)
+
+	err := updater.Update(context.Background(), bytes.NewReader(badBinary), updateInfo(badBinary))
+	if err == nil {
+		t.Fatal("Expected a binary that fails to run to be refused")
+	}
+	if got := readFile(t, updater.execPath); got != current {
+		t.Error("Executable was replaced by a binary that fails to run")
+	}
+	if info, err := updater.BeginTrial(); info != nil || err != nil {
+		t.Errorf("BeginTrial = %v, %v; want no trial", info, err)
+	}
+}
+
+func TestUpdaterRollbackOnFailedStart(t *testing.T) {
+	updater := newTestUpdater(t)
+	current := readFile(t, updater.execPath)
+
+	if err := updater.Update(context.Background(), bytes.NewReader(goodBinary), updateInfo(goodBinary)); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	// The new binary starts but never commits, e.g. because it crashes
+	// before it is healthy; each start uses up a boot
+	for i := 0; i < trialBoots; i++ {
+		if _, err := updater.BeginTrial(); err != nil {
+			t.Fatalf("BeginTrial %d failed: %v", i, err)
+		}
+		if got := readFile(t, updater.execPath); got != string(goodBinary) {
+			t.Fatalf("Executable rolled back after %d boots", i+1)
+		}
+	}
+
+	info, err := updater.BeginTrial()
+	if !errors.Is(err, ErrRolledBack) {
+		t.Fatalf("BeginTrial = %v, want ErrRolledBack", err)
+	}
+	if info == nil || info.Version != "1.0.0" {
+		t.Errorf("BeginTrial returned %v, want the rolled back update", info)
+	}
+	if got := readFile(t, updater.execPath); got != current {
+		t.Errorf("Executable = %q, want the previous executable restored", got)
+	}
+
+	// The restored binary starts normally
+	if info, err := updater.BeginTrial(); info != nil || err != nil {
+		t.Errorf("BeginTrial after rollback = %v, %v; want no trial", info, err)
+	}
+}
+
+func TestUpdaterDownload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/fleetd" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(goodBinary)
+	}))
+	defer server.Close()
+
+	updater := newTestUpdater(t)
+	if err := updater.Download(context.Background(), server.Client(), server.URL+"/missing", updateInfo(goodBinary)); err == nil {
+		t.Error("Expected a failed download to fail the update")
+	}
+	if err := updater.Download(context.Background(), server.Client(), server.URL+"/fleetd", updateInfo(goodBinary)); err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+	if got := readFile(t, updater.execPath); got != string(goodBinary) {
+		t.Errorf("Executable = %q, want the download", got)
+	}
+}
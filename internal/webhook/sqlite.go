@@ -222,6 +222,7 @@ func (m *SQLiteWebhookManager) Publish(ctx context.Context, event Event) error {
 	for rows.Next() {
 		var webhook WebhookConfig
 		var headersJSON, retryConfigJSON string
+		var timeoutMs int64
 		err := rows.Scan(
 			&webhook.ID,
 			&webhook.URL,
@@ -229,12 +230,13 @@ func (m *SQLiteWebhookManager) Publish(ctx context.Context, event Event) error {
 			&headersJSON,
 			&retryConfigJSON,
 			&webhook.MaxParallel,
-			&webhook.Timeout,
+			&timeoutMs,
 			&webhook.Enabled,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to scan webhook: %w", err)
 		}
+		webhook.Timeout = time.Duration(timeoutMs) * time.Millisecond
 
 		// Parse headers
 		if err := json.Unmarshal([]byte(headersJSON), &webhook.Headers); err != nil {
@@ -249,19 +251,7 @@ func (m *SQLiteWebhookManager) Publish(ctx context.Context, event Event) error {
 		wg.Add(1)
 		go func(webhook WebhookConfig) {
 			defer wg.Done()
-
-			delivery, err := m.sender.Send(ctx, webhook, event)
-			if err != nil {
-				// Log error but continue with other webhooks
-				log.Printf("Failed to send webhook %s: %v", webhook.ID, err)
-			}
-
-			if delivery != nil {
-				err = m.storeDelivery(ctx, delivery)
-				if err != nil {
-					log.Printf("Failed to store delivery for webhook %s: %v", webhook.ID, err)
-				}
-			}
+			m.deliver(ctx, webhook, event)
 		}(webhook)
 	}
 
@@ -269,6 +259,48 @@ func (m *SQLiteWebhookManager) Publish(ctx context.Context, event Event) error {
 	return rows.Err()
 }
 
+// deliver sends an event to a webhook, retrying with exponential backoff
+// while the receiver is unreachable or returns a server error. Every attempt
+// is stored as a delivery.
+func (m *SQLiteWebhookManager) deliver(ctx context.Context, webhook WebhookConfig, event Event) {
+	for attempt := 0; ; attempt++ {
+		delivery, err := m.sender.Send(ctx, webhook, event)
+		if err != nil {
+			// Log error but continue with other webhooks
+			log.Printf("Failed to send webhook %s: %v", webhook.ID, err)
+		}
+
+		retry := shouldRetry(delivery, err) && attempt < webhook.RetryConfig.MaxRetries
+		var wait time.Duration
+		if retry {
+			wait = webhook.RetryConfig.Backoff(attempt + 1)
+		}
+
+		if delivery != nil {
+			delivery.RetryCount = attempt
+			if retry {
+				delivery.NextRetryAt = time.Now().Add(wait)
+			}
+			if err := m.storeDelivery(ctx, delivery); err != nil {
+				log.Printf("Failed to store delivery for webhook %s: %v", webhook.ID, err)
+			}
+		}
+
+		if !retry {
+			if shouldRetry(delivery, err) {
+				log.Printf("Giving up on webhook %s for event %s after %d attempts", webhook.ID, event.ID, attempt+1)
+			}
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
 // GetDelivery implements WebhookManager
 func (m *SQLiteWebhookManager) GetDelivery(ctx context.Context, deliveryID string) (*WebhookDelivery, error) {
 	var (
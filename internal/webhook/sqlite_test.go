@@ -3,10 +3,13 @@ package webhook
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -268,8 +271,9 @@ func TestWebhookManager_RetryDelivery(t *testing.T) {
 		URL:     server.URL,
 		Events:  []EventType{EventDeviceRegistered},
 		Enabled: true,
+		// No automatic retries, so the failed delivery is retried by hand
 		RetryConfig: RetryConfig{
-			MaxRetries:  3,
+			MaxRetries:  0,
 			InitialWait: time.Millisecond,
 			MaxWait:     time.Millisecond * 10,
 		},
@@ -315,6 +319,160 @@ func TestWebhookManager_RetryDelivery(t *testing.T) {
 	assert.Len(t, deliveries, 1)
 }
 
+func TestWebhookManager_SignedDelivery(t *testing.T) {
+	verifier := NewSignatureVerifier("test-secret")
+	received := make(chan Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		if err := verifier.Verify(body, r.Header.Get("X-FleetD-Signature")); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		var event Event
+		require.NoError(t, json.Unmarshal(body, &event))
+		assert.Equal(t, string(event.Type), r.Header.Get("X-FleetD-Event"))
+		received <- event
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	manager, cleanup := setupWebhookManager(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	require.NoError(t, manager.Subscribe(ctx, WebhookConfig{
+		ID:      "offline-webhook",
+		Name:    "Offline Webhook",
+		URL:     server.URL,
+		Secret:  "test-secret",
+		Events:  []EventType{EventDeviceOffline},
+		Enabled: true,
+	}))
+
+	// Events the webhook is not subscribed to are not delivered
+	require.NoError(t, manager.Publish(ctx, Event{ID: "event-1", Type: EventDeploymentFailed, Timestamp: time.Now()}))
+	require.NoError(t, manager.Publish(ctx, Event{
+		ID:        "event-2",
+		Type:      EventDeviceOffline,
+		Timestamp: time.Now(),
+		Data:      map[string]any{"device_id": "device-1"},
+	}))
+
+	select {
+	case event := <-received:
+		assert.Equal(t, "event-2", event.ID)
+		assert.Equal(t, EventDeviceOffline, event.Type)
+		assert.Equal(t, map[string]any{"device_id": "device-1"}, event.Data)
+	default:
+		t.Fatal("event was not delivered")
+	}
+	assert.Empty(t, received)
+
+	deliveries, err := manager.ListDeliveries(ctx, DeliveryFilter{WebhookID: "offline-webhook"})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, http.StatusOK, deliveries[0].Status)
+}
+
+func TestWebhookManager_RetryWithBackoff(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	manager, cleanup := setupWebhookManager(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	require.NoError(t, manager.Subscribe(ctx, WebhookConfig{
+		ID:      "deploy-webhook",
+		Name:    "Deploy Webhook",
+		URL:     server.URL,
+		Secret:  "test-secret",
+		Events:  []EventType{EventDeploymentSucceeded},
+		Enabled: true,
+		RetryConfig: RetryConfig{
+			MaxRetries:  5,
+			InitialWait: time.Millisecond,
+			MaxWait:     10 * time.Millisecond,
+		},
+	}))
+
+	require.NoError(t, manager.Publish(ctx, Event{ID: "event-1", Type: EventDeploymentSucceeded, Timestamp: time.Now()}))
+	assert.Equal(t, int32(3), attempts.Load())
+
+	failed, err := manager.ListDeliveries(ctx, DeliveryFilter{WebhookID: "deploy-webhook", Status: http.StatusServiceUnavailable})
+	require.NoError(t, err)
+	assert.Len(t, failed, 2)
+
+	succeeded, err := manager.ListDeliveries(ctx, DeliveryFilter{WebhookID: "deploy-webhook", Status: http.StatusOK})
+	require.NoError(t, err)
+	require.Len(t, succeeded, 1)
+	assert.Equal(t, 2, succeeded[0].RetryCount)
+}
+
+func TestWebhookManager_GiveUpAfterMaxRetries(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	manager, cleanup := setupWebhookManager(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	require.NoError(t, manager.Subscribe(ctx, WebhookConfig{
+		ID:      "deploy-webhook",
+		Name:    "Deploy Webhook",
+		URL:     server.URL,
+		Secret:  "test-secret",
+		Events:  []EventType{EventDeploymentFailed},
+		Enabled: true,
+		RetryConfig: RetryConfig{
+			MaxRetries:  2,
+			InitialWait: time.Millisecond,
+			MaxWait:     10 * time.Millisecond,
+		},
+	}))
+
+	require.NoError(t, manager.Publish(ctx, Event{ID: "event-1", Type: EventDeploymentFailed, Timestamp: time.Now()}))
+	assert.Equal(t, int32(3), attempts.Load())
+
+	deliveries, err := manager.ListDeliveries(ctx, DeliveryFilter{WebhookID: "deploy-webhook"})
+	require.NoError(t, err)
+	assert.Len(t, deliveries, 3)
+	for _, d := range deliveries {
+		assert.Equal(t, http.StatusInternalServerError, d.Status)
+	}
+
+	// Client errors are not retried
+	attempts.Store(0)
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	require.NoError(t, manager.Publish(ctx, Event{ID: "event-2", Type: EventDeploymentFailed, Timestamp: time.Now()}))
+	assert.Equal(t, int32(1), attempts.Load())
+}
+
+func TestRetryConfigBackoff(t *testing.T) {
+	cfg := RetryConfig{InitialWait: time.Second, MaxWait: 5 * time.Second}
+	assert.Equal(t, time.Second, cfg.Backoff(1))
+	assert.Equal(t, 2*time.Second, cfg.Backoff(2))
+	assert.Equal(t, 4*time.Second, cfg.Backoff(3))
+	assert.Equal(t, 5*time.Second, cfg.Backoff(4))
+	assert.Equal(t, 5*time.Second, cfg.Backoff(50))
+}
+
 func TestWebhookManager_ListDeliveries(t *testing.T) {
 	manager, cleanup := setupWebhookManager(t)
 	defer cleanup()
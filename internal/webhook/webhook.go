@@ -1,6 +1,7 @@
 package webhook
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
 	"encoding/json"
@@ -20,6 +21,7 @@ const (
 	EventDeviceDisconnected EventType = "device.disconnected"
 	EventDeviceUpdated      EventType = "device.updated"
 	EventDeviceDeleted      EventType = "device.deleted"
+	EventDeviceOffline      EventType = "device.offline"
 
 	// Update events
 	EventUpdateCreated    EventType = "update.created"
@@ -28,6 +30,10 @@ const (
 	EventUpdateFailed     EventType = "update.failed"
 	EventUpdateRolledBack EventType = "update.rolled_back"
 
+	// Deployment events, published when an update campaign finishes
+	EventDeploymentSucceeded EventType = "deployment.succeeded"
+	EventDeploymentFailed    EventType = "deployment.failed"
+
 	// Binary events
 	EventBinaryUploaded EventType = "binary.uploaded"
 	EventBinaryDeleted  EventType = "binary.deleted"
@@ -61,13 +67,30 @@ type WebhookConfig struct {
 	Enabled     bool              `json:"enabled"`
 }
 
-// RetryConfig represents retry configuration for webhooks
+// RetryConfig represents retry configuration for webhooks. Failed
+// deliveries are retried up to MaxRetries times, doubling the wait after
+// each attempt from InitialWait up to MaxWait.
 type RetryConfig struct {
 	MaxRetries  int           `json:"max_retries"`
 	InitialWait time.Duration `json:"initial_wait"`
 	MaxWait     time.Duration `json:"max_wait"`
 }
 
+// Backoff returns the wait before the given retry, starting at 1
+func (c RetryConfig) Backoff(retry int) time.Duration {
+	wait := c.InitialWait
+	if wait <= 0 {
+		wait = time.Second
+	}
+	for i := 1; i < retry && (c.MaxWait <= 0 || wait < c.MaxWait); i++ {
+		wait *= 2
+	}
+	if c.MaxWait > 0 && wait > c.MaxWait {
+		wait = c.MaxWait
+	}
+	return wait
+}
+
 // WebhookDelivery represents a webhook delivery attempt
 type WebhookDelivery struct {
 	ID          string    `json:"id"`
@@ -164,7 +187,7 @@ func (s *DefaultWebhookSender) Send(ctx context.Context, config WebhookConfig, e
 	delivery.Request = string(body)
 
 	// Create request
-	req, err := http.NewRequestWithContext(ctx, "POST", config.URL, nil)
+	req, err := http.NewRequestWithContext(ctx, "POST", config.URL, bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
@@ -209,6 +232,15 @@ func (s *DefaultWebhookSender) Send(ctx context.Context, config WebhookConfig, e
 	return delivery, nil
 }
 
+// shouldRetry reports whether a delivery failed in a way worth retrying:
+// the request could not be sent, or the receiver returned a server error
+func shouldRetry(delivery *WebhookDelivery, err error) bool {
+	if err != nil {
+		return true
+	}
+	return delivery.Status >= 500 || delivery.Status == http.StatusTooManyRequests
+}
+
 func generateID() string {
 	b := make([]byte, 16)
 	_, err := rand.Read(b)
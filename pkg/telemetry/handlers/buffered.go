@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"fleetd.sh/pkg/telemetry"
+)
+
+// BufferConfig caps how much undelivered telemetry is kept on disk
+type BufferConfig struct {
+	// MaxMetrics is the maximum number of buffered metrics; oldest are dropped first
+	MaxMetrics int
+	// MaxAge drops buffered metrics older than this; zero keeps them indefinitely
+	MaxAge time.Duration
+}
+
+// DefaultBufferConfig returns a BufferConfig suitable for field devices
+func DefaultBufferConfig() BufferConfig {
+	return BufferConfig{
+		MaxMetrics: 100000,
+		MaxAge:     7 * 24 * time.Hour,
+	}
+}
+
+// Buffered wraps a handler and persists metrics locally while the wrapped
+// handler is failing, replaying them in order once it recovers. The backlog
+// is stored as JSON lines: failed batches are appended, and the file is only
+// rewritten once most of its lines have been trimmed from the backlog.
+type Buffered struct {
+	next    telemetry.Handler
+	path    string
+	cfg     BufferConfig
+	now     func() time.Time
+	mu      sync.Mutex
+	backlog []telemetry.Metric
+	dropped int
+	saved   int // Leading backlog entries already on disk
+	lines   int // Lines on disk, including ones trimmed from the backlog
+}
+
+// NewBuffered creates a Buffered handler storing its backlog at path.
+// Any backlog left over from a previous run is loaded and replayed first.
+func NewBuffered(next telemetry.Handler, path string, cfg BufferConfig) (*Buffered, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	b := &Buffered{
+		next: next,
+		path: path,
+		cfg:  cfg,
+		now:  time.Now,
+	}
+	if err := b.load(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// load reads the backlog left on disk, skipping lines that can't be parsed
+// such as a torn final append. The caller must hold the lock or not yet have
+// shared b.
+func (b *Buffered) load() error {
+	f, err := os.Open(b.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read telemetry backlog: %w", err)
+	}
+	defer f.Close()
+
+	corrupt := false
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		b.lines++
+		var m telemetry.Metric
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			// A corrupt line must not stop telemetry collection
+			slog.Error("Discarding unreadable telemetry backlog entry", "path", b.path, "error", err)
+			corrupt = true
+			continue
+		}
+		b.backlog = append(b.backlog, m)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read telemetry backlog: %w", err)
+	}
+
+	b.saved = len(b.backlog)
+	b.trim()
+	if corrupt {
+		// Rewrite the file so the next append doesn't extend a torn line
+		return b.compact()
+	}
+	return nil
+}
+
+// Handle forwards the backlog plus metrics to the wrapped handler, buffering
+// everything on disk if delivery fails
+func (b *Buffered) Handle(ctx context.Context, metrics []telemetry.Metric) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.backlog = append(b.backlog, metrics...)
+	b.trim()
+
+	if len(b.backlog) == 0 {
+		return nil
+	}
+
+	if err := b.next.Handle(ctx, b.backlog); err != nil {
+		slog.Warn("Telemetry delivery failed, buffering locally", "backlog", len(b.backlog), "error", err)
+		return b.persist()
+	}
+
+	b.backlog = nil
+	b.saved = 0
+	b.lines = 0
+	if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to clear telemetry backlog: %w", err)
+	}
+	return nil
+}
+
+// Depth returns the number of metrics waiting to be delivered
+func (b *Buffered) Depth() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.backlog)
+}
+
+// Dropped returns the number of metrics discarded because the buffer was full or expired
+func (b *Buffered) Dropped() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.dropped
+}
+
+// trim drops expired metrics and then the oldest metrics beyond MaxMetrics.
+// Dropped metrics that are already on disk stay there until the next
+// compaction; the same limits drop them again on load. The caller must hold
+// the lock.
+func (b *Buffered) trim() {
+	if b.cfg.MaxAge > 0 {
+		cutoff := b.now().Add(-b.cfg.MaxAge)
+		kept := b.backlog[:0]
+		saved := 0
+		for i, m := range b.backlog {
+			if m.Timestamp.Before(cutoff) {
+				b.dropped++
+				continue
+			}
+			if i < b.saved {
+				saved++
+			}
+			kept = append(kept, m)
+		}
+		b.backlog = kept
+		b.saved = saved
+	}
+
+	if b.cfg.MaxMetrics > 0 && len(b.backlog) > b.cfg.MaxMetrics {
+		excess := len(b.backlog) - b.cfg.MaxMetrics
+		b.dropped += excess
+		b.saved = max(b.saved-excess, 0)
+		b.backlog = append([]telemetry.Metric(nil), b.backlog[excess:]...)
+	}
+}
+
+// persist appends the metrics not yet on disk to the backlog file, or
+// rewrites it once trimmed lines outnumber the backlog. The caller must hold
+// the lock.
+func (b *Buffered) persist() error {
+	if b.lines-b.saved > len(b.backlog) {
+		return b.compact()
+	}
+
+	f, err := os.OpenFile(b.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open telemetry backlog: %w", err)
+	}
+	data, err := marshalLines(b.backlog[b.saved:])
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write telemetry backlog: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write telemetry backlog: %w", err)
+	}
+
+	b.lines += len(b.backlog) - b.saved
+	b.saved = len(b.backlog)
+	return nil
+}
+
+// compact atomically replaces the backlog file with the current backlog.
+// The caller must hold the lock.
+func (b *Buffered) compact() error {
+	data, err := marshalLines(b.backlog)
+	if err != nil {
+		return err
+	}
+
+	tmpPath := b.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write telemetry backlog: %w", err)
+	}
+	if err := os.Rename(tmpPath, b.path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to save telemetry backlog: %w", err)
+	}
+
+	b.lines = len(b.backlog)
+	b.saved = len(b.backlog)
+	return nil
+}
+
+// marshalLines encodes metrics as one JSON object per line
+func marshalLines(metrics []telemetry.Metric) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, m := range metrics {
+		if err := enc.Encode(m); err != nil {
+			return nil, fmt.Errorf("failed to marshal telemetry backlog: %w", err)
+		}
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"fleetd.sh/pkg/telemetry"
+)
+
+// flakyHandler fails while down is set and records delivered metrics otherwise
+type flakyHandler struct {
+	down      bool
+	delivered []telemetry.Metric
+}
+
+func (f *flakyHandler) Handle(ctx context.Context, metrics []telemetry.Metric) error {
+	if f.down {
+		return errors.New("server unreachable")
+	}
+	f.delivered = append(f.delivered, metrics...)
+	return nil
+}
+
+func metricAt(name string, ts time.Time) telemetry.Metric {
+	return telemetry.Metric{Name: name, Value: 1, Timestamp: ts}
+}
+
+func TestBufferedReplaysInOrderAfterOutage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backlog.jsonl")
+	next := &flakyHandler{down: true}
+
+	handler, err := NewBuffered(next, path, BufferConfig{MaxMetrics: 100})
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+
+	now := time.Now()
+	for i, name := range []string{"m1", "m2", "m3"} {
+		if err := handler.Handle(context.Background(), []telemetry.Metric{metricAt(name, now.Add(time.Duration(i)*time.Second))}); err != nil {
+			t.Fatalf("Handle failed during outage: %v", err)
+		}
+	}
+	if handler.Depth() != 3 {
+		t.Fatalf("Expected backlog depth 3, got %d", handler.Depth())
+	}
+
+	// Simulate an agent restart while the server is still down
+	handler, err = NewBuffered(next, path, BufferConfig{MaxMetrics: 100})
+	if err != nil {
+		t.Fatalf("Failed to reload handler: %v", err)
+	}
+	if handler.Depth() != 3 {
+		t.Fatalf("Expected backlog depth 3 after restart, got %d", handler.Depth())
+	}
+
+	next.down = false
+	if err := handler.Handle(context.Background(), []telemetry.Metric{metricAt("m4", now.Add(3*time.Second))}); err != nil {
+		t.Fatalf("Handle failed after recovery: %v", err)
+	}
+
+	if handler.Depth() != 0 {
+		t.Errorf("Expected empty backlog after flush, got %d", handler.Depth())
+	}
+	var names []string
+	for _, m := range next.delivered {
+		names = append(names, m.Name)
+	}
+	expected := []string{"m1", "m2", "m3", "m4"}
+	if len(names) != len(expected) {
+		t.Fatalf("Expected %v delivered, got %v", expected, names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Fatalf("Expected %v delivered, got %v", expected, names)
+		}
+	}
+}
+
+func TestBufferedDropsOldestBeyondCap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backlog.jsonl")
+	next := &flakyHandler{down: true}
+
+	now := time.Now()
+	handler, err := NewBuffered(next, path, BufferConfig{MaxMetrics: 2, MaxAge: time.Hour})
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+	handler.now = func() time.Time { return now }
+
+	handler.Handle(context.Background(), []telemetry.Metric{
+		metricAt("expired", now.Add(-2*time.Hour)),
+		metricAt("m1", now.Add(-3*time.Minute)),
+		metricAt("m2", now.Add(-2*time.Minute)),
+		metricAt("m3", now.Add(-time.Minute)),
+	})
+
+	if handler.Depth() != 2 {
+		t.Fatalf("Expected backlog depth 2, got %d", handler.Depth())
+	}
+	if handler.Dropped() != 2 {
+		t.Errorf("Expected 2 dropped metrics, got %d", handler.Dropped())
+	}
+
+	next.down = false
+	handler.Handle(context.Background(), nil)
+	if len(next.delivered) != 2 || next.delivered[0].Name != "m2" || next.delivered[1].Name != "m3" {
+		t.Errorf("Expected newest metrics m2, m3 to be delivered, got %v", next.delivered)
+	}
+}
+
+func TestBufferedAppendsBacklog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backlog.jsonl")
+	next := &flakyHandler{down: true}
+
+	handler, err := NewBuffered(next, path, BufferConfig{MaxMetrics: 2})
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+
+	now := time.Now()
+	handler.Handle(context.Background(), []telemetry.Metric{metricAt("m1", now)})
+	first, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read backlog: %v", err)
+	}
+
+	handler.Handle(context.Background(), []telemetry.Metric{metricAt("m2", now.Add(time.Second))})
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read backlog: %v", err)
+	}
+	if !bytes.HasPrefix(data, first) {
+		t.Fatalf("Expected second batch to be appended, backlog is now %q", data)
+	}
+	if lines := bytes.Count(data, []byte("\n")); lines != 2 {
+		t.Fatalf("Expected 2 backlog lines, got %d", lines)
+	}
+
+	// Overflowing the cap leaves trimmed lines on disk until they outnumber
+	// the backlog, then the file is rewritten
+	handler.Handle(context.Background(), []telemetry.Metric{metricAt("m3", now.Add(2*time.Second))})
+	handler.Handle(context.Background(), []telemetry.Metric{metricAt("m4", now.Add(3*time.Second))})
+	handler.Handle(context.Background(), []telemetry.Metric{metricAt("m5", now.Add(4*time.Second))})
+	data, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read backlog: %v", err)
+	}
+	if lines := bytes.Count(data, []byte("\n")); lines > 4 {
+		t.Errorf("Expected backlog to be compacted, got %d lines", lines)
+	}
+
+	// A restart only sees what's within the cap
+	handler, err = NewBuffered(next, path, BufferConfig{MaxMetrics: 2})
+	if err != nil {
+		t.Fatalf("Failed to reload handler: %v", err)
+	}
+	next.down = false
+	handler.Handle(context.Background(), nil)
+	if len(next.delivered) != 2 || next.delivered[0].Name != "m4" || next.delivered[1].Name != "m5" {
+		t.Errorf("Expected m4, m5 to be delivered, got %v", next.delivered)
+	}
+}
+
+func TestBufferedSkipsTornLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backlog.jsonl")
+	next := &flakyHandler{down: true}
+
+	handler, err := NewBuffered(next, path, BufferConfig{MaxMetrics: 100})
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+	handler.Handle(context.Background(), []telemetry.Metric{metricAt("m1", time.Now())})
+
+	// Simulate a crash partway through an append
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open backlog: %v", err)
+	}
+	f.WriteString(`{"name":"m2","va`)
+	f.Close()
+
+	handler, err = NewBuffered(next, path, BufferConfig{MaxMetrics: 100})
+	if err != nil {
+		t.Fatalf("Failed to reload handler: %v", err)
+	}
+	if handler.Depth() != 1 {
+		t.Errorf("Expected backlog depth 1, got %d", handler.Depth())
+	}
+
+	handler.Handle(context.Background(), []telemetry.Metric{metricAt("m3", time.Now())})
+	handler, err = NewBuffered(next, path, BufferConfig{MaxMetrics: 100})
+	if err != nil {
+		t.Fatalf("Failed to reload handler: %v", err)
+	}
+	next.down = false
+	handler.Handle(context.Background(), nil)
+	if len(next.delivered) != 2 || next.delivered[0].Name != "m1" || next.delivered[1].Name != "m3" {
+		t.Errorf("Expected m1, m3 to be delivered, got %v", next.delivered)
+	}
+}
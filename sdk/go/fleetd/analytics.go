@@ -15,7 +15,7 @@ type AnalyticsClient struct {
 }
 
 func (c *AnalyticsClient) GetDeviceHealth(ctx context.Context, deviceID string) (*pb.DeviceHealthStatus, []*pb.DeviceHealthStatus, error) {
-	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	ctx, cancel := withTimeout(ctx, c.timeout)
 	defer cancel()
 
 	resp, err := c.client.GetDeviceHealth(ctx, connect.NewRequest(&pb.GetDeviceHealthRequest{
@@ -27,3 +27,34 @@ func (c *AnalyticsClient) GetDeviceHealth(ctx context.Context, deviceID string)
 
 	return resp.Msg.CurrentStatus, resp.Msg.HistoricalStatus, nil
 }
+
+// GetDeviceMetricsRequest selects device metrics over a time range. When Step
+// is set, points are aggregated into buckets of that width.
+type GetDeviceMetricsRequest struct {
+	DeviceID    string
+	MetricNames []string
+	TimeRange   TimeRange
+	Step        time.Duration
+	Aggregation pb.MetricAggregation
+	MaxPoints   int
+}
+
+// GetDeviceMetrics returns the metric series of a device
+func (c *AnalyticsClient) GetDeviceMetrics(ctx context.Context, req GetDeviceMetricsRequest) ([]*pb.MetricSeries, error) {
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.client.GetDeviceMetrics(ctx, connect.NewRequest(&pb.GetDeviceMetricsRequest{
+		DeviceId:    req.DeviceID,
+		MetricNames: req.MetricNames,
+		TimeRange:   req.TimeRange.toProto(),
+		StepSeconds: int64(req.Step / time.Second),
+		Aggregation: req.Aggregation,
+		MaxPoints:   int32(req.MaxPoints),
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Msg.Metrics, nil
+}
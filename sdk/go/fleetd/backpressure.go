@@ -0,0 +1,71 @@
+package fleetd
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"connectrpc.com/connect"
+)
+
+const (
+	retryAfterHeader        = "Retry-After"
+	suggestedIntervalHeader = "X-Suggested-Interval"
+)
+
+// Backpressure holds the hints a server sends when it wants clients to slow down
+type Backpressure struct {
+	// RetryAfter is how long to wait before retrying a rejected request
+	RetryAfter time.Duration
+
+	// SuggestedInterval is the reporting interval the server asks for
+	SuggestedInterval time.Duration
+}
+
+// BackpressureFromError returns the backpressure hints carried by an error
+// returned from the server, if any
+func BackpressureFromError(err error) (Backpressure, bool) {
+	var connectErr *connect.Error
+	if !errors.As(err, &connectErr) || connectErr.Code() != connect.CodeResourceExhausted {
+		return Backpressure{}, false
+	}
+	bp := backpressureFromHeader(connectErr.Meta())
+	return bp, bp.RetryAfter > 0 || bp.SuggestedInterval > 0
+}
+
+// NextInterval returns how long an agent reporting every base should wait
+// before its next report, given the outcome of the previous one. The interval
+// only ever grows in response to backpressure.
+func NextInterval(base time.Duration, resp *HeartbeatResponse, err error) time.Duration {
+	var bp Backpressure
+	if err != nil {
+		bp, _ = BackpressureFromError(err)
+	} else if resp != nil {
+		bp = resp.Backpressure
+	}
+
+	next := base
+	if bp.SuggestedInterval > next {
+		next = bp.SuggestedInterval
+	}
+	if bp.RetryAfter > next {
+		next = bp.RetryAfter
+	}
+	return next
+}
+
+func backpressureFromHeader(header http.Header) Backpressure {
+	return Backpressure{
+		RetryAfter:        parseSeconds(header.Get(retryAfterHeader)),
+		SuggestedInterval: parseSeconds(header.Get(suggestedIntervalHeader)),
+	}
+}
+
+func parseSeconds(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
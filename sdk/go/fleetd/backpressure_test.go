@@ -0,0 +1,81 @@
+package fleetd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	pb "fleetd.sh/gen/fleetd/v1"
+	rpc "fleetd.sh/gen/fleetd/v1/fleetpbconnect"
+	"fleetd.sh/internal/middleware"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowDeviceService blocks heartbeats until released to simulate server load
+type slowDeviceService struct {
+	*mockDeviceService
+	release chan struct{}
+}
+
+func (s *slowDeviceService) Heartbeat(ctx context.Context, req *connect.Request[pb.HeartbeatRequest]) (*connect.Response[pb.HeartbeatResponse], error) {
+	if req.Msg.DeviceId == "slow-device" {
+		<-s.release
+	}
+	return connect.NewResponse(&pb.HeartbeatResponse{}), nil
+}
+
+func TestNextInterval_Backpressure(t *testing.T) {
+	service := &slowDeviceService{mockDeviceService: newMockDeviceService(), release: make(chan struct{})}
+	bp := middleware.NewBackpressure(middleware.BackpressureConfig{
+		SoftLimit:         1,
+		HardLimit:         1,
+		RetryAfter:        2 * time.Minute,
+		SuggestedInterval: 5 * time.Minute,
+	})
+	mux := http.NewServeMux()
+	mux.Handle(rpc.NewDeviceServiceHandler(service, connect.WithInterceptors(bp)))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(server.URL, ClientOptions{DefaultTimeout: 5 * time.Second})
+	ctx := context.Background()
+	base := time.Minute
+
+	// An idle server leaves the interval alone
+	resp, err := client.Device().Heartbeat(ctx, HeartbeatRequest{DeviceID: "fast-device"})
+	require.NoError(t, err)
+	assert.Equal(t, base, NextInterval(base, resp, err))
+
+	// Occupy the server so the next heartbeat is rejected
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		client.Device().Heartbeat(ctx, HeartbeatRequest{DeviceID: "slow-device"})
+	}()
+	require.Eventually(t, func() bool { return bp.InFlight() == 1 }, time.Second, time.Millisecond)
+
+	resp, err = client.Device().Heartbeat(ctx, HeartbeatRequest{DeviceID: "fast-device"})
+	require.Error(t, err)
+	hints, ok := BackpressureFromError(err)
+	require.True(t, ok)
+	assert.Equal(t, 2*time.Minute, hints.RetryAfter)
+	assert.Equal(t, 5*time.Minute, NextInterval(base, resp, err))
+
+	close(service.release)
+	<-done
+
+	// Once load drops the agent returns to its base interval
+	resp, err = client.Device().Heartbeat(ctx, HeartbeatRequest{DeviceID: "fast-device"})
+	require.NoError(t, err)
+	assert.Equal(t, base, NextInterval(base, resp, err))
+
+	// Hints on successful responses lengthen the interval too
+	assert.Equal(t, 3*time.Minute, NextInterval(base, &HeartbeatResponse{
+		Backpressure: Backpressure{SuggestedInterval: 3 * time.Minute},
+	}, nil))
+}
@@ -2,6 +2,7 @@ package fleetd
 
 import (
 	"context"
+	"errors"
 	"io"
 
 	"connectrpc.com/connect"
@@ -41,13 +42,30 @@ type ListBinariesRequest struct {
 
 // Upload uploads a binary to the fleet
 func (c *BinaryClient) Upload(ctx context.Context, req UploadBinaryRequest) (*UploadBinaryResponse, error) {
-	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	ctx, cancel := withTimeout(ctx, c.timeout)
 	defer cancel()
 
+	// Cancelling before closing the stream aborts the upload instead of
+	// completing it with partial content
+	ctx, abort := context.WithCancel(ctx)
+	defer abort()
 	stream := c.client.UploadBinary(ctx)
+	send := func(msg *pb.UploadBinaryRequest) error {
+		err := stream.Send(msg)
+		if errors.Is(err, io.EOF) {
+			// The server ended the stream; the reason comes with its response
+			_, err = stream.CloseAndReceive()
+		}
+		return err
+	}
+	fail := func(err error) (*UploadBinaryResponse, error) {
+		abort()
+		stream.CloseAndReceive()
+		return nil, apiError(err)
+	}
 
 	// Send metadata
-	err := stream.Send(&pb.UploadBinaryRequest{
+	err := send(&pb.UploadBinaryRequest{
 		Data: &pb.UploadBinaryRequest_Metadata{
 			Metadata: &pb.BinaryMetadata{
 				Name:         req.Name,
@@ -59,33 +77,33 @@ func (c *BinaryClient) Upload(ctx context.Context, req UploadBinaryRequest) (*Up
 		},
 	})
 	if err != nil {
-		return nil, err
+		return fail(err)
 	}
 
 	// Send binary data in chunks
 	buffer := make([]byte, 32*1024) // 32KB chunks
 	for {
 		n, err := req.Reader.Read(buffer)
+		if n > 0 {
+			if err := send(&pb.UploadBinaryRequest{
+				Data: &pb.UploadBinaryRequest_Chunk{
+					Chunk: buffer[:n],
+				},
+			}); err != nil {
+				return fail(err)
+			}
+		}
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			return nil, err
-		}
-
-		err = stream.Send(&pb.UploadBinaryRequest{
-			Data: &pb.UploadBinaryRequest_Chunk{
-				Chunk: buffer[:n],
-			},
-		})
-		if err != nil {
-			return nil, err
+			return fail(err)
 		}
 	}
 
 	resp, err := stream.CloseAndReceive()
 	if err != nil {
-		return nil, err
+		return nil, apiError(err)
 	}
 
 	return &UploadBinaryResponse{
@@ -96,33 +114,31 @@ func (c *BinaryClient) Upload(ctx context.Context, req UploadBinaryRequest) (*Up
 
 // Download downloads a binary from the fleet
 func (c *BinaryClient) Download(ctx context.Context, req DownloadBinaryRequest, w io.Writer) error {
-	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	ctx, cancel := withTimeout(ctx, c.timeout)
 	defer cancel()
 
 	stream, err := c.client.DownloadBinary(ctx, connect.NewRequest(&pb.DownloadBinaryRequest{
 		Id: req.ID,
 	}))
 	if err != nil {
-		return err
+		return apiError(err)
 	}
+	defer stream.Close()
 
-	for {
-		ok := stream.Receive()
-		if !ok {
-			break
-		}
-		chunk := stream.Msg()
-		if _, err := w.Write(chunk.Chunk); err != nil {
+	for stream.Receive() {
+		if _, err := w.Write(stream.Msg().Chunk); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	// A stream that ends early, for example because ctx was cancelled,
+	// reports why here
+	return apiError(stream.Err())
 }
 
 // List lists available binaries
 func (c *BinaryClient) List(ctx context.Context, req ListBinariesRequest) ([]*pb.Binary, string, error) {
-	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	ctx, cancel := withTimeout(ctx, c.timeout)
 	defer cancel()
 
 	resp, err := c.client.ListBinaries(ctx, connect.NewRequest(&pb.ListBinariesRequest{
@@ -139,3 +155,15 @@ func (c *BinaryClient) List(ctx context.Context, req ListBinariesRequest) ([]*pb
 
 	return resp.Msg.Binaries, resp.Msg.NextPageToken, nil
 }
+
+// Delete deletes a binary. Its content is removed from storage once no
+// other binary shares it.
+func (c *BinaryClient) Delete(ctx context.Context, id string) error {
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+
+	_, err := c.client.DeleteBinary(ctx, connect.NewRequest(&pb.DeleteBinaryRequest{
+		Id: id,
+	}))
+	return err
+}
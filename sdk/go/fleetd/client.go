@@ -2,6 +2,7 @@ package fleetd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -9,6 +10,9 @@ import (
 
 	pb "fleetd.sh/gen/fleetd/v1"
 	rpc "fleetd.sh/gen/fleetd/v1/fleetpbconnect"
+
+	"connectrpc.com/connect"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
@@ -35,6 +39,30 @@ type ClientOptions struct {
 	// DefaultTimeout is the default timeout for API calls
 	DefaultTimeout time.Duration
 
+	// TracerProvider traces API calls and propagates the trace context to
+	// the server. Defaults to the global OpenTelemetry tracer provider.
+	TracerProvider trace.TracerProvider
+
+	// MaxRetries is how many times an idempotent call failing with a
+	// transient error (Unavailable or ResourceExhausted) is retried. Calls
+	// that change state are only retried when made with an idempotency key,
+	// see WithIdempotencyKey. Zero disables retries.
+	MaxRetries int
+
+	// RetryBackoff is the delay before the first retry, doubled for each
+	// further one with jitter. A Retry-After hint from the server takes
+	// precedence. Defaults to 100ms.
+	RetryBackoff time.Duration
+
+	// CircuitBreakerThreshold is the number of consecutive transient
+	// failures after which calls fail fast with ErrCircuitOpen for
+	// CircuitBreakerCooldown. Zero disables the circuit breaker.
+	CircuitBreakerThreshold int
+
+	// CircuitBreakerCooldown is how long the circuit breaker stays open
+	// before letting a trial call through. Defaults to 30s.
+	CircuitBreakerCooldown time.Duration
+
 	// TLS configuration (TODO)
 }
 
@@ -44,14 +72,20 @@ func NewClient(serverURL string, config ClientOptions) *Client {
 		config.DefaultTimeout = 30 * time.Second
 	}
 
+	interceptors := connect.WithInterceptors(
+		errorInterceptor(),
+		newRetryInterceptor(config),
+		newTracingInterceptor(config.TracerProvider),
+	)
+
 	return &Client{
 		httpClient:     *http.DefaultClient,
 		baseURL:        serverURL,
 		defaultTimeout: config.DefaultTimeout,
-		device:         rpc.NewDeviceServiceClient(&http.Client{}, serverURL),
-		binary:         rpc.NewBinaryServiceClient(&http.Client{}, serverURL),
-		update:         rpc.NewUpdateServiceClient(&http.Client{}, serverURL),
-		analytics:      rpc.NewAnalyticsServiceClient(&http.Client{}, serverURL),
+		device:         rpc.NewDeviceServiceClient(&http.Client{}, serverURL, interceptors),
+		binary:         rpc.NewBinaryServiceClient(&http.Client{}, serverURL, interceptors),
+		update:         rpc.NewUpdateServiceClient(&http.Client{}, serverURL, interceptors),
+		analytics:      rpc.NewAnalyticsServiceClient(&http.Client{}, serverURL, interceptors),
 		apiKey:         config.APIKey,
 	}
 }
@@ -117,16 +151,57 @@ func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, c
 	return context.WithTimeout(ctx, timeout)
 }
 
-// Error represents an API error
+// Error represents an API error. Calls made through the client return
+// errors from the server as *Error.
 type Error struct {
 	Code    codes.Code
-	Message string
+	Message string // Error message sent by the server
+
+	// Meta holds the headers and trailers of the failed response, such as
+	// Retry-After
+	Meta http.Header
+
+	err error
 }
 
 func (e *Error) Error() string {
 	return fmt.Sprintf("%s: %s", e.Code, e.Message)
 }
 
+// Unwrap returns the underlying connect error, so errors.Is reports context
+// cancellation and connect.CodeOf keeps working
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// apiError converts an error returned by a connect client into an *Error.
+// Other errors are returned unchanged.
+func apiError(err error) error {
+	var connectErr *connect.Error
+	if !errors.As(err, &connectErr) {
+		return err
+	}
+	return &Error{
+		Code:    codes.Code(connectErr.Code()),
+		Message: connectErr.Message(),
+		Meta:    connectErr.Meta(),
+		err:     err,
+	}
+}
+
+// errorInterceptor converts the errors of unary calls into *Error
+func errorInterceptor() connect.UnaryInterceptorFunc {
+	return func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			resp, err := next(ctx, req)
+			if err != nil {
+				return nil, apiError(err)
+			}
+			return resp, nil
+		}
+	}
+}
+
 // TimeRange represents a time range
 type TimeRange struct {
 	StartTime time.Time
@@ -1,19 +1,23 @@
 package fleetd
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	pb "fleetd.sh/gen/fleetd/v1"
 	rpc "fleetd.sh/gen/fleetd/v1/fleetpbconnect"
+	"fleetd.sh/internal/middleware"
 
 	"connectrpc.com/connect"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -94,6 +98,23 @@ func (s *mockDeviceService) DeleteDevice(ctx context.Context, req *connect.Reque
 	}), nil
 }
 
+func (s *mockDeviceService) BatchRegisterDevices(ctx context.Context, req *connect.Request[pb.BatchRegisterDevicesRequest]) (*connect.Response[pb.BatchRegisterDevicesResponse], error) {
+	var results []*pb.BatchRegisterResult
+	for i, spec := range req.Msg.Devices {
+		result := &pb.BatchRegisterResult{Index: int32(i), HardwareId: spec.HardwareId}
+		if _, ok := s.devices[spec.HardwareId]; ok {
+			result.ErrorCode = connect.CodeAlreadyExists.String()
+			result.Error = "hardware_id already registered"
+		} else {
+			s.devices[spec.HardwareId] = &pb.Device{Id: spec.HardwareId, Name: spec.Name, Type: spec.Type}
+			result.DeviceId = spec.HardwareId
+			result.ApiKey = "test-api-key"
+		}
+		results = append(results, result)
+	}
+	return connect.NewResponse(&pb.BatchRegisterDevicesResponse{Results: results}), nil
+}
+
 func setupTestServer() (*httptest.Server, rpc.DeviceServiceHandler) {
 	mock := newMockDeviceService()
 	mux := http.NewServeMux()
@@ -197,6 +218,10 @@ func TestClient_Errors(t *testing.T) {
 	})
 	require.Error(t, err)
 	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+	apiErr, ok := err.(*Error)
+	require.True(t, ok, "expected *Error, got %T", err)
+	assert.Equal(t, codes.NotFound, apiErr.Code)
+	assert.Equal(t, "device not found", apiErr.Message)
 
 	// Test timeout error
 	timeoutCtx, cancel := context.WithTimeout(ctx, time.Nanosecond)
@@ -207,4 +232,181 @@ func TestClient_Errors(t *testing.T) {
 	})
 	require.Error(t, err)
 	assert.Equal(t, connect.CodeDeadlineExceeded, connect.CodeOf(err))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// blockingDeviceService holds GetDevice calls until the caller gives up
+type blockingDeviceService struct {
+	*mockDeviceService
+	started chan struct{}
+}
+
+func (s *blockingDeviceService) GetDevice(ctx context.Context, req *connect.Request[pb.GetDeviceRequest]) (*connect.Response[pb.GetDeviceResponse], error) {
+	close(s.started)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestClient_Cancel(t *testing.T) {
+	service := &blockingDeviceService{mockDeviceService: newMockDeviceService(), started: make(chan struct{})}
+	mux := http.NewServeMux()
+	mux.Handle(rpc.NewDeviceServiceHandler(service))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(server.URL, ClientOptions{DefaultTimeout: time.Minute})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-service.started
+		cancel()
+	}()
+
+	start := time.Now()
+	_, err := client.Device().GetDevice(ctx, GetDeviceRequest{DeviceID: "test"})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, connect.CodeCanceled, connect.CodeOf(err))
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestClient_CallerDeadline(t *testing.T) {
+	server, _ := setupTestServer()
+	defer server.Close()
+
+	// A deadline set by the caller takes precedence over DefaultTimeout
+	client := NewClient(server.URL, ClientOptions{DefaultTimeout: time.Nanosecond})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := client.Device().ListDevices(ctx, ListDevicesRequest{})
+	require.NoError(t, err)
+}
+
+// mockBinaryService streams part of a binary and then fails
+type mockBinaryService struct {
+	rpc.UnimplementedBinaryServiceHandler
+}
+
+func (s *mockBinaryService) DownloadBinary(ctx context.Context, req *connect.Request[pb.DownloadBinaryRequest], stream *connect.ServerStream[pb.DownloadBinaryResponse]) error {
+	if err := stream.Send(&pb.DownloadBinaryResponse{Chunk: []byte("partial")}); err != nil {
+		return err
+	}
+	return connect.NewError(connect.CodeDataLoss, errors.New("storage read failed"))
+}
+
+func TestBinaryClient_DownloadError(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle(rpc.NewBinaryServiceHandler(&mockBinaryService{}))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(server.URL, ClientOptions{DefaultTimeout: time.Second})
+
+	var buf bytes.Buffer
+	err := client.Binary().Download(context.Background(), DownloadBinaryRequest{ID: "binary-1"}, &buf)
+	require.Error(t, err)
+	apiErr, ok := err.(*Error)
+	require.True(t, ok, "expected *Error, got %T", err)
+	assert.Equal(t, codes.DataLoss, apiErr.Code)
+	assert.Equal(t, "storage read failed", apiErr.Message)
+	assert.Equal(t, "partial", buf.String())
+}
+
+func TestClient_BatchRegister(t *testing.T) {
+	server, _ := setupTestServer()
+	defer server.Close()
+
+	client := NewClient(server.URL, ClientOptions{
+		DefaultTimeout: time.Second,
+	})
+
+	results, err := client.Device().BatchRegister(context.Background(), []DeviceSpec{
+		{Name: "device-1", Type: "raspberry-pi", HardwareID: "hw-1"},
+		{Name: "device-2", Type: "raspberry-pi", HardwareID: "hw-1"},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	assert.Nil(t, results[0].Err)
+	assert.Equal(t, "hw-1", results[0].DeviceID)
+	assert.Equal(t, "test-api-key", results[0].APIKey)
+
+	require.NotNil(t, results[1].Err)
+	assert.Equal(t, codes.AlreadyExists, results[1].Err.Code)
+	assert.Empty(t, results[1].DeviceID)
+}
+
+// failingDeviceService fails GetDevice with the code named by the device ID
+type failingDeviceService struct {
+	*mockDeviceService
+}
+
+func (s *failingDeviceService) GetDevice(ctx context.Context, req *connect.Request[pb.GetDeviceRequest]) (*connect.Response[pb.GetDeviceResponse], error) {
+	var code connect.Code
+	if err := code.UnmarshalText([]byte(req.Msg.DeviceId)); err != nil {
+		return nil, err
+	}
+	return nil, connect.NewError(code, errors.New("failed with "+req.Msg.DeviceId))
+}
+
+func TestClient_ErrorCodes(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle(rpc.NewDeviceServiceHandler(&failingDeviceService{mockDeviceService: newMockDeviceService()}))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(server.URL, ClientOptions{DefaultTimeout: time.Second})
+
+	for _, code := range []connect.Code{
+		connect.CodeInvalidArgument,
+		connect.CodeNotFound,
+		connect.CodeAlreadyExists,
+		connect.CodePermissionDenied,
+		connect.CodeResourceExhausted,
+		connect.CodeFailedPrecondition,
+		connect.CodeUnimplemented,
+		connect.CodeInternal,
+		connect.CodeUnavailable,
+		connect.CodeUnauthenticated,
+	} {
+		t.Run(code.String(), func(t *testing.T) {
+			_, err := client.Device().GetDevice(context.Background(), GetDeviceRequest{DeviceID: code.String()})
+			apiErr, ok := err.(*Error)
+			require.True(t, ok, "expected *Error, got %T", err)
+			assert.Equal(t, codes.Code(code), apiErr.Code)
+			assert.Equal(t, "failed with "+code.String(), apiErr.Message)
+		})
+	}
+}
+
+func TestClient_MiddlewareErrors(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle(rpc.NewDeviceServiceHandler(newMockDeviceService()))
+
+	rl := middleware.NewRateLimiter(middleware.RateLimiterConfig{Rate: 0.001, Burst: 1, Expiration: time.Minute})
+	defer rl.Stop()
+	handler := middleware.BodyLimit(middleware.BodyLimitConfig{MaxBytes: 1024})(
+		middleware.DeviceRateLimitMiddleware(rl)(mux))
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := NewClient(server.URL, ClientOptions{DefaultTimeout: time.Second})
+	ctx := context.Background()
+
+	// Requests rejected before reaching a handler still carry a code and message
+	_, err := client.Device().Register(ctx, RegisterRequest{Name: strings.Repeat("x", 2048)})
+	apiErr, ok := err.(*Error)
+	require.True(t, ok, "expected *Error, got %T", err)
+	assert.Equal(t, codes.ResourceExhausted, apiErr.Code)
+	assert.Equal(t, "request body too large", apiErr.Message)
+
+	_, err = client.Device().ListDevices(ctx, ListDevicesRequest{})
+	require.NoError(t, err)
+	_, err = client.Device().ListDevices(ctx, ListDevicesRequest{})
+	apiErr, ok = err.(*Error)
+	require.True(t, ok, "expected *Error, got %T", err)
+	assert.Equal(t, codes.ResourceExhausted, apiErr.Code)
+	assert.Equal(t, "rate limit exceeded", apiErr.Message)
+	assert.NotEmpty(t, apiErr.Meta.Get("Retry-After"))
 }
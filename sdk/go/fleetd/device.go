@@ -9,6 +9,7 @@ import (
 	rpc "fleetd.sh/gen/fleetd/v1/fleetpbconnect"
 
 	"connectrpc.com/connect"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -26,6 +27,8 @@ type Device struct {
 	Version  string
 	Metadata Metadata
 	LastSeen time.Time
+	FleetID  string
+	Tags     map[string]string
 }
 
 // fromProto converts a protobuf Device to Device
@@ -40,6 +43,8 @@ func fromProtoDevice(d *pb.Device) *Device {
 		Version:  d.Version,
 		Metadata: fromProtoMetadata(d.Metadata),
 		LastSeen: d.LastSeen.AsTime(),
+		FleetID:  d.FleetId,
+		Tags:     d.Tags,
 	}
 }
 
@@ -52,6 +57,8 @@ func (d *Device) toProto() *pb.Device {
 		Version:  d.Version,
 		Metadata: d.Metadata.toProto(),
 		LastSeen: timestamppb.New(d.LastSeen),
+		FleetId:  d.FleetID,
+		Tags:     d.Tags,
 	}
 }
 
@@ -61,12 +68,19 @@ type RegisterRequest struct {
 	Type         string
 	Version      string
 	Capabilities Metadata
+	// HardwareID makes registration idempotent: registering again with the
+	// same hardware ID returns the existing device
+	HardwareID string
+	// RotateAPIKey issues a new API key when re-registering
+	RotateAPIKey bool
 }
 
 // RegisterResponse represents a device registration response
 type RegisterResponse struct {
 	DeviceID string
 	APIKey   string
+	// Reregistered is set when the device was already registered
+	Reregistered bool
 }
 
 // Register registers a new device
@@ -80,6 +94,8 @@ func (c *DeviceClient) Register(ctx context.Context, req RegisterRequest) (*Regi
 			Type:         req.Type,
 			Version:      req.Version,
 			Capabilities: req.Capabilities.toProto(),
+			HardwareId:   req.HardwareID,
+			RotateApiKey: req.RotateAPIKey,
 		},
 	})
 	if err != nil {
@@ -87,8 +103,9 @@ func (c *DeviceClient) Register(ctx context.Context, req RegisterRequest) (*Regi
 	}
 
 	return &RegisterResponse{
-		DeviceID: resp.Msg.DeviceId,
-		APIKey:   resp.Msg.ApiKey,
+		DeviceID:     resp.Msg.DeviceId,
+		APIKey:       resp.Msg.ApiKey,
+		Reregistered: resp.Msg.Reregistered,
 	}, nil
 }
 
@@ -101,6 +118,9 @@ type HeartbeatRequest struct {
 // HeartbeatResponse represents a device heartbeat response
 type HeartbeatResponse struct {
 	HasUpdate bool
+
+	// Backpressure holds any hints from the server to report less often
+	Backpressure Backpressure
 }
 
 // Heartbeat sends a device heartbeat
@@ -119,7 +139,8 @@ func (c *DeviceClient) Heartbeat(ctx context.Context, req HeartbeatRequest) (*He
 	}
 
 	return &HeartbeatResponse{
-		HasUpdate: resp.Msg.HasUpdate,
+		HasUpdate:    resp.Msg.HasUpdate,
+		Backpressure: backpressureFromHeader(resp.Header()),
 	}, nil
 }
 
@@ -183,6 +204,8 @@ type ListDevicesRequest struct {
 	Type     string
 	Version  string
 	Status   string
+	FleetID  string
+	Selector string // Filters by device field and tag, e.g. "type=rpi AND env=prod"
 	PageSize int32
 	Token    string
 }
@@ -203,6 +226,8 @@ func (c *DeviceClient) ListDevices(ctx context.Context, req ListDevicesRequest)
 			Type:      req.Type,
 			Version:   req.Version,
 			Status:    req.Status,
+			FleetId:   req.FleetID,
+			Selector:  req.Selector,
 			PageSize:  req.PageSize,
 			PageToken: req.Token,
 		},
@@ -222,6 +247,35 @@ func (c *DeviceClient) ListDevices(ctx context.Context, req ListDevicesRequest)
 	}, nil
 }
 
+// ListDevicesByFleet lists the devices in a fleet
+func (c *DeviceClient) ListDevicesByFleet(ctx context.Context, fleetID string, pageSize int32, token string) (*ListDevicesResponse, error) {
+	return c.ListDevices(ctx, ListDevicesRequest{
+		FleetID:  fleetID,
+		PageSize: pageSize,
+		Token:    token,
+	})
+}
+
+// SetDeviceTags adds or overwrites tags on a device and removes the tags in
+// removeKeys, returning all of the device's tags afterwards
+func (c *DeviceClient) SetDeviceTags(ctx context.Context, deviceID string, tags map[string]string, removeKeys ...string) (map[string]string, error) {
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.client.SetDeviceTags(ctx, &connect.Request[pb.SetDeviceTagsRequest]{
+		Msg: &pb.SetDeviceTagsRequest{
+			DeviceId:   deviceID,
+			Tags:       tags,
+			RemoveKeys: removeKeys,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Msg.Tags, nil
+}
+
 // DeleteDeviceRequest represents a delete device request
 type DeleteDeviceRequest struct {
 	DeviceID string
@@ -246,3 +300,129 @@ func (c *DeviceClient) DeleteDevice(ctx context.Context, req DeleteDeviceRequest
 
 	return nil
 }
+
+// BatchUpdateDeviceStatusRequest represents a bulk device status update request
+type BatchUpdateDeviceStatusRequest struct {
+	DeviceIDs []string
+	// Selector also updates the devices matching a selector expression
+	Selector string
+	Status   string
+	Reason   string
+}
+
+// DeviceStatusResult represents the outcome of a status update for one device
+type DeviceStatusResult struct {
+	DeviceID       string
+	Success        bool
+	PreviousStatus string
+	Error          string
+}
+
+// BatchUpdateDeviceStatusResponse represents a bulk device status update response
+type BatchUpdateDeviceStatusResponse struct {
+	Results      []DeviceStatusResult
+	UpdatedCount int32
+}
+
+// BatchUpdateDeviceStatus sets the status of several devices at once
+func (c *DeviceClient) BatchUpdateDeviceStatus(ctx context.Context, req BatchUpdateDeviceStatusRequest) (*BatchUpdateDeviceStatusResponse, error) {
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.client.BatchUpdateDeviceStatus(ctx, &connect.Request[pb.BatchUpdateDeviceStatusRequest]{
+		Msg: &pb.BatchUpdateDeviceStatusRequest{
+			DeviceIds: req.DeviceIDs,
+			Selector:  req.Selector,
+			Status:    req.Status,
+			Reason:    req.Reason,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]DeviceStatusResult, len(resp.Msg.Results))
+	for i, r := range resp.Msg.Results {
+		results[i] = DeviceStatusResult{
+			DeviceID:       r.DeviceId,
+			Success:        r.Success,
+			PreviousStatus: r.PreviousStatus,
+			Error:          r.Error,
+		}
+	}
+
+	return &BatchUpdateDeviceStatusResponse{
+		Results:      results,
+		UpdatedCount: resp.Msg.UpdatedCount,
+	}, nil
+}
+
+// DeviceSpec describes a device to pre-register
+type DeviceSpec struct {
+	Name       string
+	Type       string
+	HardwareID string
+	Version    string
+}
+
+// BatchRegisterResult represents the outcome of registering one DeviceSpec.
+// Err is nil when the device was registered.
+type BatchRegisterResult struct {
+	Index      int
+	HardwareID string
+	DeviceID   string
+	APIKey     string
+	Err        *Error
+}
+
+// BatchRegister registers many devices in a single call
+func (c *DeviceClient) BatchRegister(ctx context.Context, specs []DeviceSpec) ([]BatchRegisterResult, error) {
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+
+	devices := make([]*pb.DeviceSpec, len(specs))
+	for i, spec := range specs {
+		devices[i] = &pb.DeviceSpec{
+			Name:       spec.Name,
+			Type:       spec.Type,
+			HardwareId: spec.HardwareID,
+			Version:    spec.Version,
+		}
+	}
+
+	resp, err := c.client.BatchRegisterDevices(ctx, &connect.Request[pb.BatchRegisterDevicesRequest]{
+		Msg: &pb.BatchRegisterDevicesRequest{
+			Devices: devices,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchRegisterResult, len(resp.Msg.Results))
+	for i, r := range resp.Msg.Results {
+		results[i] = BatchRegisterResult{
+			Index:      int(r.Index),
+			HardwareID: r.HardwareId,
+			DeviceID:   r.DeviceId,
+			APIKey:     r.ApiKey,
+		}
+		if r.ErrorCode != "" {
+			results[i].Err = newBatchError(r.ErrorCode, r.Error)
+		}
+	}
+
+	return results, nil
+}
+
+// newBatchError converts a per-item connect error code name into an Error
+func newBatchError(code, message string) *Error {
+	var c connect.Code
+	if err := c.UnmarshalText([]byte(code)); err != nil {
+		c = connect.CodeUnknown
+	}
+	return &Error{
+		Code:    codes.Code(c),
+		Message: message,
+	}
+}
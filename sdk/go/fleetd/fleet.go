@@ -0,0 +1,119 @@
+package fleetd
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	pb "fleetd.sh/gen/fleetd/v1"
+
+	"connectrpc.com/connect"
+)
+
+// DefaultFleetID is the fleet devices belong to until added to another one
+const DefaultFleetID = "default"
+
+// Fleet represents a group of devices
+type Fleet struct {
+	ID          string
+	Name        string
+	Description string
+	CreatedAt   time.Time
+}
+
+// fromProtoFleet converts a protobuf Fleet to Fleet
+func fromProtoFleet(f *pb.Fleet) *Fleet {
+	if f == nil {
+		return nil
+	}
+	return &Fleet{
+		ID:          f.Id,
+		Name:        f.Name,
+		Description: f.Description,
+		CreatedAt:   f.CreatedAt.AsTime(),
+	}
+}
+
+// CreateFleetRequest represents a create fleet request
+type CreateFleetRequest struct {
+	Name        string
+	Description string
+}
+
+// CreateFleet creates a fleet
+func (c *DeviceClient) CreateFleet(ctx context.Context, req CreateFleetRequest) (*Fleet, error) {
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.client.CreateFleet(ctx, &connect.Request[pb.CreateFleetRequest]{
+		Msg: &pb.CreateFleetRequest{
+			Name:        req.Name,
+			Description: req.Description,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return fromProtoFleet(resp.Msg.Fleet), nil
+}
+
+// ListFleets lists all fleets
+func (c *DeviceClient) ListFleets(ctx context.Context) ([]*Fleet, error) {
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.client.ListFleets(ctx, &connect.Request[pb.ListFleetsRequest]{
+		Msg: &pb.ListFleetsRequest{},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fleets := make([]*Fleet, len(resp.Msg.Fleets))
+	for i, f := range resp.Msg.Fleets {
+		fleets[i] = fromProtoFleet(f)
+	}
+	return fleets, nil
+}
+
+// AddDeviceToFleet moves a device into a fleet and returns the fleet it was
+// in before. A device in a fleet other than the default one must be removed
+// from it first.
+func (c *DeviceClient) AddDeviceToFleet(ctx context.Context, deviceID, fleetID string) (string, error) {
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.client.AddDeviceToFleet(ctx, &connect.Request[pb.AddDeviceToFleetRequest]{
+		Msg: &pb.AddDeviceToFleetRequest{
+			DeviceId: deviceID,
+			FleetId:  fleetID,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return resp.Msg.PreviousFleetId, nil
+}
+
+// RemoveDeviceFromFleet returns a device to the default fleet
+func (c *DeviceClient) RemoveDeviceFromFleet(ctx context.Context, deviceID, fleetID string) error {
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.client.RemoveDeviceFromFleet(ctx, &connect.Request[pb.RemoveDeviceFromFleetRequest]{
+		Msg: &pb.RemoveDeviceFromFleetRequest{
+			DeviceId: deviceID,
+			FleetId:  fleetID,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if !resp.Msg.Success {
+		return connect.NewError(connect.CodeInternal, errors.New("failed to remove device from fleet"))
+	}
+
+	return nil
+}
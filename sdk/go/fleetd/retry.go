@@ -0,0 +1,211 @@
+package fleetd
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	rpc "fleetd.sh/gen/fleetd/v1/fleetpbconnect"
+
+	"connectrpc.com/connect"
+)
+
+// IdempotencyKeyHeader marks a request as safe to retry. Calls that change
+// state are only retried when they carry this header.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// ErrCircuitOpen is returned without contacting the server while the
+// client's circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// idempotentProcedures are the calls that can be repeated without changing
+// their outcome
+var idempotentProcedures = map[string]bool{
+	rpc.AnalyticsServiceGetDeviceMetricsProcedure:      true,
+	rpc.AnalyticsServiceGetUpdateAnalyticsProcedure:    true,
+	rpc.AnalyticsServiceGetDeviceHealthProcedure:       true,
+	rpc.AnalyticsServiceGetPerformanceMetricsProcedure: true,
+	rpc.BinaryServiceGetBinaryProcedure:                true,
+	rpc.BinaryServiceListBinariesProcedure:             true,
+	rpc.DeviceServiceGetDeviceProcedure:                true,
+	rpc.DeviceServiceListDevicesProcedure:              true,
+	rpc.DeviceServiceListFleetsProcedure:               true,
+	rpc.DeviceServiceSetDeviceTagsProcedure:            true,
+	rpc.UpdateServiceGetUpdateCampaignProcedure:        true,
+	rpc.UpdateServiceListUpdateCampaignsProcedure:      true,
+	rpc.UpdateServiceGetDeviceUpdateStatusProcedure:    true,
+	rpc.UpdateServiceGetDeviceVersionHistoryProcedure:  true,
+}
+
+const maxRetryBackoff = 30 * time.Second
+
+// retryInterceptor retries idempotent unary calls that fail with a transient
+// error, backing off exponentially with jitter. A Retry-After hint from the
+// server takes precedence over the computed backoff.
+type retryInterceptor struct {
+	maxRetries int
+	backoff    time.Duration
+	breaker    *circuitBreaker // nil when circuit breaking is disabled
+	sleep      func(context.Context, time.Duration) error
+}
+
+func newRetryInterceptor(config ClientOptions) *retryInterceptor {
+	i := &retryInterceptor{
+		maxRetries: config.MaxRetries,
+		backoff:    config.RetryBackoff,
+		sleep:      sleepContext,
+	}
+	if i.backoff <= 0 {
+		i.backoff = 100 * time.Millisecond
+	}
+	if config.CircuitBreakerThreshold > 0 {
+		i.breaker = newCircuitBreaker(config.CircuitBreakerThreshold, config.CircuitBreakerCooldown)
+	}
+	return i
+}
+
+// WrapUnary implements connect.Interceptor
+func (i *retryInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if key, ok := ctx.Value(idempotencyKeyContext{}).(string); ok && key != "" {
+			req.Header().Set(IdempotencyKeyHeader, key)
+		}
+		retryable := isIdempotent(req)
+		for attempt := 0; ; attempt++ {
+			if i.breaker != nil && !i.breaker.allow() {
+				return nil, connect.NewError(connect.CodeUnavailable, ErrCircuitOpen)
+			}
+
+			resp, err := next(ctx, req)
+			if i.breaker != nil {
+				i.breaker.record(!isTransient(err))
+			}
+			if err == nil || !retryable || !isTransient(err) || attempt >= i.maxRetries {
+				return resp, err
+			}
+
+			if sleepErr := i.sleep(ctx, i.delay(attempt, err)); sleepErr != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// WrapStreamingClient implements connect.Interceptor. Streams are not retried.
+func (i *retryInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+// WrapStreamingHandler implements connect.Interceptor
+func (i *retryInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next
+}
+
+// delay returns how long to wait before retrying after the given attempt
+func (i *retryInterceptor) delay(attempt int, err error) time.Duration {
+	var connectErr *connect.Error
+	if errors.As(err, &connectErr) {
+		if retryAfter := backpressureFromHeader(connectErr.Meta()).RetryAfter; retryAfter > 0 {
+			return retryAfter
+		}
+	}
+
+	backoff := i.backoff << attempt
+	if backoff <= 0 || backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+	// Jitter spreads out retries from clients that failed together
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+func isIdempotent(req connect.AnyRequest) bool {
+	spec := req.Spec()
+	return spec.IdempotencyLevel != connect.IdempotencyUnknown ||
+		idempotentProcedures[spec.Procedure] ||
+		req.Header().Get(IdempotencyKeyHeader) != ""
+}
+
+// isTransient reports whether err may succeed if the call is repeated
+func isTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch connect.CodeOf(err) {
+	case connect.CodeUnavailable, connect.CodeResourceExhausted:
+		return true
+	}
+	return false
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// circuitBreaker stops calls to the server after threshold consecutive
+// transient failures. Once cooldown has passed a single trial call is let
+// through; its success closes the breaker again.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	if cooldown <= 0 {
+		cooldown = 30 * time.Second
+	}
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// allow reports whether a call may be made
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+	if b.trial || b.now().Sub(b.openedAt) < b.cooldown {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+// record records the outcome of a call
+func (b *circuitBreaker) record(ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+	if ok {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = b.now()
+	}
+}
+
+// WithIdempotencyKey returns a context whose calls carry key as their
+// idempotency key, allowing calls that change state to be retried
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContext{}, key)
+}
+
+type idempotencyKeyContext struct{}
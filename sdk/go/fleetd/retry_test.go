@@ -0,0 +1,150 @@
+package fleetd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rpc "fleetd.sh/gen/fleetd/v1/fleetpbconnect"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyServer fails the first failures requests with 503 Service Unavailable
+// before passing requests on to a mock device service
+type flakyServer struct {
+	*httptest.Server
+	failures int32
+	requests atomic.Int32
+}
+
+func newFlakyServer(t *testing.T, failures int32) *flakyServer {
+	mux := http.NewServeMux()
+	mux.Handle(rpc.NewDeviceServiceHandler(newMockDeviceService()))
+
+	s := &flakyServer{failures: failures}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.requests.Add(1) <= s.failures {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	options := ClientOptions{MaxRetries: 3, RetryBackoff: time.Millisecond}
+
+	t.Run("idempotent call succeeds after transient failures", func(t *testing.T) {
+		server := newFlakyServer(t, 2)
+		client := NewClient(server.URL, options)
+
+		_, err := client.Device().ListDevices(ctx, ListDevicesRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), server.requests.Load())
+	})
+
+	t.Run("gives up after MaxRetries", func(t *testing.T) {
+		server := newFlakyServer(t, 10)
+		client := NewClient(server.URL, options)
+
+		_, err := client.Device().ListDevices(ctx, ListDevicesRequest{})
+		assert.Equal(t, connect.CodeUnavailable, connect.CodeOf(err))
+		assert.Equal(t, int32(4), server.requests.Load())
+	})
+
+	t.Run("call without idempotency key is not retried", func(t *testing.T) {
+		server := newFlakyServer(t, 2)
+		client := NewClient(server.URL, options)
+
+		_, err := client.Device().Register(ctx, RegisterRequest{Name: "device"})
+		assert.Equal(t, connect.CodeUnavailable, connect.CodeOf(err))
+		assert.Equal(t, int32(1), server.requests.Load())
+	})
+
+	t.Run("call with idempotency key is retried", func(t *testing.T) {
+		server := newFlakyServer(t, 2)
+		client := NewClient(server.URL, options)
+
+		_, err := client.Device().Register(WithIdempotencyKey(ctx, "register-1"), RegisterRequest{Name: "device"})
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), server.requests.Load())
+	})
+
+	t.Run("retries are disabled by default", func(t *testing.T) {
+		server := newFlakyServer(t, 2)
+		client := NewClient(server.URL, ClientOptions{})
+
+		_, err := client.Device().ListDevices(ctx, ListDevicesRequest{})
+		assert.Equal(t, connect.CodeUnavailable, connect.CodeOf(err))
+		assert.Equal(t, int32(1), server.requests.Load())
+	})
+}
+
+func TestRetryDelay(t *testing.T) {
+	i := newRetryInterceptor(ClientOptions{RetryBackoff: 100 * time.Millisecond})
+	unavailable := connect.NewError(connect.CodeUnavailable, errors.New("unavailable"))
+
+	for attempt, max := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond} {
+		delay := i.delay(attempt, unavailable)
+		assert.GreaterOrEqual(t, delay, max/2)
+		assert.LessOrEqual(t, delay, max)
+	}
+	assert.LessOrEqual(t, i.delay(40, unavailable), maxRetryBackoff)
+
+	// Retry-After overrides the backoff
+	unavailable.Meta().Set(retryAfterHeader, "7")
+	assert.Equal(t, 7*time.Second, i.delay(0, unavailable))
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	server := newFlakyServer(t, 100)
+	client := NewClient(server.URL, ClientOptions{
+		CircuitBreakerThreshold: 2,
+		CircuitBreakerCooldown:  time.Hour,
+	})
+	ctx := context.Background()
+
+	for range 2 {
+		_, err := client.Device().ListDevices(ctx, ListDevicesRequest{})
+		assert.Equal(t, connect.CodeUnavailable, connect.CodeOf(err))
+	}
+
+	// The breaker is open, so the server is not contacted
+	_, err := client.Device().ListDevices(ctx, ListDevicesRequest{})
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(2), server.requests.Load())
+}
+
+func TestCircuitBreaker_Cooldown(t *testing.T) {
+	now := time.Now()
+	b := newCircuitBreaker(1, time.Minute)
+	b.now = func() time.Time { return now }
+
+	require.True(t, b.allow())
+	b.record(false)
+	assert.False(t, b.allow())
+
+	// After the cooldown a single trial call is allowed
+	now = now.Add(time.Minute)
+	assert.True(t, b.allow())
+	assert.False(t, b.allow())
+
+	// A failed trial reopens the breaker, a successful one closes it
+	b.record(false)
+	assert.False(t, b.allow())
+	now = now.Add(time.Minute)
+	assert.True(t, b.allow())
+	b.record(true)
+	assert.True(t, b.allow())
+	assert.True(t, b.allow())
+}
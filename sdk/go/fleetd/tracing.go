@@ -0,0 +1,93 @@
+package fleetd
+
+import (
+	"context"
+
+	"connectrpc.com/connect"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName = "fleetd.sh/sdk/go/fleetd"
+
+	// Headers the server tags its spans with, tagged on client spans too
+	deviceIDHeader = "X-Device-ID"
+	appIDHeader    = "X-App-ID"
+)
+
+// tracingInterceptor starts a client span for each call and propagates it
+// to the server as a W3C traceparent header, so the server's spans join the
+// caller's trace
+type tracingInterceptor struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// newTracingInterceptor creates a tracingInterceptor. A nil provider uses the
+// global tracer provider.
+func newTracingInterceptor(provider trace.TracerProvider) *tracingInterceptor {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return &tracingInterceptor{
+		tracer:     provider.Tracer(tracerName),
+		propagator: propagation.TraceContext{},
+	}
+}
+
+// WrapUnary implements connect.Interceptor
+func (i *tracingInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		ctx, span := i.tracer.Start(ctx, req.Spec().Procedure, trace.WithSpanKind(trace.SpanKindClient))
+		defer span.End()
+		i.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header()))
+
+		span.SetAttributes(attribute.String("rpc.method", req.Spec().Procedure))
+		if deviceID := req.Header().Get(deviceIDHeader); deviceID != "" {
+			span.SetAttributes(attribute.String("device_id", deviceID))
+		}
+		if appID := req.Header().Get(appIDHeader); appID != "" {
+			span.SetAttributes(attribute.String("app_id", appID))
+		}
+
+		resp, err := next(ctx, req)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			span.SetAttributes(attribute.String("rpc.connect.code", connect.CodeOf(err).String()))
+		}
+		return resp, err
+	}
+}
+
+// WrapStreamingClient implements connect.Interceptor
+func (i *tracingInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		ctx, span := i.tracer.Start(ctx, spec.Procedure, trace.WithSpanKind(trace.SpanKindClient))
+		conn := next(ctx, spec)
+		i.propagator.Inject(ctx, propagation.HeaderCarrier(conn.RequestHeader()))
+		return &tracedClientConn{StreamingClientConn: conn, span: span}
+	}
+}
+
+// WrapStreamingHandler implements connect.Interceptor. The SDK only makes
+// calls, so handlers are passed through.
+func (i *tracingInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next
+}
+
+// tracedClientConn ends the client span once the response is closed
+type tracedClientConn struct {
+	connect.StreamingClientConn
+	span trace.Span
+}
+
+func (c *tracedClientConn) CloseResponse() error {
+	err := c.StreamingClientConn.CloseResponse()
+	c.span.End()
+	return err
+}
@@ -0,0 +1,53 @@
+package fleetd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	rpc "fleetd.sh/gen/fleetd/v1/fleetpbconnect"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestClient_Tracing(t *testing.T) {
+	var received trace.SpanContext
+	mux := http.NewServeMux()
+	path, handler := rpc.NewDeviceServiceHandler(newMockDeviceService())
+	mux.Handle(path, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := propagation.TraceContext{}.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		received = trace.SpanContextFromContext(ctx)
+		handler.ServeHTTP(w, r)
+	}))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	client := NewClient(server.URL, ClientOptions{TracerProvider: provider})
+
+	_, err := client.Device().GetDevice(context.Background(), GetDeviceRequest{DeviceID: "missing"})
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, rpc.DeviceServiceGetDeviceProcedure, span.Name())
+	assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+	assert.Equal(t, codes.Error, span.Status().Code)
+	assert.Contains(t, span.Attributes(), attribute.String("rpc.connect.code", connect.CodeNotFound.String()))
+
+	// The server receives the client span as its parent
+	require.True(t, received.IsValid())
+	assert.Equal(t, span.SpanContext().TraceID(), received.TraceID())
+	assert.Equal(t, span.SpanContext().SpanID(), received.SpanID())
+}
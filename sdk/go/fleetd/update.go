@@ -22,14 +22,50 @@ type CreateUpdateCampaignRequest struct {
 	TargetPlatforms     []string
 	TargetArchitectures []string
 	TargetMetadata      map[string]string
+	TargetSelector      string // Selects devices by field and tag, e.g. "env=prod AND region in (us-east, us-west)"
 	Strategy            pb.UpdateStrategy
+	MaintenanceWindow   *pb.MaintenanceWindow // Only release the update during this window
 }
 
 func (c *UpdateClient) CreateCampaign(ctx context.Context, req CreateUpdateCampaignRequest) (string, error) {
-	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	ctx, cancel := withTimeout(ctx, c.timeout)
 	defer cancel()
 
-	resp, err := c.client.CreateUpdateCampaign(ctx, connect.NewRequest(&pb.CreateUpdateCampaignRequest{
+	resp, err := c.client.CreateUpdateCampaign(ctx, connect.NewRequest(req.toProto()))
+	if err != nil {
+		return "", err
+	}
+
+	return resp.Msg.CampaignId, nil
+}
+
+// CampaignValidation is the result of validating a campaign without creating it
+type CampaignValidation struct {
+	TargetDevices int
+	Warnings      []string
+}
+
+// ValidateCampaign resolves the devices a campaign would target and checks
+// its binary, without creating the campaign
+func (c *UpdateClient) ValidateCampaign(ctx context.Context, req CreateUpdateCampaignRequest) (*CampaignValidation, error) {
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+
+	msg := req.toProto()
+	msg.ValidateOnly = true
+	resp, err := c.client.CreateUpdateCampaign(ctx, connect.NewRequest(msg))
+	if err != nil {
+		return nil, err
+	}
+
+	return &CampaignValidation{
+		TargetDevices: int(resp.Msg.TargetDeviceCount),
+		Warnings:      resp.Msg.Warnings,
+	}, nil
+}
+
+func (req CreateUpdateCampaignRequest) toProto() *pb.CreateUpdateCampaignRequest {
+	return &pb.CreateUpdateCampaignRequest{
 		Name:                req.Name,
 		Description:         req.Description,
 		BinaryId:            req.BinaryID,
@@ -37,11 +73,24 @@ func (c *UpdateClient) CreateCampaign(ctx context.Context, req CreateUpdateCampa
 		TargetPlatforms:     req.TargetPlatforms,
 		TargetArchitectures: req.TargetArchitectures,
 		TargetMetadata:      req.TargetMetadata,
+		TargetSelector:      req.TargetSelector,
 		Strategy:            req.Strategy,
+		MaintenanceWindow:   req.MaintenanceWindow,
+	}
+}
+
+// RollbackCampaign rolls the devices updated by a campaign back to their
+// previous version and returns the IDs of the rollback campaigns
+func (c *UpdateClient) RollbackCampaign(ctx context.Context, campaignID string) ([]string, error) {
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.client.RollbackUpdateCampaign(ctx, connect.NewRequest(&pb.RollbackUpdateCampaignRequest{
+		CampaignId: campaignID,
 	}))
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return resp.Msg.CampaignId, nil
+	return resp.Msg.CampaignIds, nil
 }
@@ -2,6 +2,7 @@ package e2e
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"io"
 	"net/http"
@@ -28,7 +29,7 @@ func TestAgentInContainer(t *testing.T) {
 	ctx := context.Background()
 
 	// Create container
-	container, err := createContainer(ctx, t)
+	container, err := createContainer(ctx)
 	if err != nil {
 		t.Fatalf("Failed to create container: %v", err)
 	}
@@ -70,32 +71,58 @@ func TestAgentInContainer(t *testing.T) {
 	t.Log("Basic connectivity test passed")
 }
 
-func buildAgent(t *testing.T) {
-	t.Helper()
+// buildDir is the Docker build context of the agent image: the Dockerfile
+// from testdata and an agent binary built for the test run, so no binary is
+// kept in the repository
+var buildDir string
 
-	// Get the path to the testdata directory
-	testDataDir := filepath.Join("testdata")
+func TestMain(m *testing.M) {
+	os.Exit(runTests(m))
+}
+
+func runTests(m *testing.M) int {
+	flag.Parse()
+	if testing.Short() {
+		return m.Run()
+	}
+
+	dir, err := os.MkdirTemp("", "fleetd-e2e")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create build directory: %v\n", err)
+		return 1
+	}
+	defer os.RemoveAll(dir)
+
+	if err := buildAgent(dir); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	buildDir = dir
+	return m.Run()
+}
 
+func buildAgent(dir string) error {
 	// Build the agent binary for Linux
-	cmd := exec.Command("go", "build", "-o", filepath.Join(testDataDir, "fleetd"), "../../cmd/fleetd")
+	cmd := exec.Command("go", "build", "-o", filepath.Join(dir, "fleetd"), "../../cmd/fleetd")
 	cmd.Env = append(os.Environ(),
 		"GOOS=linux",
 		"GOARCH=amd64",
 	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to build agent binary: %v\nOutput: %s", err, output)
+	}
 
-	output, err := cmd.CombinedOutput()
+	dockerfile, err := os.ReadFile(filepath.Join("testdata", "Dockerfile.test"))
 	if err != nil {
-		t.Fatalf("Failed to build agent binary: %v\nOutput: %s", err, output)
+		return fmt.Errorf("failed to read Dockerfile: %w", err)
 	}
+	return os.WriteFile(filepath.Join(dir, "Dockerfile.test"), dockerfile, 0644)
 }
 
-func createContainer(ctx context.Context, t *testing.T) (testcontainers.Container, error) {
-	// Build agent first
-	buildAgent(t)
-
+func createContainer(ctx context.Context) (testcontainers.Container, error) {
 	req := testcontainers.ContainerRequest{
 		FromDockerfile: testcontainers.FromDockerfile{
-			Context:    "testdata",
+			Context:    buildDir,
 			Dockerfile: "Dockerfile.test",
 		},
 		ExposedPorts: []string{"8080/tcp"},
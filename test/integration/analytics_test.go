@@ -167,3 +167,88 @@ func TestAnalyticsServiceIntegration(t *testing.T) {
 	assert.Equal(t, 100.0, perfResp.Msg.Metrics[0].Value)
 	assert.Equal(t, "ms", perfResp.Msg.Metrics[0].Unit)
 }
+
+func TestDeviceMetricsAggregation(t *testing.T) {
+	_, server, db, cleanup := setupAnalyticsServer(t)
+	defer cleanup()
+
+	client := rpc.NewAnalyticsServiceClient(
+		http.DefaultClient,
+		server.URL,
+	)
+	ctx := context.Background()
+
+	_, err := db.Exec(
+		"INSERT INTO device (id, name, type, version, api_key) VALUES (?, ?, ?, ?, ?)",
+		"test-device", "test", "raspberry-pi", "1.0.0", "test-key")
+	require.NoError(t, err)
+
+	// One cpu sample per minute for an hour: 0, 1, 2, ... 59
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 60; i++ {
+		_, err = db.Exec(
+			`INSERT INTO device_metric (device_id, metric_name, cpu_usage, memory_usage, timestamp)
+			 VALUES (?, ?, ?, ?, ?)`,
+			"test-device", "system", float64(i), float64(100-i), start.Add(time.Duration(i)*time.Minute).Format(time.RFC3339))
+		require.NoError(t, err)
+	}
+
+	timeRange := &pb.TimeRange{
+		StartTime: timestamppb.New(start),
+		EndTime:   timestamppb.New(start.Add(time.Hour)),
+	}
+	query := func(step int64, aggregation pb.MetricAggregation, maxPoints int32) *pb.GetDeviceMetricsResponse {
+		resp, err := client.GetDeviceMetrics(ctx, connect.NewRequest(&pb.GetDeviceMetricsRequest{
+			DeviceId:    "test-device",
+			MetricNames: []string{"cpu_usage"},
+			TimeRange:   timeRange,
+			StepSeconds: step,
+			Aggregation: aggregation,
+			MaxPoints:   maxPoints,
+		}))
+		require.NoError(t, err)
+		require.Len(t, resp.Msg.Metrics, 1)
+		return resp.Msg
+	}
+	numbers := func(series *pb.MetricSeries) []float64 {
+		var values []float64
+		for _, v := range series.Values {
+			values = append(values, v.GetNumeric())
+		}
+		return values
+	}
+
+	// Raw points are returned without a step
+	raw := query(0, pb.MetricAggregation_METRIC_AGGREGATION_UNSPECIFIED, 0)
+	assert.Equal(t, int64(0), raw.StepSeconds)
+	assert.Len(t, raw.Metrics[0].Values, 60)
+
+	// 15 minute buckets hold 15 samples each
+	avg := query(900, pb.MetricAggregation_METRIC_AGGREGATION_AVG, 0)
+	assert.Equal(t, int64(900), avg.StepSeconds)
+	assert.Equal(t, []float64{7, 22, 37, 52}, numbers(avg.Metrics[0]))
+	for i, v := range avg.Metrics[0].Values {
+		assert.Equal(t, start.Add(time.Duration(i)*15*time.Minute), v.Timestamp.AsTime())
+	}
+
+	assert.Equal(t, []float64{0, 15, 30, 45}, numbers(query(900, pb.MetricAggregation_METRIC_AGGREGATION_MIN, 0).Metrics[0]))
+	assert.Equal(t, []float64{14, 29, 44, 59}, numbers(query(900, pb.MetricAggregation_METRIC_AGGREGATION_MAX, 0).Metrics[0]))
+	assert.Equal(t, []float64{105, 330, 555, 780}, numbers(query(900, pb.MetricAggregation_METRIC_AGGREGATION_SUM, 0).Metrics[0]))
+	assert.Equal(t, []float64{15, 15, 15, 15}, numbers(query(900, pb.MetricAggregation_METRIC_AGGREGATION_COUNT, 0).Metrics[0]))
+
+	// A step too fine for the cap is widened so the series fits
+	capped := query(60, pb.MetricAggregation_METRIC_AGGREGATION_MAX, 6)
+	assert.Equal(t, int64(600), capped.StepSeconds)
+	assert.Equal(t, []float64{9, 19, 29, 39, 49, 59}, numbers(capped.Metrics[0]))
+
+	// Raw series above the cap are downsampled
+	downsampled := query(0, pb.MetricAggregation_METRIC_AGGREGATION_UNSPECIFIED, 4)
+	assert.Equal(t, int64(900), downsampled.StepSeconds)
+	assert.Equal(t, []float64{7, 22, 37, 52}, numbers(downsampled.Metrics[0]))
+
+	_, err = client.GetDeviceMetrics(ctx, connect.NewRequest(&pb.GetDeviceMetricsRequest{
+		DeviceId:  "test-device",
+		TimeRange: &pb.TimeRange{StartTime: timeRange.EndTime, EndTime: timeRange.StartTime},
+	}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+}
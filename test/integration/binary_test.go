@@ -178,3 +178,105 @@ func TestBinaryServiceIntegration(t *testing.T) {
 	assert.Len(t, listResp.Msg.Binaries, 1)
 	assert.Equal(t, "test-app", listResp.Msg.Binaries[0].Name)
 }
+
+func TestBinaryDeduplication(t *testing.T) {
+	tmpDir := t.TempDir()
+	db, err := sql.Open("sqlite", filepath.Join(tmpDir, "test.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	_, _, err = migrations.MigrateUp(db)
+	require.NoError(t, err)
+
+	storagePath := filepath.Join(tmpDir, "binaries")
+	binaryService, err := api.NewBinaryService(db, storagePath)
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.Handle(rpc.NewBinaryServiceHandler(binaryService))
+	server := httptest.NewServer(h2c.NewHandler(mux, &http2.Server{}))
+	defer server.Close()
+
+	client := rpc.NewBinaryServiceClient(http.DefaultClient, server.URL)
+	ctx := context.Background()
+
+	upload := func(name string, data []byte) string {
+		stream := client.UploadBinary(ctx)
+		require.NoError(t, stream.Send(&pb.UploadBinaryRequest{
+			Data: &pb.UploadBinaryRequest_Metadata{
+				Metadata: &pb.BinaryMetadata{Name: name, Version: "1.0.0", Platform: "linux", Architecture: "arm64"},
+			},
+		}))
+		require.NoError(t, stream.Send(&pb.UploadBinaryRequest{
+			Data: &pb.UploadBinaryRequest_Chunk{Chunk: data},
+		}))
+		resp, err := stream.CloseAndReceive()
+		require.NoError(t, err)
+		return resp.Msg.Id
+	}
+	countBlobs := func() int {
+		var count int
+		err := filepath.WalkDir(storagePath, func(_ string, d os.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				count++
+			}
+			return err
+		})
+		require.NoError(t, err)
+		return count
+	}
+	download := func(id string) []byte {
+		stream, err := client.DownloadBinary(ctx, connect.NewRequest(&pb.DownloadBinaryRequest{Id: id}))
+		require.NoError(t, err)
+		var data []byte
+		for stream.Receive() {
+			data = append(data, stream.Msg().Chunk...)
+		}
+		require.NoError(t, stream.Err())
+		return data
+	}
+
+	firmware := []byte("firmware image v1")
+	fleetA := upload("fleet-a-firmware", firmware)
+	fleetB := upload("fleet-b-firmware", firmware)
+	other := upload("other-firmware", []byte("firmware image v2"))
+
+	// Identical uploads share one blob
+	assert.Equal(t, 2, countBlobs())
+	stats, err := binaryService.StorageStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, api.BinaryStorageStats{
+		Binaries:     3,
+		Blobs:        2,
+		LogicalBytes: int64(2*len(firmware) + len("firmware image v2")),
+		StoredBytes:  int64(len(firmware) + len("firmware image v2")),
+	}, stats)
+
+	// Deleting one reference keeps the shared blob
+	_, err = client.DeleteBinary(ctx, connect.NewRequest(&pb.DeleteBinaryRequest{Id: fleetA}))
+	require.NoError(t, err)
+	assert.Equal(t, 2, countBlobs())
+	assert.Equal(t, firmware, download(fleetB))
+
+	// Dropping the last reference removes the blob
+	_, err = client.DeleteBinary(ctx, connect.NewRequest(&pb.DeleteBinaryRequest{Id: fleetB}))
+	require.NoError(t, err)
+	assert.Equal(t, 1, countBlobs())
+
+	_, err = client.GetBinary(ctx, connect.NewRequest(&pb.GetBinaryRequest{Id: fleetB}))
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+	_, err = client.DeleteBinary(ctx, connect.NewRequest(&pb.DeleteBinaryRequest{Id: fleetB}))
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+
+	// Binaries used by a campaign cannot be deleted
+	_, err = db.Exec(
+		`INSERT INTO update_campaign (id, name, description, binary_id, target_version, target_platforms, target_architectures, strategy, status)
+		 VALUES ('campaign-1', 'rollout', '', ?, '1.0.0', 'linux', 'arm64', 'immediate', 'created')`, other)
+	require.NoError(t, err)
+	_, err = client.DeleteBinary(ctx, connect.NewRequest(&pb.DeleteBinaryRequest{Id: other}))
+	assert.Equal(t, connect.CodeFailedPrecondition, connect.CodeOf(err))
+
+	stats, err = binaryService.StorageStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Binaries)
+	assert.Equal(t, 1, stats.Blobs)
+}
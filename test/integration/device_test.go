@@ -3,18 +3,24 @@ package integration
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"connectrpc.com/connect"
+	"connectrpc.com/grpchealth"
 	pb "fleetd.sh/gen/fleetd/v1"
 	rpc "fleetd.sh/gen/fleetd/v1/fleetpbconnect"
 	"fleetd.sh/internal/api"
+	"fleetd.sh/internal/health"
 	"fleetd.sh/internal/migrations"
+	"fleetd.sh/internal/webhook"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
 
@@ -42,6 +48,7 @@ func setupDeviceServer(t *testing.T) (*http.Server, *httptest.Server, *sql.DB, f
 		deviceService,
 		connect.WithCompressMinBytes(1024),
 	))
+	mux.Handle(grpchealth.NewHandler(health.NewChecker().AddCheck(rpc.DeviceServiceName, health.DBCheck(db))))
 
 	// Create test server
 	server := httptest.NewServer(h2c.NewHandler(mux, &http2.Server{}))
@@ -55,6 +62,32 @@ func setupDeviceServer(t *testing.T) (*http.Server, *httptest.Server, *sql.DB, f
 	return &http.Server{Handler: mux}, server, db, cleanup
 }
 
+func TestDeviceServerHealth(t *testing.T) {
+	_, server, db, cleanup := setupDeviceServer(t)
+	defer cleanup()
+
+	checkHealth := func() *http.Response {
+		resp, err := http.Post(server.URL+"/grpc.health.v1.Health/Check", "application/json",
+			strings.NewReader(`{"service":"`+rpc.DeviceServiceName+`"}`))
+		require.NoError(t, err)
+		return resp
+	}
+
+	var msg struct {
+		Status string `json:"status"`
+	}
+	resp := checkHealth()
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&msg))
+	resp.Body.Close()
+	assert.Equal(t, "SERVING_STATUS_SERVING", msg.Status)
+
+	require.NoError(t, db.Close())
+	resp = checkHealth()
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&msg))
+	resp.Body.Close()
+	assert.Equal(t, "SERVING_STATUS_NOT_SERVING", msg.Status)
+}
+
 func TestDeviceRegistrationFlow(t *testing.T) {
 	_, server, db, cleanup := setupDeviceServer(t)
 	defer cleanup()
@@ -128,3 +161,754 @@ func TestDeviceRegistrationFlow(t *testing.T) {
 	assert.Contains(t, metadata, "memory")
 	assert.Contains(t, metadata, "disk")
 }
+
+func TestIdempotentRegistration(t *testing.T) {
+	_, server, db, cleanup := setupDeviceServer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	client := rpc.NewDeviceServiceClient(
+		http.DefaultClient,
+		server.URL,
+	)
+
+	first, err := client.Register(ctx, connect.NewRequest(&pb.RegisterRequest{
+		Name:       "sensor",
+		Type:       "raspberry-pi",
+		Version:    "1.0.0",
+		HardwareId: "hw-1234",
+	}))
+	require.NoError(t, err)
+	assert.False(t, first.Msg.Reregistered)
+
+	// Registering again after a re-flash returns the same device and key
+	second, err := client.Register(ctx, connect.NewRequest(&pb.RegisterRequest{
+		Name:       "sensor",
+		Type:       "raspberry-pi",
+		Version:    "1.1.0",
+		HardwareId: "hw-1234",
+	}))
+	require.NoError(t, err)
+	assert.True(t, second.Msg.Reregistered)
+	assert.Equal(t, first.Msg.DeviceId, second.Msg.DeviceId)
+	assert.Equal(t, first.Msg.ApiKey, second.Msg.ApiKey)
+
+	device, err := client.GetDevice(ctx, connect.NewRequest(&pb.GetDeviceRequest{DeviceId: first.Msg.DeviceId}))
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", device.Msg.Device.Version)
+
+	// The key can be rotated on re-registration
+	rotated, err := client.Register(ctx, connect.NewRequest(&pb.RegisterRequest{
+		Name:         "sensor",
+		Type:         "raspberry-pi",
+		Version:      "1.1.0",
+		HardwareId:   "hw-1234",
+		RotateApiKey: true,
+	}))
+	require.NoError(t, err)
+	assert.True(t, rotated.Msg.Reregistered)
+	assert.Equal(t, first.Msg.DeviceId, rotated.Msg.DeviceId)
+	assert.NotEqual(t, first.Msg.ApiKey, rotated.Msg.ApiKey)
+
+	// An archived device is restored rather than registered anew
+	later := time.Now().Add(60 * 24 * time.Hour)
+	archived, err := api.NewDeviceArchiver(db, 30*24*time.Hour).
+		WithClock(func() time.Time { return later }).
+		ArchiveIdle(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, archived)
+
+	restored, err := client.Register(ctx, connect.NewRequest(&pb.RegisterRequest{
+		Name:       "sensor",
+		Type:       "raspberry-pi",
+		HardwareId: "hw-1234",
+	}))
+	require.NoError(t, err)
+	assert.True(t, restored.Msg.Reregistered)
+	assert.Equal(t, first.Msg.DeviceId, restored.Msg.DeviceId)
+
+	// Devices without a hardware ID are always new
+	for i := 0; i < 2; i++ {
+		resp, err := client.Register(ctx, connect.NewRequest(&pb.RegisterRequest{Name: "anonymous", Type: "raspberry-pi"}))
+		require.NoError(t, err)
+		assert.False(t, resp.Msg.Reregistered)
+	}
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM device").Scan(&count))
+	assert.Equal(t, 3, count)
+}
+
+func TestFleetMembership(t *testing.T) {
+	_, server, _, cleanup := setupDeviceServer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	client := rpc.NewDeviceServiceClient(
+		http.DefaultClient,
+		server.URL,
+	)
+
+	var deviceIDs []string
+	for i := 0; i < 5; i++ {
+		resp, err := client.Register(ctx, connect.NewRequest(&pb.RegisterRequest{
+			Name: fmt.Sprintf("sensor-%d", i),
+			Type: "raspberry-pi",
+		}))
+		require.NoError(t, err)
+		deviceIDs = append(deviceIDs, resp.Msg.DeviceId)
+	}
+
+	// New devices start in the default fleet
+	device, err := client.GetDevice(ctx, connect.NewRequest(&pb.GetDeviceRequest{DeviceId: deviceIDs[0]}))
+	require.NoError(t, err)
+	assert.Equal(t, api.DefaultFleetID, device.Msg.Device.FleetId)
+
+	edge, err := client.CreateFleet(ctx, connect.NewRequest(&pb.CreateFleetRequest{Name: "edge"}))
+	require.NoError(t, err)
+	lab, err := client.CreateFleet(ctx, connect.NewRequest(&pb.CreateFleetRequest{Name: "lab"}))
+	require.NoError(t, err)
+
+	_, err = client.CreateFleet(ctx, connect.NewRequest(&pb.CreateFleetRequest{Name: "edge"}))
+	assert.Equal(t, connect.CodeAlreadyExists, connect.CodeOf(err))
+
+	fleets, err := client.ListFleets(ctx, connect.NewRequest(&pb.ListFleetsRequest{}))
+	require.NoError(t, err)
+	assert.Len(t, fleets.Msg.Fleets, 3)
+
+	edgeID := edge.Msg.Fleet.Id
+	for _, id := range deviceIDs[:4] {
+		resp, err := client.AddDeviceToFleet(ctx, connect.NewRequest(&pb.AddDeviceToFleetRequest{
+			DeviceId: id,
+			FleetId:  edgeID,
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, api.DefaultFleetID, resp.Msg.PreviousFleetId)
+	}
+
+	// Adding a device to its own fleet again is a no-op
+	_, err = client.AddDeviceToFleet(ctx, connect.NewRequest(&pb.AddDeviceToFleetRequest{
+		DeviceId: deviceIDs[0],
+		FleetId:  edgeID,
+	}))
+	require.NoError(t, err)
+
+	// A device belongs to at most one fleet
+	_, err = client.AddDeviceToFleet(ctx, connect.NewRequest(&pb.AddDeviceToFleetRequest{
+		DeviceId: deviceIDs[0],
+		FleetId:  lab.Msg.Fleet.Id,
+	}))
+	assert.Equal(t, connect.CodeFailedPrecondition, connect.CodeOf(err))
+
+	_, err = client.AddDeviceToFleet(ctx, connect.NewRequest(&pb.AddDeviceToFleetRequest{
+		DeviceId: deviceIDs[4],
+		FleetId:  "missing",
+	}))
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+	_, err = client.AddDeviceToFleet(ctx, connect.NewRequest(&pb.AddDeviceToFleetRequest{
+		DeviceId: "missing",
+		FleetId:  edgeID,
+	}))
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+
+	// Fleet-scoped listing pages through the fleet's devices only
+	var listed []string
+	token := ""
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 3)
+		resp, err := client.ListDevices(ctx, connect.NewRequest(&pb.ListDevicesRequest{
+			FleetId:   edgeID,
+			PageSize:  3,
+			PageToken: token,
+		}))
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(resp.Msg.Devices), 3)
+		for _, d := range resp.Msg.Devices {
+			assert.Equal(t, edgeID, d.FleetId)
+			listed = append(listed, d.Id)
+		}
+		if token = resp.Msg.NextPageToken; token == "" {
+			break
+		}
+	}
+	assert.ElementsMatch(t, deviceIDs[:4], listed)
+
+	// Removing a device returns it to the default fleet
+	_, err = client.RemoveDeviceFromFleet(ctx, connect.NewRequest(&pb.RemoveDeviceFromFleetRequest{
+		DeviceId: deviceIDs[0],
+		FleetId:  lab.Msg.Fleet.Id,
+	}))
+	assert.Equal(t, connect.CodeFailedPrecondition, connect.CodeOf(err))
+
+	_, err = client.RemoveDeviceFromFleet(ctx, connect.NewRequest(&pb.RemoveDeviceFromFleetRequest{
+		DeviceId: deviceIDs[0],
+		FleetId:  edgeID,
+	}))
+	require.NoError(t, err)
+
+	defaults, err := client.ListDevices(ctx, connect.NewRequest(&pb.ListDevicesRequest{FleetId: api.DefaultFleetID}))
+	require.NoError(t, err)
+	assert.Len(t, defaults.Msg.Devices, 2)
+	assert.Empty(t, defaults.Msg.NextPageToken)
+
+	// The device is now free to join another fleet
+	_, err = client.AddDeviceToFleet(ctx, connect.NewRequest(&pb.AddDeviceToFleetRequest{
+		DeviceId: deviceIDs[0],
+		FleetId:  lab.Msg.Fleet.Id,
+	}))
+	require.NoError(t, err)
+}
+
+func TestDeviceSelectors(t *testing.T) {
+	_, server, _, cleanup := setupDeviceServer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	client := rpc.NewDeviceServiceClient(
+		http.DefaultClient,
+		server.URL,
+	)
+
+	seed := []struct {
+		name, deviceType string
+		tags             map[string]string
+	}{
+		{"pi-east-prod", "rpi", map[string]string{"region": "us-east", "env": "prod"}},
+		{"pi-west-prod", "rpi", map[string]string{"region": "us-west", "env": "prod"}},
+		{"pi-east-dev", "rpi", map[string]string{"region": "us-east", "env": "dev"}},
+		{"nuc-east-prod", "nuc", map[string]string{"region": "us-east", "env": "prod"}},
+		{"pi-eu-prod", "rpi", map[string]string{"region": "eu-west", "env": "prod"}},
+		{"pi-untagged", "rpi", nil},
+	}
+	ids := make(map[string]string)
+	for _, d := range seed {
+		resp, err := client.Register(ctx, connect.NewRequest(&pb.RegisterRequest{Name: d.name, Type: d.deviceType}))
+		require.NoError(t, err)
+		ids[d.name] = resp.Msg.DeviceId
+
+		if d.tags != nil {
+			tags, err := client.SetDeviceTags(ctx, connect.NewRequest(&pb.SetDeviceTagsRequest{
+				DeviceId: resp.Msg.DeviceId,
+				Tags:     d.tags,
+			}))
+			require.NoError(t, err)
+			assert.Equal(t, d.tags, tags.Msg.Tags)
+		}
+	}
+
+	selectNames := func(selector string) []string {
+		resp, err := client.ListDevices(ctx, connect.NewRequest(&pb.ListDevicesRequest{Selector: selector}))
+		require.NoError(t, err)
+		var names []string
+		for _, d := range resp.Msg.Devices {
+			names = append(names, d.Name)
+		}
+		return names
+	}
+
+	assert.ElementsMatch(t, []string{"pi-east-prod", "pi-west-prod"},
+		selectNames("type=rpi AND region in (us-east, us-west) AND env=prod"))
+	assert.ElementsMatch(t, []string{"pi-east-prod", "pi-east-dev", "nuc-east-prod"},
+		selectNames("region=us-east"))
+	assert.ElementsMatch(t, []string{"nuc-east-prod"},
+		selectNames(`type in (nuc) and env="prod"`))
+	assert.Empty(t, selectNames("env=staging"))
+	assert.Len(t, selectNames(""), len(seed))
+
+	_, err := client.ListDevices(ctx, connect.NewRequest(&pb.ListDevicesRequest{Selector: "env=prod OR env=dev"}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+	// Tags are returned with devices and can be removed
+	device, err := client.GetDevice(ctx, connect.NewRequest(&pb.GetDeviceRequest{DeviceId: ids["pi-east-dev"]}))
+	require.NoError(t, err)
+	assert.Equal(t, "dev", device.Msg.Device.Tags["env"])
+
+	tags, err := client.SetDeviceTags(ctx, connect.NewRequest(&pb.SetDeviceTagsRequest{
+		DeviceId:   ids["pi-east-dev"],
+		Tags:       map[string]string{"env": "prod"},
+		RemoveKeys: []string{"region"},
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"env": "prod"}, tags.Msg.Tags)
+	assert.ElementsMatch(t, []string{"pi-east-prod", "nuc-east-prod"}, selectNames("region=us-east"))
+
+	// Tag keys must be addressable by selectors
+	for _, key := range []string{"type", "bad key", ""} {
+		_, err = client.SetDeviceTags(ctx, connect.NewRequest(&pb.SetDeviceTagsRequest{
+			DeviceId: ids["pi-untagged"],
+			Tags:     map[string]string{key: "x"},
+		}))
+		assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err), key)
+	}
+	_, err = client.SetDeviceTags(ctx, connect.NewRequest(&pb.SetDeviceTagsRequest{
+		DeviceId: "missing",
+		Tags:     map[string]string{"env": "prod"},
+	}))
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+}
+
+func TestBatchUpdateDeviceStatus(t *testing.T) {
+	_, server, db, cleanup := setupDeviceServer(t)
+	defer cleanup()
+
+	client := rpc.NewDeviceServiceClient(
+		http.DefaultClient,
+		server.URL,
+	)
+
+	var deviceIDs []string
+	for _, name := range []string{"device-1", "device-2", "device-3"} {
+		resp, err := client.Register(context.Background(), connect.NewRequest(&pb.RegisterRequest{
+			Name:    name,
+			Type:    "raspberry-pi",
+			Version: "1.0.0",
+		}))
+		require.NoError(t, err)
+		deviceIDs = append(deviceIDs, resp.Msg.DeviceId)
+	}
+
+	resp, err := client.BatchUpdateDeviceStatus(context.Background(), connect.NewRequest(&pb.BatchUpdateDeviceStatusRequest{
+		DeviceIds: append(deviceIDs, "missing-device"),
+		Status:    "offline",
+		Reason:    "network outage",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), resp.Msg.UpdatedCount)
+	require.Len(t, resp.Msg.Results, 4)
+
+	for _, result := range resp.Msg.Results[:3] {
+		assert.True(t, result.Success)
+		assert.Equal(t, "unknown", result.PreviousStatus)
+		assert.Empty(t, result.Error)
+	}
+	assert.Equal(t, "missing-device", resp.Msg.Results[3].DeviceId)
+	assert.False(t, resp.Msg.Results[3].Success)
+	assert.Equal(t, "device not found", resp.Msg.Results[3].Error)
+
+	for _, deviceID := range deviceIDs {
+		var status string
+		err := db.QueryRowContext(context.Background(),
+			"SELECT status FROM device WHERE id = ?", deviceID).Scan(&status)
+		require.NoError(t, err)
+		assert.Equal(t, "offline", status)
+
+		var previous, reason string
+		err = db.QueryRowContext(context.Background(),
+			"SELECT previous_status, reason FROM device_status_event WHERE device_id = ? AND status = 'offline'",
+			deviceID).Scan(&previous, &reason)
+		require.NoError(t, err)
+		assert.Equal(t, "unknown", previous)
+		assert.Equal(t, "network outage", reason)
+	}
+
+	_, err = client.BatchUpdateDeviceStatus(context.Background(), connect.NewRequest(&pb.BatchUpdateDeviceStatusRequest{
+		DeviceIds: deviceIDs,
+	}))
+	require.Error(t, err)
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+}
+
+func TestBatchUpdateDeviceStatusSelector(t *testing.T) {
+	_, server, db, cleanup := setupDeviceServer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	client := rpc.NewDeviceServiceClient(
+		http.DefaultClient,
+		server.URL,
+	)
+
+	devices := make(map[string]string)
+	for _, spec := range []struct{ name, devType string }{
+		{"pi-1", "raspberry-pi"},
+		{"pi-2", "raspberry-pi"},
+		{"jetson-1", "jetson"},
+	} {
+		resp, err := client.Register(ctx, connect.NewRequest(&pb.RegisterRequest{
+			Name:    spec.name,
+			Type:    spec.devType,
+			Version: "1.0.0",
+		}))
+		require.NoError(t, err)
+		devices[spec.name] = resp.Msg.DeviceId
+	}
+
+	// The selector and the listed IDs are combined, without duplicates
+	resp, err := client.BatchUpdateDeviceStatus(ctx, connect.NewRequest(&pb.BatchUpdateDeviceStatusRequest{
+		DeviceIds: []string{devices["pi-1"]},
+		Selector:  "type=raspberry-pi",
+		Status:    "maintenance",
+		Reason:    "firmware rollout",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), resp.Msg.UpdatedCount)
+	require.Len(t, resp.Msg.Results, 2)
+	assert.Equal(t, devices["pi-1"], resp.Msg.Results[0].DeviceId)
+	assert.Equal(t, devices["pi-2"], resp.Msg.Results[1].DeviceId)
+
+	for name, deviceID := range devices {
+		var status string
+		err := db.QueryRowContext(ctx, "SELECT status FROM device WHERE id = ?", deviceID).Scan(&status)
+		require.NoError(t, err)
+		if name == "jetson-1" {
+			assert.Equal(t, "unknown", status)
+		} else {
+			assert.Equal(t, "maintenance", status)
+		}
+	}
+
+	for _, req := range []*pb.BatchUpdateDeviceStatusRequest{
+		{Status: "offline"},
+		{Selector: "type in (", Status: "offline"},
+	} {
+		_, err = client.BatchUpdateDeviceStatus(ctx, connect.NewRequest(req))
+		assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err), "request %+v", req)
+	}
+}
+
+func TestBatchRegisterDevices(t *testing.T) {
+	_, server, db, cleanup := setupDeviceServer(t)
+	defer cleanup()
+
+	client := rpc.NewDeviceServiceClient(
+		http.DefaultClient,
+		server.URL,
+	)
+
+	// Pre-register one device so its hardware ID collides with the batch
+	existing, err := client.BatchRegisterDevices(context.Background(), connect.NewRequest(&pb.BatchRegisterDevicesRequest{
+		Devices: []*pb.DeviceSpec{{Name: "existing", Type: "raspberry-pi", HardwareId: "hw-0"}},
+	}))
+	require.NoError(t, err)
+	require.Equal(t, int32(1), existing.Msg.RegisteredCount)
+
+	const batchSize = 500
+	var specs []*pb.DeviceSpec
+	for i := 0; i < batchSize; i++ {
+		hardwareID := fmt.Sprintf("hw-%d", i)
+		if i%50 == 49 {
+			// Duplicate of an earlier entry in the same batch
+			hardwareID = fmt.Sprintf("hw-%d", i-1)
+		}
+		specs = append(specs, &pb.DeviceSpec{
+			Name:       fmt.Sprintf("device-%d", i),
+			Type:       "raspberry-pi",
+			Version:    "1.0.0",
+			HardwareId: hardwareID,
+		})
+	}
+
+	resp, err := client.BatchRegisterDevices(context.Background(), connect.NewRequest(&pb.BatchRegisterDevicesRequest{
+		Devices: specs,
+	}))
+	require.NoError(t, err)
+	require.Len(t, resp.Msg.Results, batchSize)
+
+	// hw-0 already exists and every 50th entry duplicates its predecessor
+	duplicates := 1 + batchSize/50
+	assert.Equal(t, int32(batchSize-duplicates), resp.Msg.RegisteredCount)
+
+	deviceIDs := make(map[string]bool)
+	for i, result := range resp.Msg.Results {
+		assert.Equal(t, int32(i), result.Index)
+		if i == 0 || i%50 == 49 {
+			assert.Equal(t, connect.CodeAlreadyExists.String(), result.ErrorCode, "index %d", i)
+			assert.Empty(t, result.DeviceId)
+			continue
+		}
+		assert.Empty(t, result.ErrorCode, "index %d", i)
+		assert.NotEmpty(t, result.DeviceId)
+		assert.NotEmpty(t, result.ApiKey)
+		deviceIDs[result.DeviceId] = true
+	}
+	assert.Len(t, deviceIDs, batchSize-duplicates)
+
+	var count int
+	err = db.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM device").Scan(&count)
+	require.NoError(t, err)
+	assert.Equal(t, batchSize-duplicates+1, count)
+
+	// Items failing validation are reported without aborting the batch
+	resp, err = client.BatchRegisterDevices(context.Background(), connect.NewRequest(&pb.BatchRegisterDevicesRequest{
+		Devices: []*pb.DeviceSpec{
+			{Name: "", Type: "raspberry-pi", HardwareId: "hw-invalid"},
+			{Name: "valid", Type: "raspberry-pi", HardwareId: "hw-valid"},
+		},
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, connect.CodeInvalidArgument.String(), resp.Msg.Results[0].ErrorCode)
+	assert.Empty(t, resp.Msg.Results[1].ErrorCode)
+	assert.Equal(t, int32(1), resp.Msg.RegisteredCount)
+}
+
+func TestIdleDeviceArchival(t *testing.T) {
+	_, server, db, cleanup := setupDeviceServer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	client := rpc.NewDeviceServiceClient(
+		http.DefaultClient,
+		server.URL,
+	)
+
+	register := func(name string) string {
+		resp, err := client.Register(ctx, connect.NewRequest(&pb.RegisterRequest{
+			Name:    name,
+			Type:    "raspberry-pi",
+			Version: "1.0.0",
+		}))
+		require.NoError(t, err)
+		return resp.Msg.DeviceId
+	}
+	// The idle device never heartbeats, so it is judged by its creation
+	// time. Timestamps have second resolution, so the active device's
+	// heartbeat waits for the next second.
+	idleID := register("idle-device")
+	activeID := register("active-device")
+	time.Sleep(time.Second)
+
+	heartbeatAt := time.Now().Truncate(time.Second)
+	_, err := client.Heartbeat(ctx, connect.NewRequest(&pb.HeartbeatRequest{DeviceId: activeID}))
+	require.NoError(t, err)
+
+	// 90 days after the heartbeat only the idle device has gone quiet
+	later := heartbeatAt.Add(90 * 24 * time.Hour)
+	archiver := api.NewDeviceArchiver(db, 90*24*time.Hour).
+		WithClock(func() time.Time { return later })
+	archived, err := archiver.ArchiveIdle(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, archived)
+
+	countIn := func(table, deviceID string) int {
+		var count int
+		require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM "+table+" WHERE id = ?", deviceID).Scan(&count))
+		return count
+	}
+	assert.Equal(t, 0, countIn("device", idleID))
+	assert.Equal(t, 1, countIn("device_archive", idleID))
+	assert.Equal(t, 1, countIn("device", activeID))
+
+	listed, err := client.ListDevices(ctx, connect.NewRequest(&pb.ListDevicesRequest{}))
+	require.NoError(t, err)
+	require.Len(t, listed.Msg.Devices, 1)
+	assert.Equal(t, activeID, listed.Msg.Devices[0].Id)
+	assert.NotNil(t, listed.Msg.Devices[0].LastSeen)
+
+	_, err = client.GetDevice(ctx, connect.NewRequest(&pb.GetDeviceRequest{DeviceId: idleID}))
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+
+	// Running again finds nothing new to archive
+	archived, err = archiver.ArchiveIdle(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, archived)
+
+	// A heartbeat from the archived device restores it
+	_, err = client.Heartbeat(ctx, connect.NewRequest(&pb.HeartbeatRequest{DeviceId: idleID}))
+	require.NoError(t, err)
+	assert.Equal(t, 1, countIn("device", idleID))
+	assert.Equal(t, 0, countIn("device_archive", idleID))
+
+	var name string
+	require.NoError(t, db.QueryRow("SELECT name FROM device WHERE id = ?", idleID).Scan(&name))
+	assert.Equal(t, "idle-device", name)
+
+	_, err = client.Heartbeat(ctx, connect.NewRequest(&pb.HeartbeatRequest{DeviceId: "unknown"}))
+	require.Error(t, err)
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+}
+
+func TestHeartbeatTracker(t *testing.T) {
+	_, server, db, cleanup := setupDeviceServer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	client := rpc.NewDeviceServiceClient(
+		http.DefaultClient,
+		server.URL,
+	)
+
+	resp, err := client.Register(ctx, connect.NewRequest(&pb.RegisterRequest{
+		Name:    "tracked-device",
+		Type:    "raspberry-pi",
+		Version: "1.0.0",
+	}))
+	require.NoError(t, err)
+	deviceID := resp.Msg.DeviceId
+
+	now := time.Now()
+	var changes []api.StatusChange
+	tracker := api.NewHeartbeatTracker(db, api.HeartbeatTrackerConfig{
+		Interval:      time.Minute,
+		DegradedAfter: 2,
+		OfflineAfter:  5,
+	}).WithClock(func() time.Time { return now }).OnStatusChange(func(_ context.Context, change api.StatusChange) {
+		changes = append(changes, change)
+	})
+
+	status := func() string {
+		resp, err := client.GetDevice(ctx, connect.NewRequest(&pb.GetDeviceRequest{DeviceId: deviceID}))
+		require.NoError(t, err)
+		return resp.Msg.Device.Status
+	}
+	sweep := func() []api.StatusChange {
+		changes = nil
+		swept, err := tracker.Sweep(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, swept, changes)
+		return swept
+	}
+
+	// A device that never sent a heartbeat stays unknown
+	assert.Empty(t, sweep())
+	assert.Equal(t, api.DeviceStatusUnknown, status())
+
+	_, err = client.Heartbeat(ctx, connect.NewRequest(&pb.HeartbeatRequest{DeviceId: deviceID}))
+	require.NoError(t, err)
+	require.Equal(t, []api.StatusChange{{
+		DeviceID: deviceID, PreviousStatus: api.DeviceStatusUnknown, Status: api.DeviceStatusOnline, Time: now.UTC(),
+	}}, sweep())
+	assert.Equal(t, api.DeviceStatusOnline, status())
+
+	// Missing a single heartbeat changes nothing
+	now = now.Add(90 * time.Second)
+	assert.Empty(t, sweep())
+
+	now = now.Add(2 * time.Minute)
+	require.Len(t, sweep(), 1)
+	assert.Equal(t, api.DeviceStatusDegraded, status())
+
+	now = now.Add(5 * time.Minute)
+	offline := sweep()
+	require.Len(t, offline, 1)
+	assert.Equal(t, api.DeviceStatusDegraded, offline[0].PreviousStatus)
+	assert.Equal(t, api.DeviceStatusOffline, status())
+
+	// Sweeping again is a no-op
+	assert.Empty(t, sweep())
+
+	// A heartbeat brings the device back online
+	now = time.Now()
+	_, err = client.Heartbeat(ctx, connect.NewRequest(&pb.HeartbeatRequest{DeviceId: deviceID}))
+	require.NoError(t, err)
+	online := sweep()
+	require.Len(t, online, 1)
+	assert.Equal(t, api.DeviceStatusOffline, online[0].PreviousStatus)
+	assert.Equal(t, api.DeviceStatusOnline, status())
+
+	// Statuses set by operators are not overridden
+	_, err = client.BatchUpdateDeviceStatus(ctx, connect.NewRequest(&pb.BatchUpdateDeviceStatusRequest{
+		DeviceIds: []string{deviceID},
+		Status:    "maintenance",
+	}))
+	require.NoError(t, err)
+	now = now.Add(time.Hour)
+	assert.Empty(t, sweep())
+	assert.Equal(t, "maintenance", status())
+
+	var events int
+	require.NoError(t, db.QueryRow(
+		"SELECT COUNT(*) FROM device_status_event WHERE device_id = ? AND reason = 'heartbeat'", deviceID).Scan(&events))
+	assert.Equal(t, 4, events)
+}
+
+func TestDeviceOfflineWebhook(t *testing.T) {
+	_, server, db, cleanup := setupDeviceServer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	client := rpc.NewDeviceServiceClient(
+		http.DefaultClient,
+		server.URL,
+	)
+
+	received := make(chan webhook.Event, 1)
+	receiver := httptest.NewServer(webhook.SignatureMiddleware("webhook-secret")(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var event webhook.Event
+			if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			received <- event
+		})))
+	defer receiver.Close()
+
+	manager, err := webhook.NewSQLiteWebhookManager(db, nil)
+	require.NoError(t, err)
+	require.NoError(t, manager.Subscribe(ctx, webhook.WebhookConfig{
+		ID:      "offline-alerts",
+		Name:    "Offline alerts",
+		URL:     receiver.URL,
+		Secret:  "webhook-secret",
+		Events:  []webhook.EventType{webhook.EventDeviceOffline},
+		Enabled: true,
+	}))
+
+	resp, err := client.Register(ctx, connect.NewRequest(&pb.RegisterRequest{
+		Name:    "alerting-device",
+		Type:    "raspberry-pi",
+		Version: "1.0.0",
+	}))
+	require.NoError(t, err)
+	_, err = client.Heartbeat(ctx, connect.NewRequest(&pb.HeartbeatRequest{DeviceId: resp.Msg.DeviceId}))
+	require.NoError(t, err)
+
+	now := time.Now()
+	tracker := api.NewHeartbeatTracker(db, api.DefaultHeartbeatTrackerConfig()).
+		WithClock(func() time.Time { return now }).
+		OnStatusChange(api.DeviceOfflineNotifier(manager))
+
+	// Coming online does not notify
+	_, err = tracker.Sweep(ctx)
+	require.NoError(t, err)
+
+	now = now.Add(time.Hour)
+	_, err = tracker.Sweep(ctx)
+	require.NoError(t, err)
+
+	select {
+	case event := <-received:
+		assert.Equal(t, webhook.EventDeviceOffline, event.Type)
+		data, ok := event.Data.(map[string]any)
+		require.True(t, ok)
+		assert.Equal(t, resp.Msg.DeviceId, data["device_id"])
+		assert.Equal(t, api.DeviceStatusOnline, data["previous_status"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("device.offline webhook was not delivered")
+	}
+	assert.Empty(t, received)
+}
+
+func TestIdleDeviceArchivalForeignKeys(t *testing.T) {
+	// Every connection of this database enforces foreign keys
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db")+"?_pragma=foreign_keys(1)")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	_, _, err = migrations.MigrateUp(db)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = db.Exec(`INSERT INTO device (id, name, type, version, api_key)
+		VALUES ('idle', 'idle-device', 'raspberry-pi', '1.0.0', 'key')`)
+	require.NoError(t, err)
+	// History that keeps referencing the device while it is archived
+	_, err = db.Exec(`INSERT INTO device_health (device_id, status) VALUES ('idle', 'healthy')`)
+	require.NoError(t, err)
+
+	later := time.Now().Add(100 * 24 * time.Hour)
+	archived, err := api.NewDeviceArchiver(db, 90*24*time.Hour).
+		WithClock(func() time.Time { return later }).
+		ArchiveIdle(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, archived)
+
+	var health int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM device_health WHERE device_id = 'idle'").Scan(&health))
+	assert.Equal(t, 1, health)
+
+	// The connection the move disabled foreign keys on isn't reused
+	var foreignKeys int
+	require.NoError(t, db.QueryRow("PRAGMA foreign_keys").Scan(&foreignKeys))
+	assert.Equal(t, 1, foreignKeys)
+}
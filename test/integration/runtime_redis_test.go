@@ -47,7 +47,7 @@ func TestRedisDeployment(t *testing.T) {
 		t.Fatalf("Failed to read Redis binary: %v", err)
 	}
 
-	if err := rt.Deploy("redis-server", bytes.NewReader(redisData)); err != nil {
+	if err := rt.Deploy("redis-server", bytes.NewReader(redisData), ""); err != nil {
 		t.Fatalf("Failed to deploy Redis: %v", err)
 	}
 
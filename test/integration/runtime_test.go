@@ -34,7 +34,7 @@ sleep 10
 	}
 
 	// Deploy binary
-	if err := rt.Deploy("test", bytes.NewReader(data)); err != nil {
+	if err := rt.Deploy("test", bytes.NewReader(data), ""); err != nil {
 		t.Fatalf("Failed to deploy binary: %v", err)
 	}
 
@@ -20,6 +20,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
+	"google.golang.org/protobuf/proto"
 )
 
 func setupUpdateServer(t *testing.T) (*http.Server, *httptest.Server, *sql.DB, func()) {
@@ -248,3 +249,317 @@ func parseTimestamp(s string) (*time.Time, error) {
 	}
 	return &t, nil
 }
+
+func installCampaign(t *testing.T, ctx context.Context, client rpc.UpdateServiceClient, campaignID string, deviceIDs ...string) {
+	for _, deviceID := range deviceIDs {
+		_, err := client.ReportUpdateStatus(ctx, connect.NewRequest(&pb.ReportUpdateStatusRequest{
+			DeviceId:   deviceID,
+			CampaignId: campaignID,
+			Status:     pb.DeviceUpdateStatus_DEVICE_UPDATE_STATUS_INSTALLED,
+		}))
+		require.NoError(t, err)
+	}
+}
+
+func TestUpdateCampaignRollback(t *testing.T) {
+	_, server, db, cleanup := setupUpdateServer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	client := rpc.NewUpdateServiceClient(http.DefaultClient, server.URL)
+
+	deviceIDs := []string{"device-1", "device-2"}
+	for _, deviceID := range deviceIDs {
+		setupTestDevice(t, db, deviceID)
+	}
+
+	createCampaign := func(binaryID, version string) string {
+		resp, err := client.CreateUpdateCampaign(ctx, connect.NewRequest(&pb.CreateUpdateCampaignRequest{
+			Name:            "Update to " + version,
+			BinaryId:        binaryID,
+			TargetVersion:   version,
+			TargetPlatforms: []string{"raspberry-pi"},
+			Strategy:        pb.UpdateStrategy_UPDATE_STRATEGY_IMMEDIATE,
+		}))
+		require.NoError(t, err)
+		return resp.Msg.CampaignId
+	}
+
+	v1Binary := uploadTestUpdateBinary(t, server.URL)
+	v1Campaign := createCampaign(v1Binary, "1.0.0")
+	installCampaign(t, ctx, client, v1Campaign, deviceIDs...)
+
+	// Nothing was installed before v1, so it cannot be rolled back
+	_, err := client.RollbackUpdateCampaign(ctx, connect.NewRequest(&pb.RollbackUpdateCampaignRequest{
+		CampaignId: v1Campaign,
+	}))
+	require.Error(t, err)
+	assert.Equal(t, connect.CodeFailedPrecondition, connect.CodeOf(err))
+
+	v2Binary := uploadTestUpdateBinary(t, server.URL)
+	v2Campaign := createCampaign(v2Binary, "2.0.0")
+	installCampaign(t, ctx, client, v2Campaign, deviceIDs...)
+
+	var version string
+	require.NoError(t, db.QueryRow("SELECT version FROM device WHERE id = ?", "device-1").Scan(&version))
+	assert.Equal(t, "2.0.0", version)
+
+	rollback, err := client.RollbackUpdateCampaign(ctx, connect.NewRequest(&pb.RollbackUpdateCampaignRequest{
+		CampaignId: v2Campaign,
+	}))
+	require.NoError(t, err)
+	require.Len(t, rollback.Msg.CampaignIds, 1)
+	assert.Empty(t, rollback.Msg.SkippedDeviceIds)
+
+	// Rolling back again is idempotent
+	again, err := client.RollbackUpdateCampaign(ctx, connect.NewRequest(&pb.RollbackUpdateCampaignRequest{
+		CampaignId: v2Campaign,
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, rollback.Msg.CampaignIds, again.Msg.CampaignIds)
+
+	original, err := client.GetUpdateCampaign(ctx, connect.NewRequest(&pb.GetUpdateCampaignRequest{CampaignId: v2Campaign}))
+	require.NoError(t, err)
+	assert.Equal(t, pb.UpdateCampaignStatus_UPDATE_CAMPAIGN_STATUS_ROLLED_BACK, original.Msg.Campaign.Status)
+
+	rollbackCampaign, err := client.GetUpdateCampaign(ctx, connect.NewRequest(&pb.GetUpdateCampaignRequest{CampaignId: rollback.Msg.CampaignIds[0]}))
+	require.NoError(t, err)
+	assert.Equal(t, v1Binary, rollbackCampaign.Msg.Campaign.BinaryId)
+	assert.Equal(t, "1.0.0", rollbackCampaign.Msg.Campaign.TargetVersion)
+	assert.Equal(t, int32(2), rollbackCampaign.Msg.Campaign.TotalDevices)
+
+	installCampaign(t, ctx, client, rollback.Msg.CampaignIds[0], deviceIDs...)
+
+	for _, deviceID := range deviceIDs {
+		require.NoError(t, db.QueryRow("SELECT version FROM device WHERE id = ?", deviceID).Scan(&version))
+		assert.Equal(t, "1.0.0", version)
+
+		history, err := client.GetDeviceVersionHistory(ctx, connect.NewRequest(&pb.GetDeviceVersionHistoryRequest{
+			DeviceId: deviceID,
+		}))
+		require.NoError(t, err)
+		require.Len(t, history.Msg.Versions, 3)
+		assert.Equal(t, "1.0.0", history.Msg.Versions[0].Version)
+		assert.Equal(t, "2.0.0", history.Msg.Versions[1].Version)
+		assert.Equal(t, "1.0.0", history.Msg.Versions[2].Version)
+		assert.NotEmpty(t, history.Msg.Versions[0].Sha256)
+		assert.NotNil(t, history.Msg.Versions[0].InstalledAt)
+	}
+}
+
+func TestUpdateCampaignSelector(t *testing.T) {
+	_, server, db, cleanup := setupUpdateServer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	client := rpc.NewUpdateServiceClient(http.DefaultClient, server.URL)
+
+	tags := map[string]map[string]string{
+		"device-1": {"env": "prod", "region": "us-east"},
+		"device-2": {"env": "prod", "region": "eu-west"},
+		"device-3": {"env": "dev", "region": "us-east"},
+		"device-4": {},
+	}
+	for deviceID, deviceTags := range tags {
+		setupTestDevice(t, db, deviceID)
+		for key, value := range deviceTags {
+			_, err := db.Exec("INSERT INTO device_tag (device_id, key, value) VALUES (?, ?, ?)", deviceID, key, value)
+			require.NoError(t, err)
+		}
+	}
+
+	binaryID := uploadTestUpdateBinary(t, server.URL)
+	resp, err := client.CreateUpdateCampaign(ctx, connect.NewRequest(&pb.CreateUpdateCampaignRequest{
+		Name:           "Prod in the US",
+		BinaryId:       binaryID,
+		TargetVersion:  "2.0.0",
+		TargetSelector: "env=prod AND region in (us-east, us-west)",
+		Strategy:       pb.UpdateStrategy_UPDATE_STRATEGY_IMMEDIATE,
+	}))
+	require.NoError(t, err)
+
+	campaign, err := client.GetUpdateCampaign(ctx, connect.NewRequest(&pb.GetUpdateCampaignRequest{
+		CampaignId: resp.Msg.CampaignId,
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), campaign.Msg.Campaign.TotalDevices)
+	assert.Equal(t, "env=prod AND region in (us-east, us-west)", campaign.Msg.Campaign.TargetSelector)
+
+	var targeted string
+	require.NoError(t, db.QueryRow("SELECT device_id FROM device_update WHERE campaign_id = ?", resp.Msg.CampaignId).Scan(&targeted))
+	assert.Equal(t, "device-1", targeted)
+
+	_, err = client.CreateUpdateCampaign(ctx, connect.NewRequest(&pb.CreateUpdateCampaignRequest{
+		Name:           "Broken",
+		BinaryId:       binaryID,
+		TargetSelector: "env=",
+	}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+}
+
+func TestUpdateCampaignValidateOnly(t *testing.T) {
+	_, server, db, cleanup := setupUpdateServer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	client := rpc.NewUpdateServiceClient(http.DefaultClient, server.URL)
+
+	for _, deviceID := range []string{"device-1", "device-2"} {
+		setupTestDevice(t, db, deviceID)
+	}
+	binaryID := uploadTestUpdateBinary(t, server.URL)
+
+	countCampaigns := func() int {
+		var n int
+		require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM update_campaign").Scan(&n))
+		return n
+	}
+
+	resp, err := client.CreateUpdateCampaign(ctx, connect.NewRequest(&pb.CreateUpdateCampaignRequest{
+		Name:            "Dry run",
+		BinaryId:        binaryID,
+		TargetVersion:   "1.0.0",
+		TargetPlatforms: []string{"raspberry-pi"},
+		ValidateOnly:    true,
+	}))
+	require.NoError(t, err)
+	assert.Empty(t, resp.Msg.CampaignId)
+	assert.Equal(t, int32(2), resp.Msg.TargetDeviceCount)
+	assert.Empty(t, resp.Msg.Warnings)
+
+	// Problems are reported as warnings
+	resp, err = client.CreateUpdateCampaign(ctx, connect.NewRequest(&pb.CreateUpdateCampaignRequest{
+		Name:           "Dry run",
+		BinaryId:       binaryID,
+		TargetVersion:  "2.0.0",
+		TargetSelector: "type=nuc",
+		ValidateOnly:   true,
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), resp.Msg.TargetDeviceCount)
+	assert.Equal(t, []string{
+		"no devices match the campaign targets",
+		"target_version 2.0.0 does not match binary version 1.0.0",
+	}, resp.Msg.Warnings)
+
+	// Including a binary whose stored content no longer matches its checksum
+	var storagePath string
+	require.NoError(t, db.QueryRow("SELECT storage_path FROM binary WHERE id = ?", binaryID).Scan(&storagePath))
+	require.NoError(t, os.WriteFile(storagePath, []byte("corrupted"), 0644))
+	resp, err = client.CreateUpdateCampaign(ctx, connect.NewRequest(&pb.CreateUpdateCampaignRequest{
+		Name:          "Dry run",
+		BinaryId:      binaryID,
+		TargetVersion: "1.0.0",
+		ValidateOnly:  true,
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"binary content does not match its recorded size and checksum"}, resp.Msg.Warnings)
+
+	// Invalid requests fail as they would without validate_only
+	_, err = client.CreateUpdateCampaign(ctx, connect.NewRequest(&pb.CreateUpdateCampaignRequest{
+		BinaryId:     "missing",
+		ValidateOnly: true,
+	}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+	assert.Equal(t, 0, countCampaigns())
+	var updates int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM device_update").Scan(&updates))
+	assert.Equal(t, 0, updates)
+}
+
+func TestUpdateCampaignMaintenanceWindow(t *testing.T) {
+	date := func(s string) time.Time {
+		ts, err := time.Parse(time.RFC3339, s)
+		require.NoError(t, err)
+		return ts
+	}
+	berlinNights := &pb.MaintenanceWindow{StartTime: "02:00", EndTime: "04:00", TimeZone: "Europe/Berlin"}
+	fridayNights := &pb.MaintenanceWindow{StartTime: "22:00", EndTime: "04:00", Days: []string{"fri"}}
+
+	tests := []struct {
+		name      string
+		window    *pb.MaintenanceWindow
+		now       string
+		hasUpdate bool
+		opensAt   string
+	}{
+		{"no window", nil, "2026-01-15T12:00:00Z", true, ""},
+		{"before window", berlinNights, "2026-01-15T12:00:00Z", false, "2026-01-16T01:00:00Z"},
+		{"inside window", berlinNights, "2026-01-16T01:30:00Z", true, ""},
+		// 03:30 UTC is inside 02:00-04:00 in UTC but 04:30 in Berlin
+		{"window in its time zone", berlinNights, "2026-01-16T03:30:00Z", false, "2026-01-17T01:00:00Z"},
+		// 00:30 UTC is 02:30 in Berlin in summer
+		{"daylight saving time", berlinNights, "2026-07-16T00:30:00Z", true, ""},
+		// Saturday morning is still Friday's window
+		{"past midnight", fridayNights, "2026-01-17T03:00:00Z", true, ""},
+		{"day not in window", fridayNights, "2026-01-18T03:00:00Z", false, "2026-01-23T22:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, server, db, cleanup := setupUpdateServer(t)
+			defer cleanup()
+
+			ctx := context.Background()
+			client := rpc.NewUpdateServiceClient(http.DefaultClient, server.URL)
+			setupTestDevice(t, db, "device-1")
+			binaryID := uploadTestUpdateBinary(t, server.URL)
+
+			resp, err := client.CreateUpdateCampaign(ctx, connect.NewRequest(&pb.CreateUpdateCampaignRequest{
+				Name:              "Scheduled",
+				BinaryId:          binaryID,
+				TargetVersion:     "1.0.0",
+				TargetPlatforms:   []string{"raspberry-pi"},
+				Strategy:          pb.UpdateStrategy_UPDATE_STRATEGY_IMMEDIATE,
+				MaintenanceWindow: tt.window,
+			}))
+			require.NoError(t, err)
+
+			now := date(tt.now)
+			devices := api.NewDeviceService(db).WithClock(func() time.Time { return now })
+			heartbeat, err := devices.Heartbeat(ctx, connect.NewRequest(&pb.HeartbeatRequest{DeviceId: "device-1"}))
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.hasUpdate, heartbeat.Msg.HasUpdate)
+			if tt.hasUpdate {
+				assert.Equal(t, resp.Msg.CampaignId, heartbeat.Msg.UpdateId)
+				assert.Nil(t, heartbeat.Msg.UpdateWindowOpensAt)
+			} else {
+				assert.Empty(t, heartbeat.Msg.UpdateId)
+				require.NotNil(t, heartbeat.Msg.UpdateWindowOpensAt)
+				assert.Equal(t, date(tt.opensAt), heartbeat.Msg.UpdateWindowOpensAt.AsTime())
+			}
+
+			// The window is stored with the campaign
+			campaign, err := client.GetUpdateCampaign(ctx, connect.NewRequest(&pb.GetUpdateCampaignRequest{
+				CampaignId: resp.Msg.CampaignId,
+			}))
+			require.NoError(t, err)
+			assert.True(t, proto.Equal(tt.window, campaign.Msg.Campaign.MaintenanceWindow))
+		})
+	}
+}
+
+func TestUpdateCampaignMaintenanceWindowInvalid(t *testing.T) {
+	_, server, _, cleanup := setupUpdateServer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	client := rpc.NewUpdateServiceClient(http.DefaultClient, server.URL)
+	binaryID := uploadTestUpdateBinary(t, server.URL)
+
+	for _, window := range []*pb.MaintenanceWindow{
+		{StartTime: "2am", EndTime: "04:00"},
+		{StartTime: "02:00", EndTime: "02:00"},
+		{StartTime: "02:00", EndTime: "04:00", TimeZone: "Mars/Olympus_Mons"},
+		{StartTime: "02:00", EndTime: "04:00", Days: []string{"someday"}},
+	} {
+		_, err := client.CreateUpdateCampaign(ctx, connect.NewRequest(&pb.CreateUpdateCampaignRequest{
+			Name:              "Scheduled",
+			BinaryId:          binaryID,
+			MaintenanceWindow: window,
+		}))
+		assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err), "%v", window)
+	}
+}